@@ -2,17 +2,21 @@ use std::path::PathBuf;
 
 use clap::Parser;
 use sp1_core_machine::utils::setup_logger;
-use sp1_prover::build::build_groth16_bn254_artifacts_with_dummy;
+use sp1_prover::build::{build_groth16_bn254_artifacts_with_dummy, set_build_profile};
 
 #[derive(Parser, Debug)]
 #[clap(author, version, about, long_about = None)]
 struct Args {
     #[clap(short, long)]
     build_dir: PathBuf,
+    /// `release`, or `dev` for a small insecure circuit to iterate on integrations with.
+    #[clap(long, default_value = "release")]
+    profile: String,
 }
 
 pub fn main() {
     setup_logger();
     let args = Args::parse();
+    set_build_profile(&args.profile);
     build_groth16_bn254_artifacts_with_dummy(args.build_dir);
 }
@@ -1,4 +1,7 @@
-use std::{borrow::Borrow, path::PathBuf};
+use std::{
+    borrow::Borrow,
+    path::{Path, PathBuf},
+};
 
 use p3_baby_bear::BabyBear;
 use sp1_core_executor::SP1Context;
@@ -13,8 +16,8 @@ use sp1_recursion_compiler::{
     ir::Builder,
 };
 
-use sp1_recursion_core::air::RecursionPublicValues;
 pub use sp1_recursion_core::stark::sp1_dev_mode;
+use sp1_recursion_core::{air::RecursionPublicValues, stark::outer_fri_config};
 
 pub use sp1_recursion_circuit::witness::{OuterWitness, Witnessable};
 
@@ -68,6 +71,7 @@ pub fn build_plonk_bn254_artifacts(
     let build_dir = build_dir.into();
     std::fs::create_dir_all(&build_dir).expect("failed to create build directory");
     let (constraints, witness) = build_constraints_and_witness(template_vk, template_proof);
+    write_fri_config(&build_dir);
     PlonkBn254Prover::build(constraints, witness, build_dir);
 }
 
@@ -81,9 +85,24 @@ pub fn build_groth16_bn254_artifacts(
     let build_dir = build_dir.into();
     std::fs::create_dir_all(&build_dir).expect("failed to create build directory");
     let (constraints, witness) = build_constraints_and_witness(template_vk, template_proof);
+    write_fri_config(&build_dir);
     Groth16Bn254Prover::build(constraints, witness, build_dir);
 }
 
+/// Records the FRI parameters of the wrapped proofs next to the circuit, so that the gnark build
+/// can report the resulting security level and mark dev profile vkeys as insecure.
+fn write_fri_config(build_dir: &Path) {
+    let config = outer_fri_config();
+    let json = serde_json::json!({
+        "log_blowup": config.log_blowup,
+        "num_queries": config.num_queries,
+        "proof_of_work_bits": config.proof_of_work_bits,
+        "profile": if sp1_dev_mode() { "dev" } else { "release" },
+    });
+    std::fs::write(build_dir.join("fri_config.json"), json.to_string())
+        .expect("failed to write FRI config");
+}
+
 /// Builds the plonk bn254 artifacts to the given directory.
 ///
 /// This may take a while as it needs to first generate a dummy proof and then it needs to compile
@@ -118,6 +137,16 @@ pub fn build_groth16_bn254_artifacts_with_dummy(build_dir: impl Into<PathBuf>) {
     crate::build::build_groth16_bn254_artifacts(&wrap_vk, &wrapped_proof, build_dir.into());
 }
 
+/// Selects the dev profile for the rest of the build: the wrapped proofs use a single FRI query,
+/// which makes the wrap circuit drastically smaller but insecure. See [sp1_dev_mode].
+pub fn set_build_profile(profile: &str) {
+    match profile {
+        "release" => {}
+        "dev" => std::env::set_var("SP1_DEV", "true"),
+        _ => panic!("unknown build profile {profile:?}, expected \"release\" or \"dev\""),
+    }
+}
+
 /// Build the verifier constraints and template witness for the circuit.
 pub fn build_constraints_and_witness(
     template_vk: &StarkVerifyingKey<OuterSC>,
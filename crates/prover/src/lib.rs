@@ -1385,6 +1385,12 @@ pub mod tests {
         let wrapped_bn254_proof = prover.wrap_bn254(shrink_proof, opts)?;
         let bytes = bincode::serialize(&wrapped_bn254_proof).unwrap();
 
+        // Export the proof for the decoder tests of the Go library, see TestDecodeRustReduceProof
+        // in crates/recursion/gnark-ffi/go/sp1.
+        if let Ok(path) = std::env::var("SP1_REDUCE_PROOF_FIXTURE") {
+            std::fs::write(path, &bytes).unwrap();
+        }
+
         // Save the proof.
         let mut file = File::create("proof-with-pis.bin").unwrap();
         file.write_all(bytes.as_slice()).unwrap();
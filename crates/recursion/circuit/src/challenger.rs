@@ -562,6 +562,53 @@ pub(crate) mod tests {
         PlonkBn254Prover::test::<C>(constraints, witness);
     }
 
+    #[test]
+    fn test_challenger_outer_sponge_boundaries() {
+        type SC = BabyBearPoseidon2Outer;
+        type F = <SC as StarkGenericConfig>::Val;
+        type N = <C as Config>::N;
+
+        let config = SC::default();
+        let mut builder = Builder::<C>::default();
+
+        // A duplexing absorbs up to six felts (two BN254 elements of three felts each) and outputs
+        // nine, so these lengths cover empty, partial, exact and overflowing absorbs, and the ten
+        // samples that follow always refill the output buffer once.
+        for num_observed in [0, 1, 2, 5, 6, 7, 11, 12, 13] {
+            let mut challenger = config.challenger();
+            let mut challenger_var = MultiField32ChallengerVariable::<C>::new(&mut builder);
+
+            for i in 0..num_observed {
+                let value = F::from_canonical_usize(7 * i + 3);
+                challenger.observe(value);
+                let value: Felt<_> = builder.eval(value);
+                challenger_var.observe(&mut builder, value);
+            }
+            for _ in 0..10 {
+                let expected: F = challenger.sample();
+                let expected: Felt<_> = builder.eval(expected);
+                let element = challenger_var.sample(&mut builder);
+                builder.assert_felt_eq(expected, element);
+            }
+
+            // Absorbing a commitment after sampling must discard the remaining outputs.
+            let commit = N::from_canonical_usize(num_observed + 1);
+            challenger.observe(Hash::from([commit]));
+            let commit: Var<_> = builder.eval(commit);
+            challenger_var.observe_commitment(&mut builder, [commit]);
+            for _ in 0..4 {
+                let expected: F = challenger.sample();
+                let expected: Felt<_> = builder.eval(expected);
+                let element = challenger_var.sample(&mut builder);
+                builder.assert_felt_eq(expected, element);
+            }
+        }
+
+        let mut backend = ConstraintCompiler::<C>::default();
+        let constraints = backend.emit(builder.into_operations());
+        PlonkBn254Prover::test::<C>(constraints, OuterWitness::default());
+    }
+
     #[test]
     fn test_select_chain_digest() {
         type N = <C as Config>::N;
@@ -616,6 +663,28 @@ pub(crate) mod tests {
         PlonkBn254Prover::test::<C>(constraints.clone(), OuterWitness::default());
     }
 
+    #[test]
+    fn test_p2_hash_lengths() {
+        let perm = outer_perm();
+        let hasher = OuterHash::new(perm.clone()).unwrap();
+
+        // Cover inputs shorter than, equal to and spanning multiple absorbs of the sponge.
+        let mut builder = Builder::<C>::default();
+        for len in [1, 2, 3, 5, 6, 7, 12, 13, 16, 17] {
+            let input: Vec<BabyBear> =
+                (0..len).map(|i| BabyBear::from_canonical_usize(5 * i + len)).collect();
+            let output = hasher.hash_iter(input.clone());
+
+            let input: Vec<Felt<_>> = input.into_iter().map(|x| builder.eval(x)).collect();
+            let result = BabyBearPoseidon2Outer::hash(&mut builder, &input);
+            builder.assert_var_eq(result[0], output[0]);
+        }
+
+        let mut backend = ConstraintCompiler::<C>::default();
+        let constraints = backend.emit(builder.into_operations());
+        PlonkBn254Prover::test::<C>(constraints, OuterWitness::default());
+    }
+
     #[test]
     fn test_p2_compress() {
         type OuterDigestVariable = [Var<<C as Config>::N>; BN254_DIGEST_SIZE];
@@ -3,8 +3,10 @@
 
 use sp1_recursion_gnark_ffi::{
     ffi::{
-        build_groth16_bn254, build_plonk_bn254, test_groth16_bn254, test_plonk_bn254,
-        verify_groth16_bn254, verify_plonk_bn254,
+        anonymize_witness, bench_compare, build_groth16_bn254, build_plonk_bn254, load_config,
+        replay_transcript, set_checkpoint_dir, test_groth16_bn254, test_plonk_bn254,
+        verify_groth16_bn254, verify_plonk_bn254, write_compatibility_matrix,
+        write_conformance_bundle, write_support_bundle,
     },
     ProofBn254,
 };
@@ -19,6 +21,9 @@ use std::{
 struct Cli {
     #[command(subcommand)]
     command: Command,
+    /// A JSON config file applied before running the command.
+    #[arg(long, global = true)]
+    config: Option<String>,
 }
 
 #[derive(Debug, Subcommand)]
@@ -27,6 +32,12 @@ enum Command {
     Prove(ProveArgs),
     Verify(VerifyArgs),
     Test(TestArgs),
+    SupportBundle(SupportBundleArgs),
+    AnonymizeWitness(AnonymizeWitnessArgs),
+    CompatibilityMatrix(CompatibilityMatrixArgs),
+    ConformanceBundle(ConformanceBundleArgs),
+    Bench(BenchArgs),
+    Transcript(TranscriptArgs),
 }
 
 #[derive(Debug, Args)]
@@ -43,6 +54,14 @@ struct ProveArgs {
     output_path: String,
     #[arg(short, long)]
     system: String,
+    /// Also prove the witness with the other system, using the circuit built in this directory,
+    /// and verify both proofs. Used in release qualification to catch backend specific bugs.
+    #[arg(long)]
+    cross_check: Option<String>,
+    /// Persist the proof in this directory as soon as it is computed, so that a run interrupted
+    /// afterwards can resume from it instead of proving again.
+    #[arg(long)]
+    checkpoint_dir: Option<String>,
 }
 
 #[derive(Debug, Args)]
@@ -64,6 +83,55 @@ struct TestArgs {
     system: String,
 }
 
+#[derive(Debug, Args)]
+struct SupportBundleArgs {
+    data_dir: String,
+    output_path: String,
+}
+
+#[derive(Debug, Args)]
+struct AnonymizeWitnessArgs {
+    witness_path: String,
+    output_path: String,
+}
+
+#[derive(Debug, Args)]
+struct CompatibilityMatrixArgs {
+    data_dir: String,
+    output_path: String,
+}
+
+#[derive(Debug, Args)]
+struct ConformanceBundleArgs {
+    data_dir: String,
+    witness_path: String,
+    output_path: String,
+    #[arg(short, long)]
+    system: String,
+}
+
+#[derive(Debug, Args)]
+struct BenchArgs {
+    /// The artifact directories of the two circuits to compare, e.g. of two releases.
+    #[arg(long, num_args = 2, value_names = ["OLD", "NEW"], required = true)]
+    compare: Vec<String>,
+    output_path: String,
+    #[arg(short, long)]
+    system: String,
+    /// The witness to prove with both circuits, by default the one each was built with.
+    #[arg(long)]
+    witness: Option<String>,
+}
+
+/// Replays the challenger transcript of a witness outside of the circuit, to compare it with the
+/// one of the Rust prover.
+#[derive(Debug, Args)]
+struct TranscriptArgs {
+    data_dir: String,
+    witness_path: String,
+    output_path: String,
+}
+
 fn run_build(args: BuildArgs) {
     match args.system.as_str() {
         "plonk" => build_plonk_bn254(&args.data_dir),
@@ -73,11 +141,22 @@ fn run_build(args: BuildArgs) {
 }
 
 fn run_prove(args: ProveArgs) {
+    if let Some(checkpoint_dir) = &args.checkpoint_dir {
+        set_checkpoint_dir(checkpoint_dir)
+            .unwrap_or_else(|e| panic!("Failed to set the checkpoint dir: {}", e));
+    }
     let proof = match args.system.as_str() {
         "plonk" => prove_plonk_bn254(&args.data_dir, &args.witness_path),
         "groth16" => prove_groth16_bn254(&args.data_dir, &args.witness_path),
         _ => panic!("Unsupported system: {}", args.system),
     };
+    if let Some(other_data_dir) = &args.cross_check {
+        let other = match proof {
+            ProofBn254::Plonk(_) => prove_groth16_bn254(other_data_dir, &args.witness_path),
+            ProofBn254::Groth16(_) => prove_plonk_bn254(other_data_dir, &args.witness_path),
+        };
+        cross_check(&args.data_dir, &proof, other_data_dir, &other);
+    }
     let mut file = File::create(&args.output_path).unwrap();
     bincode::serialize_into(&mut file, &proof).unwrap();
 }
@@ -90,6 +169,49 @@ fn prove_groth16_bn254(data_dir: &str, witness_path: &str) -> ProofBn254 {
     ProofBn254::Groth16(sp1_recursion_gnark_ffi::ffi::prove_groth16_bn254(data_dir, witness_path))
 }
 
+/// Verifies both proofs of a cross check and that they commit to the same public inputs.
+fn cross_check(data_dir: &str, proof: &ProofBn254, other_data_dir: &str, other: &ProofBn254) {
+    if public_inputs(proof) != public_inputs(other) {
+        panic!(
+            "Cross check failed, public inputs differ: {:?} and {:?}",
+            public_inputs(proof),
+            public_inputs(other)
+        );
+    }
+    for (data_dir, proof) in [(data_dir, proof), (other_data_dir, other)] {
+        let (system, result) = match proof {
+            ProofBn254::Plonk(proof) => (
+                "plonk",
+                verify_plonk_bn254(
+                    data_dir,
+                    &proof.raw_proof,
+                    &proof.public_inputs[0],
+                    &proof.public_inputs[1],
+                ),
+            ),
+            ProofBn254::Groth16(proof) => (
+                "groth16",
+                verify_groth16_bn254(
+                    data_dir,
+                    &proof.raw_proof,
+                    &proof.public_inputs[0],
+                    &proof.public_inputs[1],
+                ),
+            ),
+        };
+        result.unwrap_or_else(|e| {
+            panic!("Cross check failed, the {} proof does not verify: {}", system, e)
+        });
+    }
+}
+
+fn public_inputs(proof: &ProofBn254) -> &[String; 2] {
+    match proof {
+        ProofBn254::Plonk(proof) => &proof.public_inputs,
+        ProofBn254::Groth16(proof) => &proof.public_inputs,
+    }
+}
+
 fn run_verify(args: VerifyArgs) {
     let file = File::open(&args.proof_path).unwrap();
     let proof = read_to_string(file).unwrap();
@@ -124,13 +246,96 @@ fn run_test(args: TestArgs) {
     }
 }
 
+fn run_support_bundle(args: SupportBundleArgs) {
+    write_support_bundle(&args.data_dir, &args.output_path)
+        .unwrap_or_else(|e| panic!("Failed to write support bundle: {}", e));
+}
+
+fn run_anonymize_witness(args: AnonymizeWitnessArgs) {
+    anonymize_witness(&args.witness_path, &args.output_path)
+        .unwrap_or_else(|e| panic!("Failed to anonymize witness: {}", e));
+}
+
+fn run_compatibility_matrix(args: CompatibilityMatrixArgs) {
+    write_compatibility_matrix(&args.data_dir, &args.output_path)
+        .unwrap_or_else(|e| panic!("Failed to write compatibility matrix: {}", e));
+}
+
+fn run_conformance_bundle(args: ConformanceBundleArgs) {
+    write_conformance_bundle(&args.data_dir, &args.system, &args.witness_path, &args.output_path)
+        .unwrap_or_else(|e| panic!("Failed to write conformance bundle: {}", e));
+}
+
+fn run_bench(args: BenchArgs) {
+    bench_compare(
+        &args.compare[0],
+        &args.compare[1],
+        &args.system,
+        args.witness.as_deref(),
+        &args.output_path,
+    )
+    .unwrap_or_else(|e| panic!("Failed to compare the artifacts: {}", e));
+}
+
+fn run_transcript(args: TranscriptArgs) {
+    replay_transcript(&args.data_dir, &args.witness_path, &args.output_path)
+        .unwrap_or_else(|e| panic!("Failed to replay the transcript: {}", e));
+}
+
 fn main() {
     let cli = Cli::parse();
+    if let Some(config) = &cli.config {
+        load_config(config).unwrap_or_else(|e| panic!("Failed to load config: {}", e));
+    }
 
     match cli.command {
         Command::Build(args) => run_build(args),
         Command::Prove(args) => run_prove(args),
         Command::Verify(args) => run_verify(args),
         Command::Test(args) => run_test(args),
+        Command::SupportBundle(args) => run_support_bundle(args),
+        Command::AnonymizeWitness(args) => run_anonymize_witness(args),
+        Command::CompatibilityMatrix(args) => run_compatibility_matrix(args),
+        Command::ConformanceBundle(args) => run_conformance_bundle(args),
+        Command::Bench(args) => run_bench(args),
+        Command::Transcript(args) => run_transcript(args),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use sp1_recursion_gnark_ffi::{Groth16Bn254Proof, PlonkBn254Proof};
+
+    fn proofs(public_inputs: [&str; 2]) -> (ProofBn254, ProofBn254) {
+        let plonk = ProofBn254::Plonk(PlonkBn254Proof {
+            public_inputs: ["1".to_string(), "2".to_string()],
+            encoded_proof: String::new(),
+            raw_proof: "00".to_string(),
+            plonk_vkey_hash: [0; 32],
+        });
+        let groth16 = ProofBn254::Groth16(Groth16Bn254Proof {
+            public_inputs: public_inputs.map(str::to_string),
+            encoded_proof: String::new(),
+            raw_proof: "00".to_string(),
+            groth16_vkey_hash: [0; 32],
+        });
+        (plonk, groth16)
+    }
+
+    #[test]
+    #[should_panic(expected = "Cross check failed, public inputs differ")]
+    fn test_cross_check_rejects_different_public_inputs() {
+        let (plonk, groth16) = proofs(["1", "3"]);
+        cross_check("plonk", &plonk, "groth16", &groth16);
+    }
+
+    #[test]
+    #[should_panic(expected = "Cross check failed, the plonk proof does not verify")]
+    fn test_cross_check_rejects_invalid_proofs() {
+        let (plonk, groth16) = proofs(["1", "2"]);
+        let data_dir = std::env::temp_dir().join("sp1-gnark-cli-missing-circuit");
+        let data_dir = data_dir.to_str().unwrap();
+        cross_check(data_dir, &plonk, data_dir, &groth16);
     }
 }
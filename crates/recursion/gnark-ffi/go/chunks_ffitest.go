@@ -0,0 +1,57 @@
+//go:build sp1_ffi_test
+
+package main
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+typedef int (*WriteChunkFn)(void *ctx, const char *data, size_t len);
+
+// Collects a streamed proof like the Rust write callback does, aborting after abortAfter chunks
+// if it is positive.
+typedef struct {
+	char data[4096];
+	size_t len;
+	size_t chunks;
+	size_t largestChunk;
+	size_t abortAfter;
+} chunkCollector;
+
+int collectChunk(void *ctx, const char *data, size_t len) {
+	chunkCollector *collector = ctx;
+	if (collector->abortAfter > 0 && collector->chunks == collector->abortAfter) {
+		return 1;
+	}
+	if (collector->len + len > sizeof(collector->data)) {
+		return 1;
+	}
+	memcpy(collector->data + collector->len, data, len);
+	collector->len += len;
+	collector->chunks++;
+	if (len > collector->largestChunk) {
+		collector->largestChunk = len;
+	}
+	return 0;
+}
+*/
+import "C"
+import (
+	"unsafe"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1"
+)
+
+// This file is only built with the sp1_ffi_test tag, so that the C callback the tests stream
+// proofs to is not part of the library.
+
+// collectProofChunks streams proof with writeProofChunks, and returns the bytes written, the
+// number of chunks and the size of the largest one.
+func collectProofChunks(proof sp1.Proof, chunkSize int, abortAfter int) ([]byte, int, int, error) {
+	collector := (*C.chunkCollector)(C.calloc(1, C.sizeof_chunkCollector))
+	defer C.free(unsafe.Pointer(collector))
+	collector.abortAfter = C.size_t(abortAfter)
+	err := exportError(writeProofChunks(proof, C.WriteChunkFn(C.collectChunk), unsafe.Pointer(collector), C.size_t(chunkSize)))
+	data := C.GoBytes(unsafe.Pointer(&collector.data[0]), C.int(collector.len))
+	return data, int(collector.chunks), int(collector.largestChunk), err
+}
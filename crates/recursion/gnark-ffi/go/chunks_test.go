@@ -0,0 +1,44 @@
+//go:build sp1_ffi_test
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1"
+)
+
+func TestProofChunks(t *testing.T) {
+	proof := sp1.Proof{PublicInputs: [2]string{"1", "2"}, EncodedProof: "abcdef", RawProof: "0123456789"}
+	expected, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chunkSize := range []int{1, 7, len(expected) - 1, len(expected), 1 << 20} {
+		data, chunks, largestChunk, err := collectProofChunks(proof, chunkSize, 0)
+		if err != nil {
+			t.Fatalf("chunks of %d bytes: %v", chunkSize, err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Errorf("chunks of %d bytes: got %q, expected %q", chunkSize, data, expected)
+		}
+		if expectedChunks := (len(expected) + chunkSize - 1) / chunkSize; chunks != expectedChunks || largestChunk > chunkSize {
+			t.Errorf("chunks of %d bytes: got %d chunks of at most %d bytes, expected %d", chunkSize, chunks, largestChunk, expectedChunks)
+		}
+	}
+
+	// The stream stops at the first chunk the callback rejects.
+	data, _, _, err := collectProofChunks(proof, 7, 2)
+	if err == nil || err.Error() != "write callback aborted the proof stream at byte 14" {
+		t.Errorf("got %v for an aborted stream", err)
+	}
+	if !bytes.Equal(data, expected[:14]) {
+		t.Errorf("got %q before aborting", data)
+	}
+	if _, _, _, err := collectProofChunks(proof, 0, 0); err == nil {
+		t.Error("streamed chunks of 0 bytes")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// The helpers below let the tests, which cannot use cgo themselves, call the exports like the
+// Rust bindings do.
+
+func cString(s string) *C.char {
+	return C.CString(s)
+}
+
+// exportError returns the error string returned by an export as an error, and frees it.
+func exportError(result *C.char) error {
+	if result == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(result))
+	return proofError(result)
+}
+
+// proofError returns the Error field of a proof returned by an export as an error. It is not
+// freed, since freeing the proof frees it.
+func proofError(err *C.char) error {
+	if err == nil {
+		return nil
+	}
+	return errors.New(C.GoString(err))
+}
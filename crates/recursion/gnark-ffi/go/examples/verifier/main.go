@@ -0,0 +1,168 @@
+// Command verifier is an example of a Go circuit embedding the SP1 verifier next to business
+// constraints of its own. It compiles the circuit, proves it with a fixture witness and verifies
+// the proof, and can be copied as a template.
+//
+// The business constraints read the public values of the SP1 program, which the verifier only
+// exposes through CommittedValuesDigest: the circuit takes the raw public values as a witness and
+// hashes them like CommittedValuesDigest does, so that they cannot differ from the committed ones.
+// The felts of the witness are internal values of the recursion program, not public values.
+//
+// The fixture in testdata is a tiny constraint system rather than a real recursion proof, so most
+// of the circuit is the SHA-256 hash: about 600k constraints, which take minutes to set up on a
+// single core. A real integration reads constraints.json and the witness written by the SP1 SDK
+// instead, and the SRS of BuildPlonk instead of an unsafe one.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/hash/sha2"
+	"github.com/consensys/gnark/std/math/uints"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1"
+)
+
+// programVkeyHash pins the SP1 program whose proofs the circuit accepts.
+const programVkeyHash = "4242"
+
+// publicValuesLen is the length of the public values of the pinned program: the balance it
+// commits, a little-endian u64 as sp1_zkvm::io::commit serializes it.
+const publicValuesLen = 8
+
+// Circuit accepts an SP1 proof of the pinned program whose balance is at least MinBalance.
+type Circuit struct {
+	Verifier     sp1.Circuit
+	PublicValues [publicValuesLen]frontend.Variable
+	MinBalance   frontend.Variable `gnark:",public"`
+}
+
+func (c *Circuit) Define(api frontend.API) error {
+	if err := c.Verifier.Define(api); err != nil {
+		return err
+	}
+	api.AssertIsEqual(c.Verifier.VkeyHash, programVkeyHash)
+
+	uapi, err := uints.New[uints.U32](api)
+	if err != nil {
+		return err
+	}
+	publicValues := make([]uints.U8, publicValuesLen)
+	for i, value := range c.PublicValues {
+		publicValues[i] = uapi.ByteValueOf(value)
+	}
+	api.AssertIsEqual(c.Verifier.CommittedValuesDigest, committedValuesDigest(api, publicValues))
+
+	balance := frontend.Variable(0)
+	for i := publicValuesLen - 1; i >= 0; i-- {
+		balance = api.Add(api.Mul(balance, 256), publicValues[i].Val)
+	}
+	api.AssertIsLessOrEqual(c.MinBalance, balance)
+	return nil
+}
+
+// committedValuesDigest computes sp1.CommittedValuesDigest in the circuit: the SHA-256 hash of the
+// public values with its top 3 bits masked, as a big-endian integer.
+func committedValuesDigest(api frontend.API, publicValues []uints.U8) frontend.Variable {
+	hasher, err := sha2.New(api)
+	if err != nil {
+		panic(err)
+	}
+	hasher.Write(publicValues)
+	hash := hasher.Sum()
+	digest := api.FromBinary(api.ToBinary(hash[0].Val, 8)[:5]...)
+	for _, b := range hash[1:] {
+		digest = api.Add(api.Mul(digest, 256), b.Val)
+	}
+	return digest
+}
+
+func main() {
+	constraintsPath := flag.String("constraints", "testdata/constraints.json", "constraints of the SP1 verifier")
+	witnessPath := flag.String("witness", "testdata/witness.json", "witness of the SP1 verifier")
+	publicValuesPath := flag.String("public-values", "testdata/public_values.bin", "public values of the SP1 proof")
+	minBalance := flag.Uint64("min-balance", 10, "smallest balance accepted")
+	flag.Parse()
+
+	if err := run(*constraintsPath, *witnessPath, *publicValuesPath, *minBalance); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("proof verified")
+}
+
+func run(constraintsPath string, witnessPath string, publicValuesPath string, minBalance uint64) error {
+	// The verifier reads its constraints when the circuit is defined.
+	os.Setenv("CONSTRAINTS_JSON", constraintsPath)
+
+	witnessInput, publicValues, err := readInputs(witnessPath, publicValuesPath)
+	if err != nil {
+		return err
+	}
+	assignment, err := newAssignment(witnessInput, publicValues, minBalance)
+	if err != nil {
+		return err
+	}
+
+	circuit := Circuit{Verifier: sp1.NewCircuit(witnessInput)}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	if err != nil {
+		return err
+	}
+
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	if err != nil {
+		return err
+	}
+	pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	if err != nil {
+		return err
+	}
+
+	witness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		return err
+	}
+	proof, err := plonk.Prove(ccs, pk, witness)
+	if err != nil {
+		return err
+	}
+	publicWitness, err := witness.Public()
+	if err != nil {
+		return err
+	}
+	return plonk.Verify(proof, vk, publicWitness)
+}
+
+func readInputs(witnessPath string, publicValuesPath string) (sp1.WitnessInput, []byte, error) {
+	data, err := os.ReadFile(witnessPath)
+	if err != nil {
+		return sp1.WitnessInput{}, nil, err
+	}
+	var witnessInput sp1.WitnessInput
+	if err := json.Unmarshal(data, &witnessInput); err != nil {
+		return sp1.WitnessInput{}, nil, fmt.Errorf("invalid witness %s: %w", witnessPath, err)
+	}
+	publicValues, err := os.ReadFile(publicValuesPath)
+	if err != nil {
+		return sp1.WitnessInput{}, nil, err
+	}
+	return witnessInput, publicValues, nil
+}
+
+func newAssignment(witnessInput sp1.WitnessInput, publicValues []byte, minBalance uint64) (*Circuit, error) {
+	if len(publicValues) != publicValuesLen {
+		return nil, fmt.Errorf("expected %d bytes of public values, got %d", publicValuesLen, len(publicValues))
+	}
+	assignment := &Circuit{Verifier: sp1.NewCircuit(witnessInput), MinBalance: minBalance}
+	for i, b := range publicValues {
+		assignment.PublicValues[i] = b
+	}
+	return assignment, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1"
+)
+
+// TestCircuit solves the circuit rather than proving it like run, whose setup takes minutes.
+func TestCircuit(t *testing.T) {
+	t.Setenv("CONSTRAINTS_JSON", "testdata/constraints.json")
+	witnessInput, publicValues, err := readInputs("testdata/witness.json", "testdata/public_values.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit := Circuit{Verifier: sp1.NewCircuit(witnessInput)}
+	solve := func(publicValues []byte, minBalance uint64) error {
+		assignment, err := newAssignment(witnessInput, publicValues, minBalance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return test.IsSolved(&circuit, assignment, ecc.BN254.ScalarField())
+	}
+
+	if err := solve(publicValues, 12); err != nil {
+		t.Fatal(err)
+	}
+	if err := solve(publicValues, 13); err == nil {
+		t.Fatal("a balance below the minimum was accepted")
+	}
+	// A larger balance than the one committed by the proof does not match its digest.
+	if err := solve(binary.LittleEndian.AppendUint64(nil, 100), 13); err == nil {
+		t.Fatal("public values other than the committed ones were accepted")
+	}
+	if _, err := newAssignment(witnessInput, publicValues[:7], 12); err == nil {
+		t.Fatal("accepted truncated public values")
+	}
+}
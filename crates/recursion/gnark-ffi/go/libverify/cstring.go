@@ -0,0 +1,36 @@
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// The helpers below let the tests, which cannot use cgo themselves, call the exports like a host
+// does.
+
+func cString(s string) *C.char {
+	return C.CString(s)
+}
+
+// exportError returns the error string returned by an export as an error, and frees it.
+func exportError(result *C.char) error {
+	if result == nil {
+		return nil
+	}
+	defer C.free(unsafe.Pointer(result))
+	return errors.New(C.GoString(result))
+}
+
+// circuitInfo calls CircuitInfo and returns the info it sets.
+func circuitInfo(dataDir string) (string, error) {
+	var info *C.char
+	if err := exportError(CircuitInfo(cString(dataDir), &info)); err != nil {
+		return "", err
+	}
+	defer FreeString(info)
+	return C.GoString(info), nil
+}
@@ -0,0 +1,93 @@
+// Command libverify is a shared library exporting only the verifiers of SP1 proofs, for hosts that
+// never prove, e.g. mobile or embedded ones. Unlike the full library, it needs neither the proving
+// keys nor the circuits, only the verifying keys in the data directory. Build it with
+//
+//	go build -buildmode=c-shared -trimpath -ldflags="-s -w" -o libsp1verify.so ./libverify
+//
+// The exports take and return the same values as their counterparts in the full library.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unsafe"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1"
+)
+
+func main() {}
+
+// recoverError returns a panic of an export as its error string instead, since a Go panic would
+// abort the process embedding the library.
+func recoverError(result **C.char) {
+	if r := recover(); r != nil {
+		*result = C.CString(fmt.Sprint(r))
+	}
+}
+
+func errorString(err error) *C.char {
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+//export VerifyPlonkBn254
+func VerifyPlonkBn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, committedValuesDigest *C.char) (result *C.char) {
+	defer recoverError(&result)
+	return errorString(sp1.VerifyPlonk(C.GoString(dataDir), C.GoString(proof), C.GoString(vkeyHash), C.GoString(committedValuesDigest)))
+}
+
+//export VerifyPlonkBn254PublicValues
+func VerifyPlonkBn254PublicValues(dataDir *C.char, proof *C.char, vkeyHash *C.char, publicValues *C.char) (result *C.char) {
+	defer recoverError(&result)
+	publicValuesBytes, err := hex.DecodeString(C.GoString(publicValues))
+	if err != nil {
+		return C.CString("invalid public values: " + err.Error())
+	}
+	return errorString(sp1.VerifyPlonkPublicValues(C.GoString(dataDir), C.GoString(proof), C.GoString(vkeyHash), publicValuesBytes))
+}
+
+//export VerifyGroth16Bn254
+func VerifyGroth16Bn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, committedValuesDigest *C.char) (result *C.char) {
+	defer recoverError(&result)
+	return errorString(sp1.VerifyGroth16(C.GoString(dataDir), C.GoString(proof), C.GoString(vkeyHash), C.GoString(committedValuesDigest)))
+}
+
+//export VerifyGroth16Bn254PublicValues
+func VerifyGroth16Bn254PublicValues(dataDir *C.char, proof *C.char, vkeyHash *C.char, publicValues *C.char) (result *C.char) {
+	defer recoverError(&result)
+	publicValuesBytes, err := hex.DecodeString(C.GoString(publicValues))
+	if err != nil {
+		return C.CString("invalid public values: " + err.Error())
+	}
+	return errorString(sp1.VerifyGroth16PublicValues(C.GoString(dataDir), C.GoString(proof), C.GoString(vkeyHash), publicValuesBytes))
+}
+
+// CircuitInfo sets info to the compatibility matrix of the artifacts in dataDir as JSON, see
+// sp1.CompatibilityMatrix, to be freed with FreeString.
+//
+//export CircuitInfo
+func CircuitInfo(dataDir *C.char, info **C.char) (result *C.char) {
+	defer recoverError(&result)
+	matrix, err := sp1.CompatibilityMatrix(C.GoString(dataDir))
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	data, err := json.Marshal(matrix)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	*info = C.CString(string(data))
+	return nil
+}
+
+//export FreeString
+func FreeString(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1"
+)
+
+func TestVerifyExports(t *testing.T) {
+	// The builds and proves set these for the rest of the process.
+	t.Setenv("CONSTRAINTS_JSON", "")
+	t.Setenv("SP1_CIRCUIT_VERSION", "v4.0.0")
+
+	// A data dir named dev uses an unsafe SRS instead of downloading one. The circuit is the fixture
+	// of the verifier example.
+	dataDir := filepath.Join(t.TempDir(), "dev")
+	if err := os.Mkdir(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for source, destination := range map[string]string{
+		"constraints.json": "constraints.json",
+		"witness.json":     "plonk_witness.json",
+	} {
+		data, err := os.ReadFile(filepath.Join("..", "examples", "verifier", "testdata", source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dataDir, destination), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sp1.BuildPlonk(dataDir, "")
+	proof := sp1.ProvePlonk(dataDir, filepath.Join(dataDir, "plonk_witness.json"))
+
+	// The vkey hash and committed values digest of the fixture witness, whose public values are a
+	// balance of 12.
+	const digest = "929127966663242118510967024717295424289994526933479300103869486691126459458"
+	if err := exportError(VerifyPlonkBn254(cString(dataDir), cString(proof.RawProof), cString("4242"), cString(digest))); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportError(VerifyPlonkBn254PublicValues(cString(dataDir), cString(proof.RawProof), cString("4242"), cString("0c00000000000000"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportError(VerifyPlonkBn254PublicValues(cString(dataDir), cString(proof.RawProof), cString("4242"), cString("0d00000000000000"))); err == nil {
+		t.Error("verified the proof against other public values")
+	}
+	if err := exportError(VerifyGroth16Bn254(cString(dataDir), cString(proof.RawProof), cString("4242"), cString(digest))); err == nil {
+		t.Error("verified a PLONK proof as a Groth16 one")
+	}
+	if err := exportError(VerifyPlonkBn254PublicValues(cString(dataDir), cString(proof.RawProof), cString("4242"), cString("abc"))); err == nil {
+		t.Error("accepted public values of an odd length")
+	}
+
+	info, err := circuitInfo(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matrix []sp1.Compatibility
+	if err := json.Unmarshal([]byte(info), &matrix); err != nil {
+		t.Fatal(err)
+	}
+	if len(matrix) != 1 || matrix[0].CircuitVersion != "v4.0.0" || matrix[0].System != "plonk" {
+		t.Errorf("unexpected circuit info %s", info)
+	}
+	if info, err := circuitInfo(t.TempDir()); err != nil || info != "[]" {
+		t.Errorf("got circuit info %s and %v without any circuit", info, err)
+	}
+}
+
+func TestExportsRejectNullPointers(t *testing.T) {
+	exports := map[string]func() error{
+		"VerifyPlonkBn254":               func() error { return exportError(VerifyPlonkBn254(nil, nil, nil, nil)) },
+		"VerifyGroth16Bn254":             func() error { return exportError(VerifyGroth16Bn254(nil, nil, nil, nil)) },
+		"VerifyPlonkBn254PublicValues":   func() error { return exportError(VerifyPlonkBn254PublicValues(nil, nil, nil, nil)) },
+		"VerifyGroth16Bn254PublicValues": func() error { return exportError(VerifyGroth16Bn254PublicValues(nil, nil, nil, nil)) },
+	}
+	for name, export := range exports {
+		if err := export(); err == nil {
+			t.Errorf("%s accepted null pointers", name)
+		}
+	}
+}
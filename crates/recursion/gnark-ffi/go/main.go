@@ -8,19 +8,32 @@ typedef struct {
 	char *PublicInputs[2];
 	char *EncodedProof;
 	char *RawProof;
+	// Set instead of the other fields when proving failed, freed with the proof.
+	char *Error;
 } C_PlonkBn254Proof;
 
 typedef struct {
 	char *PublicInputs[2];
 	char *EncodedProof;
 	char *RawProof;
+	// Set instead of the other fields when proving failed, freed with the proof.
+	char *Error;
 } C_Groth16Bn254Proof;
+
+// Receives a chunk of a streamed proof. Returning a non-zero value aborts the stream.
+typedef int (*WriteChunkFn)(void *ctx, const char *data, size_t len);
+
+static inline int callWriteChunk(WriteChunkFn write, void *ctx, const char *data, size_t len) {
+	return write(ctx, data, len);
+}
 */
 import "C"
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
+	"runtime/debug"
 	"sync"
 	"unsafe"
 
@@ -38,19 +51,32 @@ import (
 
 func main() {}
 
+// recoverError returns a panic of an export as its error string instead, since a Go panic would
+// abort the process embedding the library. The provers and verifiers panic on invalid arguments.
+//
+// Only panics of the goroutine of the export are recovered. gnark proves in worker goroutines of
+// its own, where a panic still aborts the process: the provers return the panics of hints as
+// errors, and check their inputs up front (see sp1.Preflight) so that the rest of gnark does not
+// panic on them, but a bug in gnark can still crash the host.
+func recoverError(result **C.char) {
+	if r := recover(); r != nil {
+		*result = C.CString(fmt.Sprint(r))
+	}
+}
+
 //export ProvePlonkBn254
-func ProvePlonkBn254(dataDir *C.char, witnessPath *C.char) *C.C_PlonkBn254Proof {
+func ProvePlonkBn254(dataDir *C.char, witnessPath *C.char) (structPtr *C.C_PlonkBn254Proof) {
+	// The struct is zeroed, so that only Error is set if proving panics.
+	structPtr = (*C.C_PlonkBn254Proof)(C.calloc(1, C.sizeof_C_PlonkBn254Proof))
+	if structPtr == nil {
+		return nil
+	}
+	defer recoverError(&structPtr.Error)
 	dataDirString := C.GoString(dataDir)
 	witnessPathString := C.GoString(witnessPath)
 
 	sp1PlonkBn254Proof := sp1.ProvePlonk(dataDirString, witnessPathString)
 
-	ms := C.malloc(C.sizeof_C_PlonkBn254Proof)
-	if ms == nil {
-		return nil
-	}
-
-	structPtr := (*C.C_PlonkBn254Proof)(ms)
 	structPtr.PublicInputs[0] = C.CString(sp1PlonkBn254Proof.PublicInputs[0])
 	structPtr.PublicInputs[1] = C.CString(sp1PlonkBn254Proof.PublicInputs[1])
 	structPtr.EncodedProof = C.CString(sp1PlonkBn254Proof.EncodedProof)
@@ -60,23 +86,47 @@ func ProvePlonkBn254(dataDir *C.char, witnessPath *C.char) *C.C_PlonkBn254Proof
 
 //export FreePlonkBn254Proof
 func FreePlonkBn254Proof(proof *C.C_PlonkBn254Proof) {
+	if proof == nil {
+		return
+	}
 	C.free(unsafe.Pointer(proof.EncodedProof))
 	C.free(unsafe.Pointer(proof.RawProof))
 	C.free(unsafe.Pointer(proof.PublicInputs[0]))
 	C.free(unsafe.Pointer(proof.PublicInputs[1]))
+	C.free(unsafe.Pointer(proof.Error))
 	C.free(unsafe.Pointer(proof))
 }
 
+// ProvePlonkBn254Chunked proves like ProvePlonkBn254, but streams the JSON encoded proof
+// (public_inputs, encoded_proof and raw_proof) to write in chunks of at most chunkSize bytes
+// instead of returning it in a single allocation. ctx is passed back to write unchanged.
+//
+//export ProvePlonkBn254Chunked
+func ProvePlonkBn254Chunked(dataDir *C.char, witnessPath *C.char, write C.WriteChunkFn, ctx unsafe.Pointer, chunkSize C.size_t) (result *C.char) {
+	defer recoverError(&result)
+	dataDirString := C.GoString(dataDir)
+	witnessPathString := C.GoString(witnessPath)
+
+	sp1PlonkBn254Proof := sp1.ProvePlonk(dataDirString, witnessPathString)
+	return writeProofChunks(sp1PlonkBn254Proof, write, ctx, chunkSize)
+}
+
+// BuildPlonkBn254 builds the PLONK circuit in dataDir, recording its vkey in the registry under
+// circuitVersion. A NULL or empty circuitVersion falls back to SP1_CIRCUIT_VERSION.
+//
 //export BuildPlonkBn254
-func BuildPlonkBn254(dataDir *C.char) {
+func BuildPlonkBn254(dataDir *C.char, circuitVersion *C.char) (result *C.char) {
+	defer recoverError(&result)
 	// Sanity check the required arguments have been provided.
 	dataDirString := C.GoString(dataDir)
 
-	sp1.BuildPlonk(dataDirString)
+	sp1.BuildPlonk(dataDirString, C.GoString(circuitVersion))
+	return nil
 }
 
 //export VerifyPlonkBn254
-func VerifyPlonkBn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, committedValuesDigest *C.char) *C.char {
+func VerifyPlonkBn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, committedValuesDigest *C.char) (result *C.char) {
+	defer recoverError(&result)
 	dataDirString := C.GoString(dataDir)
 	proofString := C.GoString(proof)
 	vkeyHashString := C.GoString(vkeyHash)
@@ -89,18 +139,36 @@ func VerifyPlonkBn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, committe
 	return nil
 }
 
+// VerifyPlonkBn254PublicValues verifies a PLONK proof against its raw public values, given as a
+// hex string, whose digest is computed here.
+//
+//export VerifyPlonkBn254PublicValues
+func VerifyPlonkBn254PublicValues(dataDir *C.char, proof *C.char, vkeyHash *C.char, publicValues *C.char) (result *C.char) {
+	defer recoverError(&result)
+	publicValuesBytes, err := hex.DecodeString(C.GoString(publicValues))
+	if err != nil {
+		return C.CString("invalid public values: " + err.Error())
+	}
+	err = sp1.VerifyPlonkPublicValues(C.GoString(dataDir), C.GoString(proof), C.GoString(vkeyHash), publicValuesBytes)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
 var testMutex = &sync.Mutex{}
 
 //export TestPlonkBn254
-func TestPlonkBn254(witnessPath *C.char, constraintsJson *C.char) *C.char {
+func TestPlonkBn254(witnessPath *C.char, constraintsJson *C.char) (result *C.char) {
+	defer recoverError(&result)
 	// Because of the global env variables used here, we need to lock this function
 	testMutex.Lock()
+	defer testMutex.Unlock()
 	witnessPathString := C.GoString(witnessPath)
 	constraintsJsonString := C.GoString(constraintsJson)
 	os.Setenv("WITNESS_JSON", witnessPathString)
 	os.Setenv("CONSTRAINTS_JSON", constraintsJsonString)
 	err := TestMain()
-	testMutex.Unlock()
 	if err != nil {
 		return C.CString(err.Error())
 	}
@@ -108,18 +176,18 @@ func TestPlonkBn254(witnessPath *C.char, constraintsJson *C.char) *C.char {
 }
 
 //export ProveGroth16Bn254
-func ProveGroth16Bn254(dataDir *C.char, witnessPath *C.char) *C.C_Groth16Bn254Proof {
+func ProveGroth16Bn254(dataDir *C.char, witnessPath *C.char) (structPtr *C.C_Groth16Bn254Proof) {
+	// The struct is zeroed, so that only Error is set if proving panics.
+	structPtr = (*C.C_Groth16Bn254Proof)(C.calloc(1, C.sizeof_C_Groth16Bn254Proof))
+	if structPtr == nil {
+		return nil
+	}
+	defer recoverError(&structPtr.Error)
 	dataDirString := C.GoString(dataDir)
 	witnessPathString := C.GoString(witnessPath)
 
 	sp1Groth16Bn254Proof := sp1.ProveGroth16(dataDirString, witnessPathString)
 
-	ms := C.malloc(C.sizeof_C_Groth16Bn254Proof)
-	if ms == nil {
-		return nil
-	}
-
-	structPtr := (*C.C_Groth16Bn254Proof)(ms)
 	structPtr.PublicInputs[0] = C.CString(sp1Groth16Bn254Proof.PublicInputs[0])
 	structPtr.PublicInputs[1] = C.CString(sp1Groth16Bn254Proof.PublicInputs[1])
 	structPtr.EncodedProof = C.CString(sp1Groth16Bn254Proof.EncodedProof)
@@ -129,23 +197,61 @@ func ProveGroth16Bn254(dataDir *C.char, witnessPath *C.char) *C.C_Groth16Bn254Pr
 
 //export FreeGroth16Bn254Proof
 func FreeGroth16Bn254Proof(proof *C.C_Groth16Bn254Proof) {
+	if proof == nil {
+		return
+	}
 	C.free(unsafe.Pointer(proof.EncodedProof))
 	C.free(unsafe.Pointer(proof.RawProof))
 	C.free(unsafe.Pointer(proof.PublicInputs[0]))
 	C.free(unsafe.Pointer(proof.PublicInputs[1]))
+	C.free(unsafe.Pointer(proof.Error))
 	C.free(unsafe.Pointer(proof))
 }
 
+// ProveGroth16Bn254Chunked is the Groth16 counterpart of ProvePlonkBn254Chunked.
+//
+//export ProveGroth16Bn254Chunked
+func ProveGroth16Bn254Chunked(dataDir *C.char, witnessPath *C.char, write C.WriteChunkFn, ctx unsafe.Pointer, chunkSize C.size_t) (result *C.char) {
+	defer recoverError(&result)
+	dataDirString := C.GoString(dataDir)
+	witnessPathString := C.GoString(witnessPath)
+
+	sp1Groth16Bn254Proof := sp1.ProveGroth16(dataDirString, witnessPathString)
+	return writeProofChunks(sp1Groth16Bn254Proof, write, ctx, chunkSize)
+}
+
+func writeProofChunks(proof sp1.Proof, write C.WriteChunkFn, ctx unsafe.Pointer, chunkSize C.size_t) *C.char {
+	if write == nil || chunkSize == 0 {
+		return C.CString("a write callback and a positive chunk size are required")
+	}
+	data, err := json.Marshal(proof)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	for offset := 0; offset < len(data); offset += int(chunkSize) {
+		chunk := data[offset:min(offset+int(chunkSize), len(data))]
+		if C.callWriteChunk(write, ctx, (*C.char)(unsafe.Pointer(&chunk[0])), C.size_t(len(chunk))) != 0 {
+			return C.CString(fmt.Sprintf("write callback aborted the proof stream at byte %d", offset))
+		}
+	}
+	return nil
+}
+
+// BuildGroth16Bn254 builds the Groth16 circuit like BuildPlonkBn254.
+//
 //export BuildGroth16Bn254
-func BuildGroth16Bn254(dataDir *C.char) {
+func BuildGroth16Bn254(dataDir *C.char, circuitVersion *C.char) (result *C.char) {
+	defer recoverError(&result)
 	// Sanity check the required arguments have been provided.
 	dataDirString := C.GoString(dataDir)
 
-	sp1.BuildGroth16(dataDirString)
+	sp1.BuildGroth16(dataDirString, C.GoString(circuitVersion))
+	return nil
 }
 
 //export VerifyGroth16Bn254
-func VerifyGroth16Bn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, committedValuesDigest *C.char) *C.char {
+func VerifyGroth16Bn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, committedValuesDigest *C.char) (result *C.char) {
+	defer recoverError(&result)
 	dataDirString := C.GoString(dataDir)
 	proofString := C.GoString(proof)
 	vkeyHashString := C.GoString(vkeyHash)
@@ -158,17 +264,34 @@ func VerifyGroth16Bn254(dataDir *C.char, proof *C.char, vkeyHash *C.char, commit
 	return nil
 }
 
+// VerifyGroth16Bn254PublicValues is VerifyPlonkBn254PublicValues for Groth16 proofs.
+//
+//export VerifyGroth16Bn254PublicValues
+func VerifyGroth16Bn254PublicValues(dataDir *C.char, proof *C.char, vkeyHash *C.char, publicValues *C.char) (result *C.char) {
+	defer recoverError(&result)
+	publicValuesBytes, err := hex.DecodeString(C.GoString(publicValues))
+	if err != nil {
+		return C.CString("invalid public values: " + err.Error())
+	}
+	err = sp1.VerifyGroth16PublicValues(C.GoString(dataDir), C.GoString(proof), C.GoString(vkeyHash), publicValuesBytes)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
 //export TestGroth16Bn254
-func TestGroth16Bn254(witnessJson *C.char, constraintsJson *C.char) *C.char {
+func TestGroth16Bn254(witnessJson *C.char, constraintsJson *C.char) (result *C.char) {
+	defer recoverError(&result)
 	// Because of the global env variables used here, we need to lock this function
 	testMutex.Lock()
+	defer testMutex.Unlock()
 	witnessPathString := C.GoString(witnessJson)
 	constraintsJsonString := C.GoString(constraintsJson)
 	os.Setenv("WITNESS_JSON", witnessPathString)
 	os.Setenv("CONSTRAINTS_JSON", constraintsJsonString)
 	os.Setenv("GROTH16", "1")
 	err := TestMain()
-	testMutex.Unlock()
 	if err != nil {
 		return C.CString(err.Error())
 	}
@@ -232,7 +355,8 @@ func TestMain() error {
 }
 
 //export TestPoseidonBabyBear2
-func TestPoseidonBabyBear2() *C.char {
+func TestPoseidonBabyBear2() (result *C.char) {
+	defer recoverError(&result)
 	input := [poseidon2.BABYBEAR_WIDTH]babybear.Variable{
 		babybear.NewF("0"),
 		babybear.NewF("0"),
@@ -301,6 +425,150 @@ func TestPoseidonBabyBear2() *C.char {
 	return nil
 }
 
+// WriteSupportBundle writes a tarball describing the circuit in dataDir and the environment to
+// outputPath, see sp1.WriteSupportBundle.
+//
+//export WriteSupportBundle
+func WriteSupportBundle(dataDir *C.char, outputPath *C.char) (result *C.char) {
+	defer recoverError(&result)
+	dataDirString := C.GoString(dataDir)
+	outputPathString := C.GoString(outputPath)
+
+	err := sp1.WriteSupportBundle(dataDirString, outputPathString)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// AnonymizeWitness writes a copy of the witness at inputPath with its program data randomized to
+// outputPath, see sp1.AnonymizeWitness.
+//
+//export AnonymizeWitness
+func AnonymizeWitness(inputPath *C.char, outputPath *C.char) (result *C.char) {
+	defer recoverError(&result)
+	inputPathString := C.GoString(inputPath)
+	outputPathString := C.GoString(outputPath)
+
+	err := sp1.AnonymizeWitnessFile(inputPathString, outputPathString)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// WriteCompatibilityMatrix writes the combinations of circuit version, system, vkey hash, witness
+// schema version and ABI version supported with the artifacts in dataDir to outputPath as JSON,
+// see sp1.CompatibilityMatrix.
+//
+//export WriteCompatibilityMatrix
+func WriteCompatibilityMatrix(dataDir *C.char, outputPath *C.char) (result *C.char) {
+	defer recoverError(&result)
+	dataDirString := C.GoString(dataDir)
+	outputPathString := C.GoString(outputPath)
+
+	err := sp1.WriteCompatibilityMatrix(dataDirString, outputPathString)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// WriteConformanceBundle proves the witness at witnessPath with the circuit of system in dataDir
+// and writes the conformance vectors to outputPath, see sp1.WriteConformanceBundle.
+//
+//export WriteConformanceBundle
+func WriteConformanceBundle(dataDir *C.char, system *C.char, witnessPath *C.char, outputPath *C.char) (result *C.char) {
+	defer recoverError(&result)
+	dataDirString := C.GoString(dataDir)
+	systemString := C.GoString(system)
+	witnessPathString := C.GoString(witnessPath)
+	outputPathString := C.GoString(outputPath)
+
+	err := sp1.WriteConformanceBundle(dataDirString, systemString, witnessPathString, outputPathString)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// BenchCompare proves a witness with the artifacts of oldDir and newDir and writes the deltas of
+// their measurements to outputPath, see sp1.WriteBenchComparison.
+//
+//export BenchCompare
+func BenchCompare(oldDir *C.char, newDir *C.char, system *C.char, witnessPath *C.char, outputPath *C.char) (result *C.char) {
+	defer recoverError(&result)
+	oldDirString := C.GoString(oldDir)
+	newDirString := C.GoString(newDir)
+	systemString := C.GoString(system)
+	witnessPathString := C.GoString(witnessPath)
+	outputPathString := C.GoString(outputPath)
+
+	err := sp1.WriteBenchComparison(oldDirString, newDirString, systemString, witnessPathString, outputPathString)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// ReplayTranscript writes the challenger transcript of the witness at witnessPath, replayed
+// outside of the circuit of dataDir, to outputPath, see sp1.ReplayTranscript.
+//
+//export ReplayTranscript
+func ReplayTranscript(dataDir *C.char, witnessPath *C.char, outputPath *C.char) (result *C.char) {
+	defer recoverError(&result)
+	dataDirString := C.GoString(dataDir)
+	witnessPathString := C.GoString(witnessPath)
+	outputPathString := C.GoString(outputPath)
+
+	err := sp1.ReplayTranscriptFile(dataDirString, witnessPathString, outputPathString)
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// LoadConfig reads the config file at path and applies it to this process, see sp1.Config.
+//
+//export LoadConfig
+func LoadConfig(path *C.char) (result *C.char) {
+	defer recoverError(&result)
+	err := sp1.LoadConfig(C.GoString(path))
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// SetCheckpointDir enables proof checkpoints in dir, or disables them if dir is empty, see
+// sp1.SetCheckpointDir.
+//
+//export SetCheckpointDir
+func SetCheckpointDir(dir *C.char) (result *C.char) {
+	defer recoverError(&result)
+	err := sp1.SetCheckpointDir(C.GoString(dir))
+	if err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// SetMemoryLimit sets the soft memory limit of the Go runtime in bytes, like GOMEMLIMIT, and
+// returns the previous one. A negative limit only reads the current one.
+//
+//export SetMemoryLimit
+func SetMemoryLimit(limit C.longlong) C.longlong {
+	return C.longlong(debug.SetMemoryLimit(int64(limit)))
+}
+
+// SetGCPercent sets the garbage collection target percentage, like GOGC, and returns the previous
+// one. A negative percentage disables the collector until the memory limit is reached.
+//
+//export SetGCPercent
+func SetGCPercent(percent C.int) C.int {
+	return C.int(debug.SetGCPercent(int(percent)))
+}
+
 //export FreeString
 func FreeString(s *C.char) {
 	C.free(unsafe.Pointer(s))
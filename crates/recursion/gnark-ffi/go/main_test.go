@@ -1,9 +1,139 @@
 package main
 
 import (
+	"runtime/debug"
+	"sync"
 	"testing"
 )
 
 func TestCircuit(t *testing.T) {
 	TestMain()
 }
+
+// The tests below inject faults at the FFI boundary: every export must return an error instead of
+// crashing the process embedding the library. They only reach the goroutine of the export; the
+// panics of hints, which run in gnark's worker goroutines, are tested in the sp1 package.
+
+func setTestEnv(t *testing.T) {
+	for _, name := range []string{"WITNESS_JSON", "CONSTRAINTS_JSON", "GROTH16"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestExportsRejectNullPointers(t *testing.T) {
+	setTestEnv(t)
+
+	exports := map[string]func() error{
+		"ProvePlonkBn254": func() error {
+			proof := ProvePlonkBn254(nil, nil)
+			defer FreePlonkBn254Proof(proof)
+			if proof.EncodedProof != nil || proof.RawProof != nil {
+				t.Error("ProvePlonkBn254 returned a proof along with its error")
+			}
+			return proofError(proof.Error)
+		},
+		"ProveGroth16Bn254": func() error {
+			proof := ProveGroth16Bn254(nil, nil)
+			defer FreeGroth16Bn254Proof(proof)
+			if proof.EncodedProof != nil || proof.RawProof != nil {
+				t.Error("ProveGroth16Bn254 returned a proof along with its error")
+			}
+			return proofError(proof.Error)
+		},
+		"BuildPlonkBn254":                func() error { return exportError(BuildPlonkBn254(nil, nil)) },
+		"BuildGroth16Bn254":              func() error { return exportError(BuildGroth16Bn254(nil, nil)) },
+		"ProvePlonkBn254Chunked":         func() error { return exportError(ProvePlonkBn254Chunked(nil, nil, nil, nil, 0)) },
+		"ProveGroth16Bn254Chunked":       func() error { return exportError(ProveGroth16Bn254Chunked(nil, nil, nil, nil, 0)) },
+		"VerifyPlonkBn254":               func() error { return exportError(VerifyPlonkBn254(nil, nil, nil, nil)) },
+		"VerifyGroth16Bn254":             func() error { return exportError(VerifyGroth16Bn254(nil, nil, nil, nil)) },
+		"VerifyPlonkBn254PublicValues":   func() error { return exportError(VerifyPlonkBn254PublicValues(nil, nil, nil, nil)) },
+		"VerifyGroth16Bn254PublicValues": func() error { return exportError(VerifyGroth16Bn254PublicValues(nil, nil, nil, nil)) },
+		"TestPlonkBn254":                 func() error { return exportError(TestPlonkBn254(nil, nil)) },
+		"TestGroth16Bn254":               func() error { return exportError(TestGroth16Bn254(nil, nil)) },
+		"WriteSupportBundle":             func() error { return exportError(WriteSupportBundle(nil, nil)) },
+		"AnonymizeWitness":               func() error { return exportError(AnonymizeWitness(nil, nil)) },
+		"WriteCompatibilityMatrix":       func() error { return exportError(WriteCompatibilityMatrix(nil, nil)) },
+		"WriteConformanceBundle":         func() error { return exportError(WriteConformanceBundle(nil, nil, nil, nil)) },
+		"BenchCompare":                   func() error { return exportError(BenchCompare(nil, nil, nil, nil, nil)) },
+		"ReplayTranscript":               func() error { return exportError(ReplayTranscript(nil, nil, nil)) },
+		"LoadConfig":                     func() error { return exportError(LoadConfig(nil)) },
+	}
+	for name, export := range exports {
+		if err := export(); err == nil {
+			t.Errorf("%s accepted null pointers", name)
+		}
+	}
+
+	// Freeing nothing is a no-op, like free(NULL).
+	FreePlonkBn254Proof(nil)
+	FreeGroth16Bn254Proof(nil)
+	FreeString(nil)
+}
+
+func TestExportsRejectTruncatedInputs(t *testing.T) {
+	dataDir := cString(t.TempDir())
+	vkeyHash := cString("1")
+	digest := cString("2")
+
+	// An odd number of hex digits, and a proof cut after 4 bytes.
+	for _, proof := range []string{"abc", "deadbeef"} {
+		if err := exportError(VerifyPlonkBn254(dataDir, cString(proof), vkeyHash, digest)); err == nil {
+			t.Errorf("VerifyPlonkBn254 accepted the proof %q", proof)
+		}
+		if err := exportError(VerifyGroth16Bn254(dataDir, cString(proof), vkeyHash, digest)); err == nil {
+			t.Errorf("VerifyGroth16Bn254 accepted the proof %q", proof)
+		}
+	}
+	if err := exportError(VerifyPlonkBn254PublicValues(dataDir, cString("deadbeef"), vkeyHash, cString("abc"))); err == nil {
+		t.Error("VerifyPlonkBn254PublicValues accepted public values of an odd length")
+	}
+}
+
+func TestExportsAreSafeConcurrently(t *testing.T) {
+	setTestEnv(t)
+
+	// The test exports share the environment under a lock, which must be released when they
+	// fail, and the verifiers must not share state between calls.
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- exportError(TestPlonkBn254(nil, nil))
+			errs <- exportError(TestGroth16Bn254(nil, nil))
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- exportError(VerifyPlonkBn254(cString(t.TempDir()), cString("deadbeef"), nil, nil))
+			errs <- exportError(VerifyGroth16Bn254(cString(t.TempDir()), cString("deadbeef"), nil, nil))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err == nil {
+			t.Error("an export succeeded on invalid inputs")
+		}
+	}
+}
+
+func TestGoRuntimeSettings(t *testing.T) {
+	previousLimit := SetMemoryLimit(1 << 30)
+	defer SetMemoryLimit(previousLimit)
+	if limit := SetMemoryLimit(-1); limit != 1<<30 {
+		t.Errorf("got memory limit %d, expected %d", limit, 1<<30)
+	}
+	if limit := debug.SetMemoryLimit(-1); limit != 1<<30 {
+		t.Errorf("the runtime has memory limit %d, expected %d", limit, 1<<30)
+	}
+
+	previousPercent := SetGCPercent(50)
+	defer SetGCPercent(previousPercent)
+	if percent := SetGCPercent(-1); percent != 50 {
+		t.Errorf("got GC percent %d, expected 50", percent)
+	}
+	if percent := debug.SetGCPercent(int(previousPercent)); percent != -1 {
+		t.Errorf("the runtime has GC percent %d, expected the collector to be disabled", percent)
+	}
+}
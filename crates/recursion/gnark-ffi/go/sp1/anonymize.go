@@ -0,0 +1,98 @@
+package sp1
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+)
+
+// AnonymizeWitness replaces every well formed value of witness, including the public inputs, with
+// a random one drawn from r, so that it can be attached to a bug report without leaking program
+// data. The shape is preserved: the number of vars, felts and exts and the shape digest are kept,
+// as are malformed or non-canonical values, so parser, preflight and shape errors reproduce.
+// Failures that depend on the actual values, e.g. an unsatisfied constraint, do not.
+func AnonymizeWitness(witness WitnessInput, r io.Reader) (WitnessInput, error) {
+	bn254Modulus := ecc.BN254.ScalarField()
+	randomVar := func(value string) (string, error) {
+		v, ok := new(big.Int).SetString(value, 10)
+		if !ok || v.Sign() < 0 || v.Cmp(bn254Modulus) >= 0 {
+			return value, nil
+		}
+		random, err := rand.Int(r, bn254Modulus)
+		if err != nil {
+			return "", err
+		}
+		return random.String(), nil
+	}
+	randomFelt := func(value string) (string, error) {
+		if _, err := babybear.ParseF(value); err != nil {
+			return value, nil
+		}
+		random, err := rand.Int(r, babybear.Params{}.Modulus())
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(random.Uint64(), 10), nil
+	}
+
+	anonymized := WitnessInput{
+		Vars:        make([]string, len(witness.Vars)),
+		Felts:       make([]string, len(witness.Felts)),
+		Exts:        make([][]string, len(witness.Exts)),
+		ShapeDigest: witness.ShapeDigest,
+	}
+	var err error
+	if anonymized.VkeyHash, err = randomVar(witness.VkeyHash); err != nil {
+		return WitnessInput{}, err
+	}
+	if anonymized.CommittedValuesDigest, err = randomVar(witness.CommittedValuesDigest); err != nil {
+		return WitnessInput{}, err
+	}
+	for i, value := range witness.Vars {
+		if anonymized.Vars[i], err = randomVar(value); err != nil {
+			return WitnessInput{}, err
+		}
+	}
+	for i, value := range witness.Felts {
+		if anonymized.Felts[i], err = randomFelt(value); err != nil {
+			return WitnessInput{}, err
+		}
+	}
+	for i, value := range witness.Exts {
+		anonymized.Exts[i] = make([]string, len(value))
+		for j, coefficient := range value {
+			if anonymized.Exts[i][j], err = randomFelt(coefficient); err != nil {
+				return WitnessInput{}, err
+			}
+		}
+	}
+	return anonymized, nil
+}
+
+// AnonymizeWitnessFile writes an anonymized copy of the witness at inputPath to outputPath, see
+// AnonymizeWitness. A witness that is not valid JSON cannot be anonymized and is rejected.
+func AnonymizeWitnessFile(inputPath string, outputPath string) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return err
+	}
+	var witness WitnessInput
+	if err := json.Unmarshal(data, &witness); err != nil {
+		return err
+	}
+	anonymized, err := AnonymizeWitness(witness, rand.Reader)
+	if err != nil {
+		return err
+	}
+	out, err := json.Marshal(anonymized)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, out, 0644)
+}
@@ -0,0 +1,121 @@
+package sp1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The audit log is an append-only JSONL file with one entry per prove and verify call. It is
+// enabled by setting SP1_AUDIT_LOG to its path. Once the file grows past SP1_AUDIT_LOG_MAX_BYTES
+// (100 MiB by default) it is rotated to path.1, shifting older files up to path.5.
+const (
+	auditLogEnv         = "SP1_AUDIT_LOG"
+	auditLogMaxBytesEnv = "SP1_AUDIT_LOG_MAX_BYTES"
+	auditLogMaxBytes    = 100 << 20
+	auditLogBackups     = 5
+)
+
+var auditMutex sync.Mutex
+
+type AuditEntry struct {
+	Time                  time.Time `json:"time"`
+	Operation             string    `json:"operation"`
+	System                string    `json:"system"`
+	WitnessDigest         string    `json:"witness_digest,omitempty"`
+	ProofDigest           string    `json:"proof_digest,omitempty"`
+	VkeyHash              string    `json:"vkey_hash,omitempty"`
+	CommittedValuesDigest string    `json:"committed_values_digest,omitempty"`
+	DurationMs            int64     `json:"duration_ms"`
+	Result                string    `json:"result"`
+}
+
+func startAudit(operation string, system string) *AuditEntry {
+	return &AuditEntry{Time: time.Now().UTC(), Operation: operation, System: system}
+}
+
+// finish records the entry with the outcome of the call.
+func (e *AuditEntry) finish(err error) {
+	e.DurationMs = time.Since(e.Time).Milliseconds()
+	e.Result = "ok"
+	if err != nil {
+		e.Result = err.Error()
+	}
+	if err := writeAuditEntry(os.Getenv(auditLogEnv), e); err != nil {
+		fmt.Printf("Writing audit log failed: %v\n", err)
+	}
+}
+
+// finishOnPanic records a failed entry if the call panics, then resumes panicking. It must be
+// deferred directly.
+func (e *AuditEntry) finishOnPanic() {
+	if r := recover(); r != nil {
+		e.finish(fmt.Errorf("panic: %v", r))
+		panic(r)
+	}
+}
+
+func digest(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func writeAuditEntry(path string, entry *AuditEntry) error {
+	if path == "" {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	auditMutex.Lock()
+	defer auditMutex.Unlock()
+
+	if err := rotateAuditLog(path, int64(len(line))); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(line)
+	return err
+}
+
+func rotateAuditLog(path string, pending int64) error {
+	maxBytes := int64(auditLogMaxBytes)
+	if value := os.Getenv(auditLogMaxBytesEnv); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid %s: %q", auditLogMaxBytesEnv, value)
+		}
+		maxBytes = parsed
+	}
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 || info.Size()+pending <= maxBytes {
+		return nil
+	}
+
+	for i := auditLogBackups - 1; i >= 1; i-- {
+		older := fmt.Sprintf("%s.%d", path, i)
+		if err := os.Rename(older, fmt.Sprintf("%s.%d", path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
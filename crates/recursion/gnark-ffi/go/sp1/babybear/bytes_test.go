@@ -0,0 +1,56 @@
+package babybear
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+type bytesCircuit struct {
+	X      Variable
+	XBytes [4]frontend.Variable
+	V      frontend.Variable
+	VBytes [32]frontend.Variable
+}
+
+func (c *bytesCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	xBytes := chip.ToBytes(c.X)
+	for i := range xBytes {
+		api.AssertIsEqual(xBytes[i], c.XBytes[i])
+	}
+	chip.AssertIsEqualF(chip.FromBytes(c.XBytes[:]), c.X)
+
+	vBytes := field.ToBytes(api, c.V, 32)
+	for i := range vBytes {
+		api.AssertIsEqual(vBytes[i], c.VBytes[i])
+	}
+	api.AssertIsEqual(field.FromBytes(api, c.VBytes[1:]), c.V)
+	api.AssertIsEqual(field.FromDigest(api, c.VBytes), c.V)
+	return nil
+}
+
+func TestBytes(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := bytesCircuit{X: NewF("0")}
+
+	// X is 0x77800000, given as its non-canonical representative X + p.
+	assignment := bytesCircuit{
+		X:      NewF("4018143233"),
+		XBytes: [4]frontend.Variable{0x77, 0x80, 0x00, 0x00},
+		V:      0x0102,
+	}
+	for i := range assignment.VBytes {
+		assignment.VBytes[i] = 0
+	}
+	assignment.VBytes[30], assignment.VBytes[31] = 1, 2
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.XBytes[3] = 1
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
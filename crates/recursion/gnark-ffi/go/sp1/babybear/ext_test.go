@@ -0,0 +1,247 @@
+package babybear
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+func TestExtRoundTrip(t *testing.T) {
+	// GnarkWitness serializes BinomialExtensionField::from_base_slice(&[1, 2, 3, p - 1]) like this.
+	rust := `["1","2","3","2013265920"]`
+
+	var e Ext
+	if err := json.Unmarshal([]byte(rust), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e != (Ext{1, 2, 3, 2013265920}) {
+		t.Fatalf("unexpected coefficients %v", e)
+	}
+	encoded, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(encoded) != rust {
+		t.Fatalf("round trip changed the encoding: %s", encoded)
+	}
+
+	for _, invalid := range [][]string{
+		{"1", "2", "3"},
+		{"1", "2", "3", "2013265921"},
+		{"1", "2", "3", "-1"},
+		{"1", "2", "3", "04"},
+		{"1", "2", "3", "x"},
+	} {
+		if _, err := ParseExt(invalid); err == nil {
+			t.Fatalf("accepted %v", invalid)
+		}
+	}
+}
+
+type extOrderCircuit struct {
+	A, B, Product ExtensionVariable
+}
+
+func (c *extOrderCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualE(chip.MulE(c.A, c.B), c.Product)
+	return nil
+}
+
+func TestExtLimbOrder(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// X * X^3 = X^4 = 11, which only holds if the first coefficient is the constant term.
+	circuit := extOrderCircuit{A: Ext{}.Variable(), B: Ext{}.Variable(), Product: Ext{}.Variable()}
+	assignment := extOrderCircuit{
+		A:       Ext{0, 1, 0, 0}.Variable(),
+		B:       Ext{0, 0, 0, 1}.Variable(),
+		Product: Ext{11, 0, 0, 0}.Variable(),
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Product = Ext{0, 0, 0, 11}.Variable()
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type extArithmeticCircuit struct {
+	A, B, Scaled ExtensionVariable
+}
+
+func (c *extArithmeticCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualE(chip.ScalarMulE(c.A, 3), c.Scaled)
+	chip.AssertIsEqualE(chip.AddE(chip.AddE(c.A, c.A), c.A), c.Scaled)
+	chip.AssertIsEqualE(chip.MulE(chip.DivE(c.A, c.B), c.B), c.A)
+	chip.AssertIsEqualE(chip.SubE(chip.AddE(c.A, c.B), c.B), c.A)
+	return nil
+}
+
+func TestExtArithmetic(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := extArithmeticCircuit{A: Ext{}.Variable(), B: Ext{}.Variable(), Scaled: Ext{}.Variable()}
+	assignment := extArithmeticCircuit{
+		A:      Ext{1, 2, 3, 2013265920}.Variable(),
+		B:      Ext{5, 0, 7, 1}.Variable(),
+		Scaled: Ext{3, 6, 9, 2013265918}.Variable(),
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Scaled = Ext{3, 6, 9, 2013265919}.Variable()
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type expECircuit struct {
+	A ExtensionVariable
+}
+
+func (c *expECircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	powers := chip.Powers(c.A, 70)
+	for _, n := range []uint64{0, 1, 2, 5, 13, 64, 69} {
+		chip.AssertIsEqualE(chip.ExpE(c.A, n), powers[n])
+	}
+	return nil
+}
+
+func TestExpE(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := expECircuit{A: Ext{}.Variable()}
+	assignment := expECircuit{A: Ext{1, 2, 3, 2013265920}.Variable()}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type powersCircuit struct {
+	A        ExtensionVariable
+	Expected []ExtensionVariable
+	// Asked for first, to check that a longer sequence extends it.
+	Prefix int
+}
+
+func (c *powersCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	prefix := chip.Powers(c.A, c.Prefix)
+	powers := chip.Powers(c.A, len(c.Expected))
+	if len(powers) != len(c.Expected) {
+		return fmt.Errorf("got %d powers, expected %d", len(powers), len(c.Expected))
+	}
+	for i := range prefix {
+		if prefix[i] != powers[i] {
+			return fmt.Errorf("power %d was computed again", i)
+		}
+	}
+	for i := range powers {
+		chip.AssertIsEqualE(powers[i], c.Expected[i])
+	}
+	return nil
+}
+
+func TestPowers(t *testing.T) {
+	// X^k = 11^(k / 4) X^(k % 4), since X^4 = 11.
+	const n = 10
+	expected := make([]ExtensionVariable, n)
+	placeholders := make([]ExtensionVariable, n)
+	coefficient := uint32(1)
+	for k := 0; k < n; k++ {
+		var power Ext
+		power[k%4] = coefficient
+		if k%4 == 3 {
+			coefficient *= 11
+		}
+		expected[k] = power.Variable()
+		placeholders[k] = Ext{}.Variable()
+	}
+
+	circuit := powersCircuit{A: Ext{}.Variable(), Expected: placeholders, Prefix: 3}
+	assignment := powersCircuit{A: Ext{0, 1, 0, 0}.Variable(), Expected: expected, Prefix: 3}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+	assignment.Expected = append([]ExtensionVariable{}, expected...)
+	assignment.Expected[n-1] = Ext{0, 11, 0, 0}.Variable()
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("X^9 = 11 X is satisfiable")
+	}
+
+	// Extending the prefix costs no more than computing the sequence at once.
+	nbConstraints := func(prefix int) int {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &powersCircuit{A: Ext{}.Variable(), Expected: placeholders, Prefix: prefix})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ccs.GetNbConstraints()
+	}
+	if extended, once := nbConstraints(3), nbConstraints(0); extended != once {
+		t.Fatalf("extending 3 powers to %d costs %d constraints, computing them at once %d", n, extended, once)
+	}
+}
+
+type invECircuit struct {
+	A ExtensionVariable
+}
+
+func (c *invECircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualE(chip.MulE(c.A, chip.InvE(c.A)), Ext{1, 0, 0, 0}.Variable())
+	return nil
+}
+
+func TestInvE(t *testing.T) {
+	rng := rand.New(rand.NewSource(205))
+	circuit := invECircuit{A: Ext{}.Variable()}
+	for i := 0; i < 8; i++ {
+		var a Ext
+		for j := range a {
+			a[j] = uint32(rng.Int63n(2013265921))
+		}
+		// Elements of the base field and of F_p[X^2] take other paths through the norm map.
+		switch i {
+		case 0:
+			a = Ext{a[0], 0, 0, 0}
+		case 1:
+			a = Ext{a[0], 0, a[2], 0}
+		case 2:
+			a = Ext{0, a[1], 0, a[3]}
+		}
+		if err := test.IsSolved(&circuit, &invECircuit{A: a.Variable()}, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("inverting %v: %v", a, err)
+		}
+	}
+}
+
+func TestInvERejectsWrongInverse(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &invECircuit{A: Ext{}.Variable()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := frontend.NewWitness(&invECircuit{A: Ext{1, 2, 3, 2013265920}.Variable()}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ccs.IsSolved(w); err != nil {
+		t.Fatal(err)
+	}
+
+	// The inverse of the norm, off by one.
+	dishonest := func(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+		modulus := inputs[0]
+		results[0].ModInverse(inputs[1], modulus)
+		results[0].Add(results[0], big.NewInt(1)).Mod(results[0], modulus)
+		return nil
+	}
+	if err := ccs.IsSolved(w, solver.OverrideHint(solver.GetHintID(field.InvFHint), dishonest)); err == nil {
+		t.Fatal("accepted a wrong inverse of the norm")
+	}
+}
@@ -0,0 +1,712 @@
+package babybear
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+type divisionCircuit struct {
+	A, B, Inverse, Quotient Variable
+}
+
+func (c *divisionCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualF(chip.InvF(c.B), c.Inverse)
+	chip.AssertIsEqualF(chip.DivF(c.A, c.B), c.Quotient)
+	return nil
+}
+
+func TestDivision(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := divisionCircuit{A: NewF("0"), B: NewF("0"), Inverse: NewF("0"), Quotient: NewF("0")}
+
+	// 2^-1 = (p + 1) / 2 and 6 / 2 = 3, with B given as its non-canonical representative 2 + p.
+	assignment := divisionCircuit{
+		A:        NewF("6"),
+		B:        NewF("2013265923"),
+		Inverse:  NewF("1006632961"),
+		Quotient: NewF("3"),
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Quotient = NewF("4")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// Zero has no inverse.
+	assignment = divisionCircuit{A: NewF("6"), B: NewF("0"), Inverse: NewF("0"), Quotient: NewF("0")}
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type batchInversionCircuit struct {
+	Xs, Inverses [3]Variable
+}
+
+func (c *batchInversionCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	inverses := chip.BatchInvF(c.Xs[:])
+	for i := range inverses {
+		chip.AssertIsEqualF(inverses[i], c.Inverses[i])
+	}
+	return nil
+}
+
+func TestBatchInversion(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var circuit batchInversionCircuit
+	for i := range circuit.Xs {
+		circuit.Xs[i] = NewF("0")
+		circuit.Inverses[i] = NewF("0")
+	}
+
+	// 2^-1 = (p + 1) / 2, 3^-1 = (2p + 1) / 3 and (p - 1)^-1 = p - 1.
+	assignment := batchInversionCircuit{
+		Xs:       [3]Variable{NewF("2"), NewF("3"), NewF("2013265920")},
+		Inverses: [3]Variable{NewF("1006632961"), NewF("1342177281"), NewF("2013265920")},
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Inverses[1] = NewF("1006632961")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// A single zero makes the whole batch unsatisfiable.
+	assignment.Xs[1] = NewF("0")
+	assignment.Inverses[1] = NewF("0")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type expCircuit struct {
+	X, Cube, Inverse Variable
+}
+
+func (c *expCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualF(chip.ExpF(c.X, 0), NewFConst("1"))
+	chip.AssertIsEqualF(chip.ExpF(c.X, 1), c.X)
+	chip.AssertIsEqualF(chip.ExpF(c.X, 3), c.Cube)
+	// x^(p - 2) is the inverse of x by Fermat's little theorem.
+	chip.AssertIsEqualF(chip.ExpF(c.X, 2013265919), c.Inverse)
+	return nil
+}
+
+func TestExp(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := expCircuit{X: NewF("0"), Cube: NewF("0"), Inverse: NewF("0")}
+
+	// X is 2 given as 2 + p.
+	assignment := expCircuit{X: NewF("2013265923"), Cube: NewF("8"), Inverse: NewF("1006632961")}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Cube = NewF("9")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type selectCircuit struct {
+	Cond     frontend.Variable
+	Node     Variable
+	Sibling  Variable
+	Children [2]Variable
+}
+
+func (c *selectCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	cond := chip.NewBool(c.Cond)
+	left := chip.SelectF(cond, c.Sibling, c.Node)
+	children := chip.SelectSliceF(cond, []Variable{c.Sibling, c.Node}, []Variable{c.Node, c.Sibling})
+	chip.AssertIsEqualF(left, children[0])
+	chip.AssertIsEqualF(children[0], c.Children[0])
+	chip.AssertIsEqualF(children[1], c.Children[1])
+	return nil
+}
+
+func TestSelect(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := selectCircuit{Node: NewF("0"), Sibling: NewF("0"), Children: [2]Variable{NewF("0"), NewF("0")}}
+
+	// The node is the left child when the direction bit is 0, and the right one when it is 1.
+	assignment := selectCircuit{Cond: 0, Node: NewF("3"), Sibling: NewF("5"), Children: [2]Variable{NewF("3"), NewF("5")}}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Cond = 1
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Children = [2]Variable{NewF("5"), NewF("3")}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// The direction bit must be boolean.
+	assignment.Cond = 2
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type conditionalAssertCircuit struct {
+	Cond          frontend.Variable
+	A, B          Variable
+	Claimed, E, F ExtensionVariable
+}
+
+func (c *conditionalAssertCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	cond := chip.NewBool(c.Cond)
+	chip.AssertIsEqualFIf(cond, c.A, c.B)
+	chip.AssertIsEqualEIf(cond, c.E, c.F)
+	// The claimed contribution of an absent section is the zero identity.
+	chip.AssertOptionalE(cond, c.Claimed, c.E, Ext{}.Variable())
+	return nil
+}
+
+func TestConditionalAsserts(t *testing.T) {
+	circuit := conditionalAssertCircuit{A: NewF("0"), B: NewF("0"), Claimed: Ext{}.Variable(), E: Ext{}.Variable(), F: Ext{}.Variable()}
+	e := Ext{1, 2, 3, 4}
+	for _, c := range []struct {
+		name                string
+		cond                int
+		a, b                string
+		claimed, f          Ext
+		expectedSatisfiable bool
+	}{
+		{name: "present and equal", cond: 1, a: "7", b: "7", claimed: e, f: e, expectedSatisfiable: true},
+		// A value congruent modulo p is the same element.
+		{name: "present and congruent", cond: 1, a: "7", b: "2013265928", claimed: e, f: e, expectedSatisfiable: true},
+		{name: "present and different felts", cond: 1, a: "7", b: "8", claimed: e, f: e},
+		{name: "present and different extension elements", cond: 1, a: "7", b: "7", claimed: e, f: Ext{1, 2, 3, 5}},
+		{name: "present with the identity claimed", cond: 1, a: "7", b: "7", claimed: Ext{}, f: e},
+		{name: "absent and different", cond: 0, a: "7", b: "8", claimed: Ext{}, f: Ext{1, 2, 3, 5}, expectedSatisfiable: true},
+		{name: "absent with a contribution claimed", cond: 0, a: "7", b: "8", claimed: e, f: Ext{1, 2, 3, 5}},
+	} {
+		assignment := conditionalAssertCircuit{
+			Cond:    c.cond,
+			A:       NewF(c.a),
+			B:       NewF(c.b),
+			Claimed: c.claimed.Variable(),
+			E:       e.Variable(),
+			F:       c.f.Variable(),
+		}
+		err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField())
+		if c.expectedSatisfiable && err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		} else if !c.expectedSatisfiable && err == nil {
+			t.Errorf("%s: the circuit is satisfied", c.name)
+		}
+	}
+}
+
+type mulFConstCircuit struct {
+	X, Product Variable
+	Constants  []int
+}
+
+// Define checks the product by the first constant. The other products are only computed, and
+// reduced eagerly, which costs constraints unless they are memoized.
+func (c *mulFConstCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.Reduction = field.ReductionEager
+	products := make([]Variable, len(c.Constants))
+	for i, constant := range c.Constants {
+		products[i] = chip.MulFConst(c.X, constant)
+	}
+	chip.AssertIsEqualF(products[0], c.Product)
+	return nil
+}
+
+func TestMulFConstIsMemoized(t *testing.T) {
+	nbConstraints := func(constants ...int) int {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &mulFConstCircuit{X: NewF("0"), Product: NewF("0"), Constants: constants})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ccs.GetNbConstraints()
+	}
+
+	// A product is computed and reduced once however many times it is asked for, but the
+	// products by different constants are not shared.
+	once, thrice, other := nbConstraints(7), nbConstraints(7, 7, 7), nbConstraints(7, 11)
+	if once != thrice {
+		t.Errorf("asking three times for x * 7 costs %d constraints, asking once %d", thrice, once)
+	}
+	if other <= once {
+		t.Errorf("x * 7 and x * 11 cost %d constraints, x * 7 alone %d", other, once)
+	}
+
+	// The memoized product is still the product.
+	circuit := mulFConstCircuit{X: NewF("0"), Product: NewF("0"), Constants: []int{7, 7}}
+	assignment := mulFConstCircuit{X: NewF("2013265920"), Product: NewF("2013265914"), Constants: []int{7, 7}}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+	assignment.Product = NewF("7")
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("-1 * 7 = 7 is satisfiable")
+	}
+}
+
+type boolCircuit struct {
+	A, B               frontend.Variable
+	And, Or, Xor, NotA frontend.Variable
+	X                  Variable
+	Bits               [31]frontend.Variable
+}
+
+func (c *boolCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	a, b := chip.NewBool(c.A), chip.NewBool(c.B)
+	api.AssertIsEqual(chip.And(a, b).Variable(), c.And)
+	api.AssertIsEqual(chip.Or(a, b).Variable(), c.Or)
+	api.AssertIsEqual(chip.Xor(a, b).Variable(), c.Xor)
+	api.AssertIsEqual(chip.Not(a).Variable(), c.NotA)
+	api.AssertIsEqual(chip.SelectV(a, 7, 9), api.Select(c.A, 7, 9))
+	api.AssertIsEqual(chip.SelectV(field.NewBoolConst(true), c.A, c.B), c.A)
+	api.AssertIsEqual(chip.SelectV(field.NewBoolConst(false), c.A, c.B), c.B)
+	for i, bit := range chip.Bits(c.X) {
+		api.AssertIsEqual(bit.Variable(), c.Bits[i])
+	}
+	return nil
+}
+
+func TestBool(t *testing.T) {
+	circuit := boolCircuit{X: NewF("0")}
+	for a := 0; a < 2; a++ {
+		for b := 0; b < 2; b++ {
+			assignment := boolCircuit{A: a, B: b, And: a & b, Or: a | b, Xor: a ^ b, NotA: 1 - a, X: NewF("13")}
+			// 13 = 0b1101, little-endian.
+			for i := range assignment.Bits {
+				assignment.Bits[i] = (13 >> i) & 1
+			}
+			if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+				t.Fatalf("a = %d, b = %d: %v", a, b, err)
+			}
+
+			wrong := assignment
+			wrong.Xor = 1 - wrong.Xor.(int)
+			if err := test.IsSolved(&circuit, &wrong, ecc.BN254.ScalarField()); err == nil {
+				t.Fatalf("a = %d, b = %d: a wrong xor is satisfiable", a, b)
+			}
+		}
+	}
+
+	// Bits decomposes the canonical representative: p + 13 has the bits of 13.
+	assignment := boolCircuit{A: 0, B: 0, And: 0, Or: 0, Xor: 0, NotA: 1, X: NewF("2013265934")}
+	for i := range assignment.Bits {
+		assignment.Bits[i] = (13 >> i) & 1
+	}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A Bool must be 0 or 1.
+	assignment.A, assignment.NotA = 2, -1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("2 is accepted as a Bool")
+	}
+}
+
+type zeroCircuit struct {
+	X      Variable
+	IsZero frontend.Variable
+}
+
+func (c *zeroCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	api.AssertIsEqual(chip.IsZeroF(c.X).Variable(), c.IsZero)
+	return nil
+}
+
+func TestIsZero(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := zeroCircuit{X: NewF("0")}
+
+	// p is a representative of zero.
+	for _, x := range []string{"0", "2013265921"} {
+		assignment := zeroCircuit{X: NewF(x), IsZero: 1}
+		assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+	}
+	assignment := zeroCircuit{X: NewF("2013265922"), IsZero: 0}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.IsZero = 1
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type assertMsgCircuit struct {
+	A, B Variable
+}
+
+func (c *assertMsgCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualFMsg(c.A, c.B, "the opened value does not match the evaluation of the quotient")
+	return nil
+}
+
+func TestAssertIsEqualFMsg(t *testing.T) {
+	circuit := assertMsgCircuit{A: NewF("0"), B: NewF("0")}
+
+	// 3 + p and 3 are equal.
+	assignment := assertMsgCircuit{A: NewF("2013265924"), B: NewF("3")}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	assignment.B = NewF("4")
+	err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField())
+	if err == nil || !strings.Contains(err.Error(), "the opened value does not match the evaluation of the quotient: 3 != 4") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type debugCircuit struct {
+	A     Variable
+	debug bool
+}
+
+func (c *debugCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.Debug = c.debug
+	// A bound the value does not respect, like a gadget with a bookkeeping bug would produce.
+	a := Variable{Value: c.A.Value, UpperBound: big.NewInt(10)}
+	chip.ReduceSlow(chip.MulF(a, a, false))
+	return nil
+}
+
+func TestDebug(t *testing.T) {
+	circuit := debugCircuit{A: NewF("0"), debug: true}
+
+	assignment := debugCircuit{A: NewF("10")}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The wrong bound goes unnoticed without the debug mode.
+	assignment = debugCircuit{A: NewF("11")}
+	if err := test.IsSolved(&debugCircuit{A: NewF("0")}, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+	err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField())
+	if err == nil || !strings.Contains(err.Error(), "MulF called from") || !strings.Contains(err.Error(), "field_test.go") ||
+		!strings.Contains(err.Error(), "121 exceeds its bound 100") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type lazyReductionCircuit struct {
+	X, Power Variable
+	strict   bool
+}
+
+func (c *lazyReductionCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.Strict = c.strict
+	// Without reductions the bound of x^10 would have 320 bits.
+	power := c.X
+	for i := 1; i < 10; i++ {
+		power = chip.MulF(power, c.X, false)
+	}
+	chip.AssertIsEqualF(power, c.Power)
+	return nil
+}
+
+func TestLazyReduction(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := lazyReductionCircuit{X: NewF("0"), Power: NewF("0")}
+
+	// (p - 1)^10 = 1 and 2^10 = 1024.
+	assignment := lazyReductionCircuit{X: NewF("2013265920"), Power: NewF("1")}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+	assignment = lazyReductionCircuit{X: NewF("2"), Power: NewF("1024")}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Power = NewF("1025")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	circuit.strict = true
+	_, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	if err == nil || !strings.Contains(err.Error(), "MulF overflows the native field") {
+		t.Fatalf("expected the strict chip to reject the overflow, got %v", err)
+	}
+}
+
+type reductionsCircuit struct {
+	X    [64]Variable
+	bits bool
+}
+
+func (c *reductionsCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	if c.bits {
+		chip.RangeChecker = NewBitsRangeChecker(api)
+	} else {
+		chip.RangeChecker = rangecheck.New(api)
+	}
+	for _, x := range c.X {
+		chip.ReduceSlow(chip.MulF(x, x, false))
+	}
+	return nil
+}
+
+// BenchmarkRangeCheck reports the constraints of a reduction with each range checker, for the
+// builders of both backends.
+func BenchmarkRangeCheck(b *testing.B) {
+	builders := map[string]frontend.NewBuilder{"plonk": scs.NewBuilder, "groth16": r1cs.NewBuilder}
+	for name, newBuilder := range builders {
+		for _, bits := range []bool{false, true} {
+			checker := "logderivative"
+			if bits {
+				checker = "bits"
+			}
+			b.Run(name+"/"+checker, func(b *testing.B) {
+				circuit := reductionsCircuit{bits: bits}
+				for i := range circuit.X {
+					circuit.X[i] = NewF("0")
+				}
+				var nbConstraints int
+				for i := 0; i < b.N; i++ {
+					ccs, err := frontend.Compile(ecc.BN254.ScalarField(), newBuilder, &circuit)
+					if err != nil {
+						b.Fatal(err)
+					}
+					nbConstraints = ccs.GetNbConstraints()
+				}
+				b.ReportMetric(float64(nbConstraints)/float64(len(circuit.X)), "constraints/reduction")
+			})
+		}
+	}
+}
+
+type reduceBatchCircuit struct {
+	Xs, Reduced [3]Variable
+}
+
+func (c *reduceBatchCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	// The first value is known to be canonical and is not reduced.
+	xs := []Variable{NewFConst("7"), chip.MulF(c.Xs[1], c.Xs[1], false), c.Xs[2]}
+	reduced := chip.ReduceBatch(xs)
+	for i := range reduced {
+		api.AssertIsEqual(reduced[i].Value, c.Reduced[i].Value)
+	}
+	return nil
+}
+
+func TestReduceBatch(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var circuit reduceBatchCircuit
+	for i := range circuit.Xs {
+		circuit.Xs[i] = NewF("0")
+		circuit.Reduced[i] = NewF("0")
+	}
+
+	// (p - 1)^2 = 1 and 2^32 - 1 = 2^32 - 1 - 2p.
+	assignment := reduceBatchCircuit{
+		Xs:      [3]Variable{NewF("0"), NewF("2013265920"), NewF("4294967295")},
+		Reduced: [3]Variable{NewF("7"), NewF("1"), NewF("268435453")},
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Reduced[2] = NewF("4294967295")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type binaryCircuit struct {
+	X          Variable
+	Bits, Ones [32]frontend.Variable
+	Packed     Variable
+}
+
+func (c *binaryCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	bits := chip.ToBinaryF(c.X, 32)
+	for i := range bits {
+		api.AssertIsEqual(bits[i], c.Bits[i])
+	}
+	packed := chip.FromBinaryF(c.Ones[:])
+	api.AssertIsEqual(packed.Value, c.Packed.Value)
+	return nil
+}
+
+func TestBinary(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var circuit binaryCircuit
+	circuit.X = NewF("0")
+	circuit.Packed = NewF("0")
+
+	// 2^32 - 1 = 268435453 + 2p, both as the input of ToBinaryF and as the bits of FromBinaryF.
+	assignment := binaryCircuit{X: NewF("4294967295"), Packed: NewF("268435453")}
+	for i := range assignment.Bits {
+		assignment.Bits[i] = (268435453 >> i) & 1
+		assignment.Ones[i] = 1
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Bits[31] = 1
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type constantFoldingCircuit struct {
+	X Variable
+}
+
+func (c *constantFoldingCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	// ImmF constants carry the bound of a witness, 2^32.
+	a, b := NewF("2013265926"), NewF("3")
+	folded := chip.ReduceSlow(chip.SubF(chip.MulF(a, b), NewF("1")))
+	api.AssertIsEqual(folded.Value, c.X.Value)
+	return nil
+}
+
+func TestConstantFolding(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := constantFoldingCircuit{X: NewF("0")}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	assert.NoError(err)
+	assert.Equal(1, ccs.GetNbConstraints())
+
+	assignment := constantFoldingCircuit{X: NewF("14")}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type reductionStrategyCircuit struct {
+	A, B, Product Variable
+}
+
+func (c *reductionStrategyCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.Reduction = field.ReductionEager
+	product := chip.MulF(c.A, c.B)
+	if product.UpperBound.Cmp(modulus) >= 0 {
+		return fmt.Errorf("eager reduction left the product bounded by %s", product.UpperBound)
+	}
+	chip.AssertIsEqualF(product, c.Product)
+	return nil
+}
+
+func TestReductionStrategy(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	for _, name := range []string{"", "deferred", "eager", "aggressive-unsafe"} {
+		strategy, err := field.ParseReductionStrategy(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "" && strategy.String() != name {
+			t.Errorf("%q parsed as %s", name, strategy)
+		}
+	}
+	if _, err := field.ParseReductionStrategy("lazy"); err == nil {
+		t.Error("parsed an unknown strategy")
+	}
+
+	circuit := reductionStrategyCircuit{A: NewF("0"), B: NewF("0"), Product: NewF("0")}
+	assignment := reductionStrategyCircuit{A: NewF("2013265920"), B: NewF("2"), Product: NewF("2013265919")}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type constantOperandsCircuit struct {
+	A, Sum, Difference, Negation Variable
+}
+
+func (c *constantOperandsCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualF(chip.AddFConst(c.A, 7), c.Sum)
+	chip.AssertIsEqualF(chip.SubFConst(c.A, 7), c.Difference)
+	chip.AssertIsEqualF(chip.NegF(c.A), c.Negation)
+	chip.AssertIsEqualF(chip.SubFConst(chip.AddFConst(c.A, 2013265920), 2013265920), c.A)
+	return nil
+}
+
+func TestConstantOperands(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := constantOperandsCircuit{A: NewF("0"), Sum: NewF("0"), Difference: NewF("0"), Negation: NewF("0")}
+	assignment := constantOperandsCircuit{
+		A:          NewF("3"),
+		Sum:        NewF("10"),
+		Difference: NewF("2013265917"),
+		Negation:   NewF("2013265918"),
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Sum = NewF("11")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type sqrtCircuit struct {
+	X        Variable
+	IsSquare frontend.Variable
+}
+
+func (c *sqrtCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	root, isSquare := chip.SqrtF(c.X)
+	api.AssertIsEqual(isSquare.Variable(), c.IsSquare)
+	chip.AssertIsEqualFIf(isSquare, chip.MulF(root, root), c.X)
+	return nil
+}
+
+func TestSqrtF(t *testing.T) {
+	circuit := sqrtCircuit{X: NewF("0")}
+
+	// 31 generates the multiplicative group, so it is not a square.
+	for _, c := range []struct {
+		x        string
+		isSquare int
+	}{{"0", 1}, {"4", 1}, {"1", 1}, {"2013265920", 1}, {"31", 0}, {"62", 0}} {
+		assignment := sqrtCircuit{X: NewF(c.x), IsSquare: c.isSquare}
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("%s: %v", c.x, err)
+		}
+		assignment.IsSquare = 1 - c.isSquare
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatalf("%s: wrong square flag accepted", c.x)
+		}
+	}
+}
+
+// TestSqrtFRejectsNonCanonicalRoot solves the compiled circuit with a dishonest hint claiming that
+// zero is not a square, with p as the certificate root: p is zero modulo p but not as a native
+// value. The test engine always calls the honest hints, so the hint is overridden in the solver.
+func TestSqrtFRejectsNonCanonicalRoot(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &sqrtCircuit{X: NewF("0")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	honest, err := frontend.NewWitness(&sqrtCircuit{X: NewF("0"), IsSquare: 1}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ccs.IsSolved(honest); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := frontend.NewWitness(&sqrtCircuit{X: NewF("0"), IsSquare: 0}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dishonest := func(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+		results[0].SetUint64(0)
+		results[1].Set(inputs[0])
+		return nil
+	}
+	if err := ccs.IsSolved(w, solver.OverrideHint(solver.GetHintID(field.SqrtHintF), dishonest)); err == nil {
+		t.Fatal("zero was certified as a non-square with a non-canonical root")
+	}
+}
@@ -0,0 +1,49 @@
+package babybear
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+func TestGadgetsAnnotated(t *testing.T) {
+	chip := reflect.TypeOf((*Chip)(nil))
+	for i := 0; i < chip.NumMethod(); i++ {
+		method := chip.Method(i)
+		spec, ok := field.Gadgets[method.Name]
+		if !ok {
+			t.Errorf("%s is not annotated in field.Gadgets", method.Name)
+			continue
+		}
+		// The receiver is the first input of the method type.
+		if got := method.Type.NumIn() - 1; got != len(spec.Inputs) {
+			t.Errorf("%s takes %d inputs, annotated with %d", method.Name, got, len(spec.Inputs))
+		}
+		if got := method.Type.NumOut(); got != len(spec.Outputs) {
+			t.Errorf("%s returns %d outputs, annotated with %d", method.Name, got, len(spec.Outputs))
+		}
+		if method.Type.IsVariadic() && spec.Inputs[len(spec.Inputs)-1] != field.KindReduceFlag {
+			t.Errorf("%s is variadic but its last input is not annotated as %s", method.Name, field.KindReduceFlag)
+		}
+	}
+	for name := range field.Gadgets {
+		if _, ok := chip.MethodByName(name); !ok {
+			t.Errorf("field.Gadgets annotates %s, which is not a method of Chip", name)
+		}
+	}
+
+	expected, err := field.GadgetsJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := os.ReadFile("../field/gadgets.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Error("field/gadgets.json is out of date, run go generate ./sp1/field")
+	}
+}
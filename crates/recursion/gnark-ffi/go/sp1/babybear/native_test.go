@@ -0,0 +1,120 @@
+package babybear
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/cmp"
+	"github.com/consensys/gnark/std/selector"
+	"github.com/consensys/gnark/test"
+)
+
+type nativeGadgetsCircuit struct {
+	A, B, C  Variable
+	Sel      frontend.Variable
+	Selected Variable
+	IsLess   frontend.Variable
+}
+
+func (c *nativeGadgetsCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	a, b, cc := chip.Native(c.A), chip.Native(c.B), chip.Native(c.C)
+
+	selected := chip.FromCanonical(selector.Mux(api, c.Sel, a, b, cc))
+	chip.AssertIsEqualF(selected, c.Selected)
+
+	comparator := cmp.NewBoundedComparator(api, modulus, false)
+	api.AssertIsEqual(comparator.IsLess(a, b), c.IsLess)
+	return nil
+}
+
+func TestNativeGadgets(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := nativeGadgetsCircuit{A: NewF("0"), B: NewF("0"), C: NewF("0"), Selected: NewF("0")}
+
+	// A is 5 + p: only its canonical representative 5 is less than 7.
+	assignment := nativeGadgetsCircuit{
+		A:        NewF("2013265926"),
+		B:        NewF("7"),
+		C:        NewF("9"),
+		Sel:      0,
+		Selected: NewF("5"),
+		IsLess:   1,
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Sel = 2
+	assignment.Selected = NewF("9")
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.IsLess = 0
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type canonicalCircuit struct {
+	X Variable
+}
+
+func (c *canonicalCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertCanonical(c.X)
+	return nil
+}
+
+func TestAssertCanonical(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := canonicalCircuit{X: NewF("0")}
+	for _, x := range []string{"0", "1", "2013265920"} {
+		assignment := canonicalCircuit{X: NewF(x)}
+		assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+	}
+	// p, 5 + p and 2^32 - 1 are valid representatives, but not canonical.
+	for _, x := range []string{"2013265921", "2013265926", "4294967295"} {
+		assignment := canonicalCircuit{X: NewF(x)}
+		assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+	}
+}
+
+type packCircuit struct {
+	Xs     [8]Variable
+	Packed frontend.Variable
+}
+
+func (c *packCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	api.AssertIsEqual(chip.PackF(c.Xs[:]), c.Packed)
+	unpacked := chip.UnpackF(c.Packed, len(c.Xs))
+	for i := range unpacked {
+		chip.AssertIsEqualF(unpacked[i], c.Xs[i])
+	}
+	return nil
+}
+
+func TestPackF(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var circuit packCircuit
+	for i := range circuit.Xs {
+		circuit.Xs[i] = NewF("0")
+	}
+
+	// The first element is 5 + p, packed as 5.
+	var assignment packCircuit
+	packed := new(big.Int)
+	for i := len(assignment.Xs) - 1; i >= 0; i-- {
+		x := big.NewInt(int64(i + 5))
+		packed.Lsh(packed, 31).Add(packed, x)
+		assignment.Xs[i] = NewF(x.String())
+	}
+	assignment.Xs[0] = NewF("2013265926")
+	assignment.Packed = packed
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Packed = new(big.Int).Add(packed, big.NewInt(1))
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
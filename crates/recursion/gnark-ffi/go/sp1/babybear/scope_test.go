@@ -0,0 +1,61 @@
+package babybear
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/google/pprof/profile"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+type scopedCircuit struct {
+	A, B Variable
+}
+
+func (c *scopedCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.RangeChecker = NewBitsRangeChecker(api)
+	defer chip.Scope("outer")()
+	product := chip.MulF(c.A, c.B)
+	func() {
+		defer chip.Scope("inner")()
+		chip.AssertIsEqualF(chip.ReduceSlow(product), c.A)
+	}()
+	return nil
+}
+
+func TestScopeProfile(t *testing.T) {
+	p := field.StartScopeProfile()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &scopedCircuit{A: NewF("0"), B: NewF("0")})
+	p.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	constraints := p.Constraints()
+	total := 0
+	for _, n := range constraints {
+		total += n
+	}
+	if total == 0 || total > ccs.GetNbConstraints() {
+		t.Fatalf("%d constraints in scopes, %d in the circuit", total, ccs.GetNbConstraints())
+	}
+	if constraints["outer/inner/field.reduce"] == 0 || constraints["outer/inner/field.reduce/field.range_check"] == 0 {
+		t.Errorf("the reduction is not attributed to the nested scopes: %v", constraints)
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	prof, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prof.Sample) != len(constraints) {
+		t.Errorf("the pprof profile has %d samples for %d scopes", len(prof.Sample), len(constraints))
+	}
+}
@@ -0,0 +1,42 @@
+package babybear
+
+import "math/big"
+
+// TwoAdicity is the log2 of the order of the largest two-adic multiplicative subgroup of BabyBear:
+// p - 1 = 15 * 2^27.
+const TwoAdicity = 27
+
+// twoAdicGenerator generates the subgroup of order 2^TwoAdicity, like Plonky3's
+// BabyBear::TWO_ADIC_GENERATOR.
+const twoAdicGenerator = 440564289
+
+// TwoAdicGenerator returns the generator g^(2^(TwoAdicity - logN)) of the subgroup of order
+// 2^logN, the one Plonky3 uses for domains of that size.
+func TwoAdicGenerator(logN int) *big.Int {
+	if logN < 0 || logN > TwoAdicity {
+		panic("logN exceeds the two-adicity of BabyBear")
+	}
+	exponent := new(big.Int).Lsh(big.NewInt(1), uint(TwoAdicity-logN))
+	return new(big.Int).Exp(big.NewInt(twoAdicGenerator), exponent, modulus)
+}
+
+// DomainPoint returns g^index, the point of row index of the subgroup of order 2^logN.
+func DomainPoint(logN int, index uint64) *big.Int {
+	return new(big.Int).Exp(TwoAdicGenerator(logN), new(big.Int).SetUint64(index), modulus)
+}
+
+// PowOfGenerator computes g^index in the circuit, where g generates the subgroup of order 2^logN
+// and index is given by its little-endian bits, e.g. the bits of a FRI query index. It takes one
+// select and one multiplication per bit, the powers g^(2^i) being constants.
+func PowOfGenerator(chip *Chip, logN int, bits []Bool) Variable {
+	if len(bits) > logN {
+		panic("the index has more bits than the subgroup")
+	}
+	result := One()
+	pow := TwoAdicGenerator(logN)
+	for _, bit := range bits {
+		result = chip.MulF(result, chip.SelectF(bit, NewFConst(pow.String()), One()))
+		pow.Mul(pow, pow).Mod(pow, modulus)
+	}
+	return chip.ReduceSlow(result)
+}
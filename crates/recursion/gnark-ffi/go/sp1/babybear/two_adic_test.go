@@ -0,0 +1,55 @@
+package babybear
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestTwoAdicGenerator(t *testing.T) {
+	one := big.NewInt(1)
+	for logN := 0; logN <= TwoAdicity; logN++ {
+		g := TwoAdicGenerator(logN)
+		order := new(big.Int).Lsh(one, uint(logN))
+		if new(big.Int).Exp(g, order, modulus).Cmp(one) != 0 {
+			t.Fatalf("the generator of logN %d does not have order 2^%d", logN, logN)
+		}
+		if logN > 0 && new(big.Int).Exp(g, order.Rsh(order, 1), modulus).Cmp(one) == 0 {
+			t.Fatalf("the generator of logN %d has order less than 2^%d", logN, logN)
+		}
+	}
+}
+
+type powOfGeneratorCircuit struct {
+	Bits   [5]frontend.Variable
+	Result Variable
+}
+
+func (c *powOfGeneratorCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	bits := make([]Bool, len(c.Bits))
+	for i := range bits {
+		bits[i] = chip.NewBool(c.Bits[i])
+	}
+	chip.AssertIsEqualF(PowOfGenerator(chip, 7, bits), c.Result)
+	return nil
+}
+
+func TestPowOfGenerator(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := powOfGeneratorCircuit{Result: NewF("0")}
+	// 22 = 0b10110.
+	assignment := powOfGeneratorCircuit{
+		Bits:   [5]frontend.Variable{0, 1, 1, 0, 1},
+		Result: NewF(DomainPoint(7, 22).String()),
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Result = NewF(DomainPoint(7, 23).String())
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
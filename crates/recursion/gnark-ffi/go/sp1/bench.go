@@ -0,0 +1,194 @@
+package sp1
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"time"
+)
+
+// heapSampleInterval is the delay between two samples of the heap while benchmarking a proof.
+var heapSampleInterval = 10 * time.Millisecond
+
+// BenchResult measures a proof of a witness with the artifacts of a data dir. The prove time
+// includes reading the circuit and the proving key, like the first proof of a prover does.
+type BenchResult struct {
+	DataDir       string `json:"data_dir"`
+	WitnessPath   string `json:"witness_path"`
+	Constraints   int    `json:"constraints"`
+	ProveTimeMs   int64  `json:"prove_time_ms"`
+	PeakHeapBytes uint64 `json:"peak_heap_bytes"`
+	ProofBytes    int    `json:"proof_bytes"`
+}
+
+// BenchDelta is the change of each measurement from the old artifacts to the new ones, in absolute
+// value and in percent of the old one.
+type BenchDelta struct {
+	Constraints        int     `json:"constraints"`
+	ConstraintsPercent float64 `json:"constraints_percent"`
+	ProveTimeMs        int64   `json:"prove_time_ms"`
+	ProveTimePercent   float64 `json:"prove_time_percent"`
+	PeakHeapBytes      int64   `json:"peak_heap_bytes"`
+	PeakHeapPercent    float64 `json:"peak_heap_percent"`
+	ProofBytes         int     `json:"proof_bytes"`
+	ProofBytesPercent  float64 `json:"proof_bytes_percent"`
+}
+
+// BenchComparison is the report written by WriteBenchComparison.
+type BenchComparison struct {
+	System string      `json:"system"`
+	Old    BenchResult `json:"old"`
+	New    BenchResult `json:"new"`
+	Delta  BenchDelta  `json:"delta"`
+}
+
+// Bench proves the witness at witnessPath with the circuit of system ("plonk" or "groth16") in
+// dataDir and measures the proof. An empty witnessPath proves the witness the artifacts were
+// built with, since a witness of another release may not match the shape of the circuit.
+func Bench(dataDir string, system string, witnessPath string) BenchResult {
+	var prove func(string, string) Proof
+	var builtWitnessPath string
+	switch system {
+	case "plonk":
+		prove, builtWitnessPath = ProvePlonk, plonkWitnessPath
+		forgetPlonkArtifacts(dataDir)
+	case "groth16":
+		prove, builtWitnessPath = ProveGroth16, groth16WitnessPath
+		// The Groth16 circuit is kept for the process whatever its data dir, drop it so that
+		// the one of dataDir is read and timed.
+		forgetGroth16Artifacts()
+	default:
+		panic(fmt.Errorf("unknown proof system %q", system))
+	}
+	if witnessPath == "" {
+		witnessPath = filepath.Join(dataDir, builtWitnessPath)
+	}
+
+	runtime.GC()
+	debug.FreeOSMemory()
+	stopSampling := sampleHeap()
+	start := time.Now()
+	proof := prove(dataDir, witnessPath)
+	elapsed := time.Since(start)
+	peakHeap := stopSampling()
+
+	return BenchResult{
+		DataDir:       dataDir,
+		WitnessPath:   witnessPath,
+		Constraints:   benchConstraints(dataDir, system),
+		ProveTimeMs:   elapsed.Milliseconds(),
+		PeakHeapBytes: peakHeap,
+		ProofBytes:    len(proof.RawProof) / 2,
+	}
+}
+
+// sampleHeap samples the live heap until the returned function is called, which returns its peak.
+// runtime/metrics is read rather than runtime.MemStats since it does not stop the world.
+func sampleHeap() func() uint64 {
+	samples := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	var peak uint64
+	sample := func() {
+		metrics.Read(samples)
+		if samples[0].Value.Kind() == metrics.KindUint64 {
+			peak = max(peak, samples[0].Value.Uint64())
+		}
+	}
+	sample()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(heapSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				sample()
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		<-stopped
+		sample()
+		return peak
+	}
+}
+
+// benchConstraints returns the constraints of the circuit in dataDir, as recorded by the build in
+// constraint_stats.json or else read from the circuit proven with.
+func benchConstraints(dataDir string, system string) int {
+	if data, err := os.ReadFile(filepath.Join(dataDir, constraintStatsPath)); err == nil {
+		var stats CircuitStats
+		if json.Unmarshal(data, &stats) == nil && stats.Total > 0 {
+			return stats.Total
+		}
+	}
+	if system == "plonk" {
+		return loadPlonkArtifacts(dataDir).scs.GetNbConstraints()
+	}
+	globalMutex.RLock()
+	defer globalMutex.RUnlock()
+	return globalR1cs.GetNbConstraints()
+}
+
+// compareBench returns the deltas from old to new.
+func compareBench(old BenchResult, new BenchResult) BenchDelta {
+	return BenchDelta{
+		Constraints:        new.Constraints - old.Constraints,
+		ConstraintsPercent: percentChange(float64(old.Constraints), float64(new.Constraints)),
+		ProveTimeMs:        new.ProveTimeMs - old.ProveTimeMs,
+		ProveTimePercent:   percentChange(float64(old.ProveTimeMs), float64(new.ProveTimeMs)),
+		PeakHeapBytes:      int64(new.PeakHeapBytes) - int64(old.PeakHeapBytes),
+		PeakHeapPercent:    percentChange(float64(old.PeakHeapBytes), float64(new.PeakHeapBytes)),
+		ProofBytes:         new.ProofBytes - old.ProofBytes,
+		ProofBytesPercent:  percentChange(float64(old.ProofBytes), float64(new.ProofBytes)),
+	}
+}
+
+func percentChange(old float64, new float64) float64 {
+	if old == 0 {
+		return 0
+	}
+	return (new - old) / old * 100
+}
+
+// WriteBenchComparison proves the same witness with the artifacts of oldDir and newDir, e.g. those
+// of two releases, and writes the measurements and their deltas to outputPath as JSON. An empty
+// witnessPath proves the witness each set of artifacts was built with.
+func WriteBenchComparison(oldDir string, newDir string, system string, witnessPath string, outputPath string) error {
+	if oldDir == "" || newDir == "" {
+		return fmt.Errorf("both artifact dirs are required")
+	}
+	if system != "plonk" && system != "groth16" {
+		return fmt.Errorf("unknown proof system %q", system)
+	}
+
+	comparison := BenchComparison{System: system}
+	comparison.Old = Bench(oldDir, system, witnessPath)
+	comparison.New = Bench(newDir, system, witnessPath)
+	comparison.Delta = compareBench(comparison.Old, comparison.New)
+
+	data, err := json.MarshalIndent(comparison, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return err
+	}
+
+	delta := comparison.Delta
+	fmt.Printf("%-12s %14s %14s %14s\n", "", "old", "new", "delta")
+	fmt.Printf("%-12s %14d %14d %+13.1f%%\n", "constraints", comparison.Old.Constraints, comparison.New.Constraints, delta.ConstraintsPercent)
+	fmt.Printf("%-12s %14d %14d %+13.1f%%\n", "prove ms", comparison.Old.ProveTimeMs, comparison.New.ProveTimeMs, delta.ProveTimePercent)
+	fmt.Printf("%-12s %14d %14d %+13.1f%%\n", "peak heap", comparison.Old.PeakHeapBytes, comparison.New.PeakHeapBytes, delta.PeakHeapPercent)
+	fmt.Printf("%-12s %14d %14d %+13.1f%%\n", "proof bytes", comparison.Old.ProofBytes, comparison.New.ProofBytes, delta.ProofBytesPercent)
+	return nil
+}
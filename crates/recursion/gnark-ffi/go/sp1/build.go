@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
 	"github.com/consensys/gnark-crypto/kzg"
 	groth16 "github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
@@ -15,15 +16,19 @@ import (
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
 	"github.com/succinctlabs/sp1-recursion-gnark/sp1/trusted_setup"
 )
 
-func BuildPlonk(dataDir string) {
+// BuildPlonk builds the PLONK circuit in dataDir and records its vkey under circuitVersion, see
+// recordVkey.
+func BuildPlonk(dataDir string, circuitVersion string) {
 	// Set the environment variable for the constraints file.
 	//
 	// TODO: There might be some non-determinism if a single process is running this command
 	// multiple times.
 	os.Setenv("CONSTRAINTS_JSON", dataDir+"/"+constraintsJsonFile)
+	forgetPlonkArtifacts(dataDir)
 
 	// Read the file.
 	witnessInputPath := dataDir + "/" + plonkWitnessPath
@@ -39,14 +44,21 @@ func BuildPlonk(dataDir string) {
 		panic(err)
 	}
 
+	printFriSoundness(dataDir)
+	printReductionStrategy()
+
 	// Initialize the circuit.
 	circuit := NewCircuit(witnessInput)
+	circuit.Stats = newCircuitStats(dataDir)
 
 	// Compile the circuit.
+	writeScopeProfile := startScopeProfile()
 	scs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
 	if err != nil {
 		panic(err)
 	}
+	writeScopeProfile()
+	circuit.Stats.finish(scs.GetNbConstraints())
 
 	// Download the trusted setup.
 	var srs kzg.SRS = kzg.NewSRS(ecc.BN254)
@@ -63,8 +75,16 @@ func BuildPlonk(dataDir string) {
 
 	if !strings.Contains(dataDir, "dev") {
 		if _, err := os.Stat(srsFileName); os.IsNotExist(err) {
-			fmt.Println("downloading aztec ignition srs")
-			trusted_setup.DownloadAndSaveAztecIgnitionSrs(174, srsFileName)
+			source := os.Getenv(plonkSrsEnv)
+			if source == "" {
+				source = trusted_setup.AztecIgnition
+			}
+			fmt.Println("deriving the srs from", source)
+			provenance, err := trusted_setup.SaveSRS(source, srsFileName)
+			if err != nil {
+				panic(err)
+			}
+			writeSrsProvenance(dataDir, provenance)
 
 			srsFile, err := os.Open(srsFileName)
 			if err != nil {
@@ -99,6 +119,10 @@ func BuildPlonk(dataDir string) {
 				panic(err)
 			}
 
+			// The SRS was derived by an older build, which did not record where it comes from.
+			if _, err := os.Stat(dataDir + "/" + srsProvenanceFile); os.IsNotExist(err) {
+				writeSrsProvenance(dataDir, trusted_setup.Provenance{Ceremony: trusted_setup.Unknown})
+			}
 		}
 	} else {
 		srs, srsLagrange, err = unsafekzg.NewSRS(scs)
@@ -121,6 +145,7 @@ func BuildPlonk(dataDir string) {
 		if err != nil {
 			panic(err)
 		}
+		writeSrsProvenance(dataDir, trusted_setup.Provenance{Ceremony: trusted_setup.Unsafe, NbG1Points: len(srs.(*kzg_bn254.SRS).Pk.G1)})
 	}
 
 	// Generate the proving and verifying key.
@@ -152,6 +177,7 @@ func BuildPlonk(dataDir string) {
 
 	// Create the build directory.
 	os.MkdirAll(dataDir, 0755)
+	circuit.Stats.write(dataDir)
 
 	// Write the solidity verifier.
 	solidityVerifierFile, err := os.Create(dataDir + "/" + plonkVerifierContractPath)
@@ -193,9 +219,27 @@ func BuildPlonk(dataDir string) {
 	if err != nil {
 		panic(err)
 	}
+
+	recordVkey(dataDir, circuitVersion, plonkVkPath, []string{constraintsJsonFile, plonkCircuitPath, plonkPkPath, plonkVerifierContractPath, srsProvenanceFile})
+}
+
+// plonkSrsEnv selects the source of the SRS of the PLONK circuit, see trusted_setup.SaveSRS.
+const plonkSrsEnv = "SP1_PLONK_SRS"
+
+// writeSrsProvenance records where the SRS comes from next to it. The file is hashed with the
+// other artifacts in the vkey registry, so that the provenance is pinned with the vkey.
+func writeSrsProvenance(dataDir string, provenance trusted_setup.Provenance) {
+	data, err := json.MarshalIndent(provenance, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(dataDir+"/"+srsProvenanceFile, append(data, '\n'), 0644); err != nil {
+		panic(err)
+	}
 }
 
-func BuildGroth16(dataDir string) {
+// BuildGroth16 builds the Groth16 circuit like BuildPlonk.
+func BuildGroth16(dataDir string, circuitVersion string) {
 	// Set the environment variable for the constraints file.
 	//
 	// TODO: There might be some non-determinism if a single process is running this command
@@ -217,14 +261,21 @@ func BuildGroth16(dataDir string) {
 		panic(err)
 	}
 
+	printFriSoundness(dataDir)
+	printReductionStrategy()
+
 	// Initialize the circuit.
 	circuit := NewCircuit(witnessInput)
+	circuit.Stats = newCircuitStats(dataDir)
 
 	// Compile the circuit.
+	writeScopeProfile := startScopeProfile()
 	r1cs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
 	if err != nil {
 		panic(err)
 	}
+	writeScopeProfile()
+	circuit.Stats.finish(r1cs.GetNbConstraints())
 
 	// Generate the proving and verifying key.
 	pk, vk, err := groth16.Setup(r1cs)
@@ -255,6 +306,7 @@ func BuildGroth16(dataDir string) {
 
 	// Create the build directory.
 	os.MkdirAll(dataDir, 0755)
+	circuit.Stats.write(dataDir)
 
 	// Write the solidity verifier.
 	solidityVerifierFile, err := os.Create(dataDir + "/" + groth16VerifierContractPath)
@@ -286,6 +338,30 @@ func BuildGroth16(dataDir string) {
 		panic(err)
 	}
 
+	// Write the verifier key in the raw layout, for runtimes without the EVM precompiles.
+	vkRawFile, err := os.Create(dataDir + "/" + groth16RawVkPath)
+	if err != nil {
+		panic(err)
+	}
+	defer vkRawFile.Close()
+	err = ExportGroth16VerifyingKey(vk, vkRawFile)
+	if err != nil {
+		panic(err)
+	}
+
+	// Write the batch verifier, if requested.
+	if os.Getenv(groth16BatchVerifierEnv) == "1" {
+		batchVerifierFile, err := os.Create(dataDir + "/" + groth16BatchVerifierContractPath)
+		if err != nil {
+			panic(err)
+		}
+		defer batchVerifierFile.Close()
+		err = ExportGroth16BatchVerifier(vk, batchVerifierFile)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	// Write the proving key.
 	pkFile, err := os.Create(dataDir + "/" + groth16PkPath)
 	if err != nil {
@@ -296,4 +372,14 @@ func BuildGroth16(dataDir string) {
 	if err != nil {
 		panic(err)
 	}
+
+	recordVkey(dataDir, circuitVersion, groth16VkPath, []string{constraintsJsonFile, groth16CircuitPath, groth16PkPath, groth16VerifierContractPath, groth16RawVkPath})
+}
+
+func printReductionStrategy() {
+	strategy := field.ReductionStrategyFromEnv()
+	fmt.Printf("Reduction strategy: %s\n", strategy)
+	if strategy == field.ReductionAggressiveUnsafe {
+		fmt.Println("WARNING: building with the aggressive reduction strategy, the circuit has not been reviewed for soundness")
+	}
 }
@@ -0,0 +1,95 @@
+package sp1
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// The checkpoint dir, enabled by setting SP1_CHECKPOINT_DIR, lets a preempted prover pick up a
+// wrap proof where it stopped instead of starting over. gnark solves the witness and computes the
+// proof in a single call without exposing its intermediate state, so the only stage boundary that
+// can be persisted is the finished proof: it is written as soon as it is computed, keyed by the
+// system, the verifying key and the witness, and returned by later calls for the same witness
+// once it verifies again, e.g. when the process was killed before handing the proof over.
+const checkpointDirEnv = "SP1_CHECKPOINT_DIR"
+
+// SetCheckpointDir enables checkpoints in dir, or disables them if dir is empty.
+func SetCheckpointDir(dir string) error {
+	if dir == "" {
+		return os.Unsetenv(checkpointDirEnv)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.Setenv(checkpointDirEnv, dir)
+}
+
+type proofCheckpoint struct {
+	path string
+}
+
+// openProofCheckpoint returns the checkpoint of the proof of witnessData with the verifying key at
+// vkPath, or nil if checkpoints are disabled.
+func openProofCheckpoint(system string, vkPath string, witnessData []byte) *proofCheckpoint {
+	dir := os.Getenv(checkpointDirEnv)
+	if dir == "" {
+		return nil
+	}
+	vkData, err := os.ReadFile(vkPath)
+	if err != nil {
+		fmt.Printf("Disabling the checkpoint, reading the verifying key failed: %v\n", err)
+		return nil
+	}
+	key := digest([]byte(system + digest(vkData) + digest(witnessData)))
+	return &proofCheckpoint{path: filepath.Join(dir, system+"-"+key+".proof")}
+}
+
+// load reads the checkpointed proof into proof, returning false if there is none.
+func (c *proofCheckpoint) load(proof io.ReaderFrom) bool {
+	if c == nil {
+		return false
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return false
+	}
+	if _, err := proof.ReadFrom(bytes.NewReader(data)); err != nil {
+		fmt.Printf("Ignoring the corrupted checkpoint %s: %v\n", c.path, err)
+		return false
+	}
+	fmt.Printf("Resuming from the checkpoint %s\n", c.path)
+	return true
+}
+
+// save writes proof to the checkpoint. Checkpoints are best effort: failures are only reported.
+func (c *proofCheckpoint) save(proof io.WriterTo) {
+	if c == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		fmt.Printf("Writing the checkpoint failed: %v\n", err)
+		return
+	}
+	// Write to a temporary file first, so that a crash never leaves a truncated checkpoint.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		fmt.Printf("Writing the checkpoint failed: %v\n", err)
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		fmt.Printf("Writing the checkpoint failed: %v\n", err)
+	}
+}
+
+// discard removes a checkpoint that turned out to be invalid.
+func (c *proofCheckpoint) discard() {
+	if c == nil {
+		return
+	}
+	fmt.Printf("Discarding the invalid checkpoint %s\n", c.path)
+	os.Remove(c.path)
+}
@@ -0,0 +1,77 @@
+package sp1
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+)
+
+// AbiVersion is the version of the C interface exported by the library. It is bumped whenever an
+// exported function or struct changes in a way the existing Rust bindings cannot call.
+const AbiVersion = 1
+
+// WitnessSchemaVersion is the version of the witness JSON read by the provers, see WitnessInput.
+const WitnessSchemaVersion = 1
+
+// Compatibility is a combination of artifacts and interfaces this binary can prove and verify with.
+type Compatibility struct {
+	CircuitVersion       string `json:"circuit_version"`
+	System               string `json:"system"`
+	VkeyHash             string `json:"vkey_hash"`
+	WitnessSchemaVersion int    `json:"witness_schema_version"`
+	AbiVersion           int    `json:"abi_version"`
+}
+
+// CompatibilityMatrix returns the combinations supported with the artifacts in dataDir: one per
+// vkey recorded in its registry, sorted by circuit version, system and vkey hash. Vkeys whose
+// system cannot be told from their recorded artifacts are skipped.
+func CompatibilityMatrix(dataDir string) ([]Compatibility, error) {
+	registry, err := ReadVkeyRegistry(dataDir + "/" + vkeyRegistryPath)
+	if err != nil {
+		return nil, err
+	}
+	matrix := []Compatibility{}
+	for version, vkeys := range registry {
+		for vkeyHash, artifacts := range vkeys {
+			var system string
+			if _, ok := artifacts[plonkCircuitPath]; ok {
+				system = "plonk"
+			} else if _, ok := artifacts[groth16CircuitPath]; ok {
+				system = "groth16"
+			} else {
+				continue
+			}
+			matrix = append(matrix, Compatibility{
+				CircuitVersion:       version,
+				System:               system,
+				VkeyHash:             vkeyHash,
+				WitnessSchemaVersion: WitnessSchemaVersion,
+				AbiVersion:           AbiVersion,
+			})
+		}
+	}
+	sort.Slice(matrix, func(i, j int) bool {
+		a, b := matrix[i], matrix[j]
+		if a.CircuitVersion != b.CircuitVersion {
+			return a.CircuitVersion < b.CircuitVersion
+		}
+		if a.System != b.System {
+			return a.System < b.System
+		}
+		return a.VkeyHash < b.VkeyHash
+	})
+	return matrix, nil
+}
+
+// WriteCompatibilityMatrix writes the compatibility matrix of dataDir to outputPath as JSON.
+func WriteCompatibilityMatrix(dataDir string, outputPath string) error {
+	matrix, err := CompatibilityMatrix(dataDir)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(matrix, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, append(data, '\n'), 0644)
+}
@@ -0,0 +1,151 @@
+package sp1
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"github.com/consensys/gnark/logger"
+	"github.com/rs/zerolog"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+// Config gathers the settings of the prover in a single JSON file, so that a setup can be
+// reproduced from one file instead of a set of environment variables. Every field is optional and
+// overrides the corresponding environment variable when set; settings given on the command line
+// or through the FFI after LoadConfig override the file in turn.
+type Config struct {
+	// AuditLog and AuditLogMaxBytes replace SP1_AUDIT_LOG and SP1_AUDIT_LOG_MAX_BYTES.
+	AuditLog         string `json:"audit_log,omitempty"`
+	AuditLogMaxBytes int64  `json:"audit_log_max_bytes,omitempty"`
+
+	// CircuitVersion replaces SP1_CIRCUIT_VERSION.
+	CircuitVersion string `json:"circuit_version,omitempty"`
+
+	// Parallelism is the number of threads used by the prover (GOMAXPROCS).
+	Parallelism int `json:"parallelism,omitempty"`
+
+	// MemoryLimit and GCPercent tune the Go runtime like GOMEMLIMIT and GOGC.
+	MemoryLimit int64 `json:"memory_limit,omitempty"`
+	GCPercent   *int  `json:"gc_percent,omitempty"`
+
+	// MemoryPerThread replaces SP1_PROVER_MEMORY_PER_THREAD, the scratch memory of a prover thread
+	// in bytes. Provers warn when the available memory cannot cover a thread per CPU.
+	MemoryPerThread int64 `json:"memory_per_thread,omitempty"`
+
+	// Groth16BatchVerifier replaces SP1_GROTH16_BATCH_VERIFIER=1, exporting Groth16BatchVerifier.sol
+	// when building the Groth16 circuit.
+	Groth16BatchVerifier bool `json:"groth16_batch_verifier,omitempty"`
+
+	// PlonkSrs replaces SP1_PLONK_SRS, the source of the SRS of the PLONK circuit, see
+	// trusted_setup.SaveSRS.
+	PlonkSrs string `json:"plonk_srs,omitempty"`
+
+	// CheckpointDir replaces SP1_CHECKPOINT_DIR, see SetCheckpointDir.
+	CheckpointDir string `json:"checkpoint_dir,omitempty"`
+
+	// ReductionStrategy replaces SP1_REDUCTION_STRATEGY, the reduction strategy of the circuits
+	// built: "deferred", "eager" or "aggressive-unsafe", see field.ReductionStrategy.
+	ReductionStrategy string `json:"reduction_strategy,omitempty"`
+
+	// SolverStallTimeout replaces SP1_SOLVER_STALL_TIMEOUT, how long a hint may run while solving
+	// a witness before the prover dumps its state, as a Go duration. The check is off by default.
+	SolverStallTimeout string `json:"solver_stall_timeout,omitempty"`
+
+	// LogLevel is the level of gnark's logger: "debug", "info", "warn", "error" or "disabled".
+	LogLevel string `json:"log_level,omitempty"`
+}
+
+// ReadConfig reads a config file, rejecting unknown fields so that typos are not silently
+// ignored.
+func ReadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var config Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Apply applies the settings of the config to this process.
+func (c Config) Apply() error {
+	var logLevel zerolog.Level
+	if c.LogLevel != "" {
+		var err error
+		if logLevel, err = zerolog.ParseLevel(c.LogLevel); err != nil {
+			return fmt.Errorf("invalid log level %q", c.LogLevel)
+		}
+	}
+
+	if _, err := field.ParseReductionStrategy(c.ReductionStrategy); err != nil {
+		return err
+	}
+	if c.SolverStallTimeout != "" {
+		if _, err := time.ParseDuration(c.SolverStallTimeout); err != nil {
+			return fmt.Errorf("invalid solver stall timeout %q", c.SolverStallTimeout)
+		}
+	}
+
+	variables := map[string]string{
+		auditLogEnv:              c.AuditLog,
+		"SP1_CIRCUIT_VERSION":    c.CircuitVersion,
+		plonkSrsEnv:              c.PlonkSrs,
+		"SP1_REDUCTION_STRATEGY": c.ReductionStrategy,
+		solverStallTimeoutEnv:    c.SolverStallTimeout,
+	}
+	if c.Groth16BatchVerifier {
+		variables[groth16BatchVerifierEnv] = "1"
+	}
+	if c.AuditLogMaxBytes != 0 {
+		variables[auditLogMaxBytesEnv] = strconv.FormatInt(c.AuditLogMaxBytes, 10)
+	}
+	if c.MemoryPerThread != 0 {
+		variables[memoryPerThreadEnv] = strconv.FormatInt(c.MemoryPerThread, 10)
+	}
+	for name, value := range variables {
+		if value == "" {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+
+	if c.CheckpointDir != "" {
+		if err := SetCheckpointDir(c.CheckpointDir); err != nil {
+			return err
+		}
+	}
+	if c.Parallelism != 0 {
+		runtime.GOMAXPROCS(c.Parallelism)
+	}
+	if c.MemoryLimit != 0 {
+		debug.SetMemoryLimit(c.MemoryLimit)
+	}
+	if c.GCPercent != nil {
+		debug.SetGCPercent(*c.GCPercent)
+	}
+	if c.LogLevel != "" {
+		logger.Set(logger.Logger().Level(logLevel))
+	}
+	return nil
+}
+
+// LoadConfig reads and applies the config file at path.
+func LoadConfig(path string) error {
+	config, err := ReadConfig(path)
+	if err != nil {
+		return err
+	}
+	return config.Apply()
+}
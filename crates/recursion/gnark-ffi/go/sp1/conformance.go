@@ -0,0 +1,153 @@
+package sp1
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+// ConformanceVersion is the version of the conformance bundle layout. It is bumped whenever a file
+// of the bundle or a field of its manifest changes meaning.
+const ConformanceVersion = 1
+
+// ConformanceManifest describes a conformance bundle: the circuit it was generated for and the
+// vectors an alternative verifier should agree with.
+type ConformanceManifest struct {
+	Version              int                 `json:"version"`
+	System               string              `json:"system"`
+	CircuitVersion       string              `json:"circuit_version"`
+	VerifyingKeySha256   string              `json:"verifying_key_sha256"`
+	VkeyHash             string              `json:"vkey_hash"`
+	PublicInputs         [2]string           `json:"public_inputs"`
+	WitnessSchemaVersion int                 `json:"witness_schema_version"`
+	AbiVersion           int                 `json:"abi_version"`
+	Vectors              []ConformanceVector `json:"vectors"`
+}
+
+// ConformanceVector is a proof and the public inputs it is checked against, with the result the
+// verifier of this package gives. Proofs are hex encoded in the format taken by VerifyPlonk and
+// VerifyGroth16.
+type ConformanceVector struct {
+	Name          string    `json:"name"`
+	Proof         string    `json:"proof"`
+	PublicInputs  [2]string `json:"public_inputs"`
+	ExpectedValid bool      `json:"expected_valid"`
+}
+
+// WriteConformanceBundle proves the witness at witnessPath with the circuit of the given system
+// ("plonk" or "groth16") in dataDir, and writes a gzipped tarball to outputPath for alternative
+// verifiers to check themselves against. The bundle holds the witness, the verifying key, the
+// constraints and a manifest.json listing the expected vkey hash, the expected public inputs and
+// vectors made of the valid proof and of tampered proofs and public inputs, each with the result
+// of verifying it with this package.
+func WriteConformanceBundle(dataDir string, system string, witnessPath string, outputPath string) error {
+	var prove func(string, string) Proof
+	var verify func(string, string, string, string) error
+	var vkPath string
+	switch system {
+	case "plonk":
+		prove, verify, vkPath = ProvePlonk, VerifyPlonk, plonkVkPath
+	case "groth16":
+		prove, verify, vkPath = ProveGroth16, VerifyGroth16, groth16VkPath
+	default:
+		return fmt.Errorf("unknown proof system %q", system)
+	}
+
+	proof := prove(dataDir, witnessPath)
+	vectors, err := conformanceVectors(proof)
+	if err != nil {
+		return err
+	}
+	for i := range vectors {
+		vectors[i].ExpectedValid = conformanceVerify(verify, dataDir, vectors[i])
+	}
+	if !vectors[0].ExpectedValid {
+		return fmt.Errorf("the %s proof does not verify", system)
+	}
+
+	vkDigest, err := fileDigest(filepath.Join(dataDir, vkPath))
+	if err != nil {
+		return err
+	}
+	version := os.Getenv("SP1_CIRCUIT_VERSION")
+	if version == "" {
+		version = "unknown"
+	}
+	manifest := ConformanceManifest{
+		Version:              ConformanceVersion,
+		System:               system,
+		CircuitVersion:       version,
+		VerifyingKeySha256:   "0x" + vkDigest,
+		VkeyHash:             proof.PublicInputs[0],
+		PublicInputs:         proof.PublicInputs,
+		WitnessSchemaVersion: WitnessSchemaVersion,
+		AbiVersion:           AbiVersion,
+		Vectors:              vectors,
+	}
+
+	files := make(map[string][]byte)
+	if files["manifest.json"], err = json.MarshalIndent(manifest, "", "  "); err != nil {
+		return err
+	}
+	if files["witness.json"], err = os.ReadFile(witnessPath); err != nil {
+		return err
+	}
+	for _, name := range []string{vkPath, constraintsJsonFile} {
+		if files[name], err = os.ReadFile(filepath.Join(dataDir, name)); err != nil {
+			return err
+		}
+	}
+	return writeTarGz(outputPath, fmt.Sprintf("sp1-conformance-v%d/", ConformanceVersion), files)
+}
+
+// conformanceVectors returns the valid proof followed by the tampered vectors, without their
+// expected results.
+func conformanceVectors(proof Proof) ([]ConformanceVector, error) {
+	rawProof, err := hex.DecodeString(proof.RawProof)
+	if err != nil {
+		return nil, err
+	}
+	if len(rawProof) == 0 {
+		return nil, fmt.Errorf("empty proof")
+	}
+	rawProof[len(rawProof)/2] ^= 1
+
+	vkeyHash, ok := new(big.Int).SetString(proof.PublicInputs[0], 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid vkey hash %q", proof.PublicInputs[0])
+	}
+	committedValuesDigest, ok := new(big.Int).SetString(proof.PublicInputs[1], 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid committed values digest %q", proof.PublicInputs[1])
+	}
+	one := big.NewInt(1)
+
+	return []ConformanceVector{
+		{Name: "valid", Proof: proof.RawProof, PublicInputs: proof.PublicInputs},
+		{
+			Name:         "wrong_vkey_hash",
+			Proof:        proof.RawProof,
+			PublicInputs: [2]string{vkeyHash.Add(vkeyHash, one).String(), proof.PublicInputs[1]},
+		},
+		{
+			Name:         "wrong_committed_values_digest",
+			Proof:        proof.RawProof,
+			PublicInputs: [2]string{proof.PublicInputs[0], committedValuesDigest.Add(committedValuesDigest, one).String()},
+		},
+		{Name: "tampered_proof", Proof: hex.EncodeToString(rawProof), PublicInputs: proof.PublicInputs},
+	}, nil
+}
+
+// conformanceVerify reports whether verify accepts the vector. Proofs it cannot even decode are
+// rejected.
+func conformanceVerify(verify func(string, string, string, string) error, dataDir string, vector ConformanceVector) (valid bool) {
+	defer func() {
+		if recover() != nil {
+			valid = false
+		}
+	}()
+	return verify(dataDir, vector.Proof, vector.PublicInputs[0], vector.PublicInputs[1]) == nil
+}
@@ -0,0 +1,7 @@
+// Package constants holds the BabyBear and BN254 Poseidon2 constants shared with the Rust crates.
+//
+// constants.json is exported by the sp1-recursion-gnark-ffi crate (see src/constants.rs, whose
+// test fails when the file is stale) and constants_gen.go is generated from it.
+package constants
+
+//go:generate go run ./gen -in constants.json -out constants_gen.go
@@ -0,0 +1,28 @@
+package constants
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// rc3Digest is the SHA-256 of the BN254 round constants as 32 byte big-endian integers. The Rust
+// crate checks the same digest against the constants of the outer challenger.
+const rc3Digest = "c08ebd7b8765286f6933070d74e9a1859072e58fb7d18d17f9ca97872b93f4a2"
+
+func TestPoseidon2RoundConstants3Digest(t *testing.T) {
+	h := sha256.New()
+	for _, round := range Poseidon2RoundConstants3 {
+		for _, c := range round {
+			v, ok := new(big.Int).SetString(c, 0)
+			if !ok {
+				t.Fatalf("invalid round constant %s", c)
+			}
+			h.Write(v.FillBytes(make([]byte, 32)))
+		}
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != rc3Digest {
+		t.Errorf("round constants digest is %s, expected %s", got, rc3Digest)
+	}
+}
@@ -0,0 +1,80 @@
+// Command gen generates the Go tables of the constants package from constants.json.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+)
+
+type constants struct {
+	Rc16           [][16]uint32 `json:"rc16"`
+	InternalDiagM1 [16]uint32   `json:"internal_diag_m1"`
+	MontyInverse   uint32       `json:"monty_inverse"`
+	Rc3            [][3]string  `json:"rc3"`
+}
+
+func main() {
+	in := flag.String("in", "constants.json", "constants exported by the Rust crate")
+	out := flag.String("out", "constants_gen.go", "generated Go file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var c constants
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		log.Fatalf("error deserializing %s: %v", *in, err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by go run ./gen; DO NOT EDIT.\n\npackage constants\n\n")
+	fmt.Fprintf(&buf, "// Poseidon2RoundConstants16 are the round constants of the width 16 BabyBear Poseidon2\n")
+	fmt.Fprintf(&buf, "// permutation, as raw u32 values that still have to be reduced modulo p.\n")
+	fmt.Fprintf(&buf, "var Poseidon2RoundConstants16 = [%d][16]uint32{\n", len(c.Rc16))
+	for _, round := range c.Rc16 {
+		fmt.Fprintf(&buf, "\t{")
+		for i, v := range round {
+			if i > 0 {
+				fmt.Fprintf(&buf, ", ")
+			}
+			fmt.Fprintf(&buf, "%d", v)
+		}
+		fmt.Fprintf(&buf, "},\n")
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// Poseidon2InternalDiagM1 is the diagonal of the internal matrix minus the identity, scaled by\n")
+	fmt.Fprintf(&buf, "// the Montgomery factor.\n")
+	fmt.Fprintf(&buf, "var Poseidon2InternalDiagM1 = [16]uint32{")
+	for i, v := range c.InternalDiagM1 {
+		if i > 0 {
+			fmt.Fprintf(&buf, ", ")
+		}
+		fmt.Fprintf(&buf, "%d", v)
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// MontyInverse is the inverse of the Montgomery factor 2^32 modulo p.\n")
+	fmt.Fprintf(&buf, "const MontyInverse = %d\n", c.MontyInverse)
+	fmt.Fprintf(&buf, "\n// Poseidon2RoundConstants3 are the round constants of the width 3 BN254 Poseidon2 permutation\n")
+	fmt.Fprintf(&buf, "// of the outer challenger, as hex strings.\n")
+	fmt.Fprintf(&buf, "var Poseidon2RoundConstants3 = [%d][3]string{\n", len(c.Rc3))
+	for _, round := range c.Rc3 {
+		fmt.Fprintf(&buf, "\t{%q, %q, %q},\n", round[0], round[1], round[2])
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
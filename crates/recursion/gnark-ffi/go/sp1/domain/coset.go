@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"math/big"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+// TwoAdicField describes the two-adic multiplicative subgroup of a field, in which the cosets
+// live.
+type TwoAdicField struct {
+	Modulus    uint64
+	TwoAdicity int
+	// Generator generates the subgroup of order 2^TwoAdicity.
+	Generator uint64
+}
+
+// BabyBear has a multiplicative subgroup of order 2^27.
+var BabyBear = TwoAdicField{
+	Modulus:    2013265921,
+	TwoAdicity: babybear.TwoAdicity,
+	Generator:  babybear.TwoAdicGenerator(babybear.TwoAdicity).Uint64(),
+}
+
+// Coset is the multiplicative coset shift * <g> of size 2^LogN, where g generates the subgroup of
+// order 2^LogN. It mirrors Plonky3's TwoAdicMultiplicativeCoset.
+type Coset struct {
+	Field TwoAdicField
+	LogN  int
+	Shift uint64
+}
+
+func NewCoset(f TwoAdicField, logN int, shift uint64) Coset {
+	if logN < 0 || logN > f.TwoAdicity {
+		panic("logN exceeds the two-adicity of the field")
+	}
+	return Coset{Field: f, LogN: logN, Shift: shift}
+}
+
+func (d Coset) Size() int {
+	return 1 << d.LogN
+}
+
+// Gen returns the generator g of the subgroup of order 2^LogN.
+func (d Coset) Gen() *big.Int {
+	exponent := new(big.Int).Lsh(big.NewInt(1), uint(d.Field.TwoAdicity-d.LogN))
+	return new(big.Int).Exp(new(big.Int).SetUint64(d.Field.Generator), exponent, d.modulus())
+}
+
+func (d Coset) FirstPoint() field.Variable {
+	return field.NewFConst(new(big.Int).SetUint64(d.Shift).String())
+}
+
+// NextPoint returns x * g, the point of the following row.
+func (d Coset) NextPoint(chip field.SmallField, x field.ExtensionVariable) field.ExtensionVariable {
+	d.checkField(chip)
+	return mulEConst(chip, x, d.Gen())
+}
+
+// ZpAtPoint evaluates the vanishing polynomial Z_H(x) = (x / shift)^n - 1 of the coset.
+func (d Coset) ZpAtPoint(chip field.SmallField, x field.ExtensionVariable) field.ExtensionVariable {
+	d.checkField(chip)
+	return chip.SubEF(expPowerOf2(chip, d.unshift(chip, x), d.LogN), field.One())
+}
+
+func (d Coset) modulus() *big.Int {
+	return new(big.Int).SetUint64(d.Field.Modulus)
+}
+
+// checkField panics if chip is not over the field of the coset.
+func (d Coset) checkField(chip field.SmallField) {
+	if chip.Modulus().Cmp(d.modulus()) != 0 {
+		panic("the chip is not over the field of the coset")
+	}
+}
+
+func (d Coset) unshift(chip field.SmallField, x field.ExtensionVariable) field.ExtensionVariable {
+	if d.Shift == 1 {
+		return x
+	}
+	shiftInv := new(big.Int).ModInverse(new(big.Int).SetUint64(d.Shift), d.modulus())
+	return mulEConst(chip, x, shiftInv)
+}
+
+func mulEConst(chip field.SmallField, a field.ExtensionVariable, b *big.Int) field.ExtensionVariable {
+	return chip.MulEF(a, field.NewFConst(b.String()))
+}
+
+// expPowerOf2 computes x^(2^logN) by repeated squaring.
+func expPowerOf2(chip field.SmallField, x field.ExtensionVariable, logN int) field.ExtensionVariable {
+	for i := 0; i < logN; i++ {
+		x = chip.MulE(x, x)
+	}
+	return x
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/koalabear"
+)
+
+var modulus = new(big.Int).SetUint64(BabyBear.Modulus)
+
+// baseE embeds a BabyBear element in the extension field, where the expected values are computed.
+func baseE(x *big.Int) field.ExtensionVariable {
+	return babybear.Ext{uint32(new(big.Int).Mod(x, modulus).Uint64())}.Variable()
+}
+
+func mulMod(a, b *big.Int) *big.Int {
+	return new(big.Int).Mod(new(big.Int).Mul(a, b), modulus)
+}
+
+func subMod(a, b *big.Int) *big.Int {
+	return new(big.Int).Mod(new(big.Int).Sub(a, b), modulus)
+}
+
+func invMod(a *big.Int) *big.Int {
+	return new(big.Int).ModInverse(a, modulus)
+}
+
+type cosetCircuit struct {
+	X, Next, Zp field.ExtensionVariable
+
+	coset Coset
+}
+
+func (c *cosetCircuit) Define(api frontend.API) error {
+	chip := babybear.NewChip(api)
+	chip.AssertIsEqualE(c.coset.NextPoint(chip, c.X), c.Next)
+	chip.AssertIsEqualE(c.coset.ZpAtPoint(chip, c.X), c.Zp)
+	return nil
+}
+
+func TestCoset(t *testing.T) {
+	coset := NewCoset(BabyBear, 4, 31)
+	g := coset.Gen()
+	one := big.NewInt(1)
+	if new(big.Int).Exp(g, big.NewInt(16), modulus).Cmp(one) != 0 || new(big.Int).Exp(g, big.NewInt(8), modulus).Cmp(one) == 0 {
+		t.Fatalf("%s does not generate the subgroup of order 16", g)
+	}
+
+	shift := new(big.Int).SetUint64(coset.Shift)
+	circuit := cosetCircuit{X: babybear.Ext{}.Variable(), Next: babybear.Ext{}.Variable(), Zp: babybear.Ext{}.Variable(), coset: coset}
+	for _, c := range []struct {
+		name string
+		x    *big.Int
+		zp   *big.Int
+	}{
+		// The vanishing polynomial is zero on the coset, including on its first and last points.
+		{name: "first point", x: shift, zp: big.NewInt(0)},
+		{name: "fifth point", x: mulMod(shift, new(big.Int).Exp(g, big.NewInt(5), modulus)), zp: big.NewInt(0)},
+		{name: "last point", x: mulMod(shift, invMod(g)), zp: big.NewInt(0)},
+		// (2 * 31 / 31)^16 - 1.
+		{name: "outside of the coset", x: big.NewInt(62), zp: big.NewInt(65535)},
+		// The subgroup itself is outside of the coset.
+		{name: "subgroup", x: big.NewInt(1), zp: subMod(new(big.Int).Exp(invMod(shift), big.NewInt(16), modulus), one)},
+	} {
+		assignment := cosetCircuit{X: baseE(c.x), Next: baseE(mulMod(c.x, g)), Zp: baseE(c.zp)}
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+		}
+		assignment.Zp = baseE(new(big.Int).Add(c.zp, one))
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Errorf("%s: a wrong evaluation of the vanishing polynomial is satisfiable", c.name)
+		}
+	}
+}
+
+type koalabearCosetCircuit struct {
+	X field.ExtensionVariable
+}
+
+func (c *koalabearCosetCircuit) Define(api frontend.API) error {
+	NewCoset(BabyBear, 4, 31).ZpAtPoint(koalabear.NewChip(api), c.X)
+	return nil
+}
+
+func TestCosetRejectsOtherFields(t *testing.T) {
+	if _, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &koalabearCosetCircuit{X: babybear.Ext{}.Variable()}); err == nil {
+		t.Fatal("evaluated a BabyBear coset with a KoalaBear chip")
+	}
+}
+
+func TestNewCosetRejectsLargeDomains(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("created a coset larger than the two-adic subgroup")
+		}
+	}()
+	NewCoset(BabyBear, BabyBear.TwoAdicity+1, 31)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"math/big"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+// LagrangeSelectors holds the selector evaluations the constraint folder needs at the
+// out-of-domain point.
+type LagrangeSelectors struct {
+	IsFirstRow   field.ExtensionVariable
+	IsLastRow    field.ExtensionVariable
+	IsTransition field.ExtensionVariable
+	InvZeroifier field.ExtensionVariable
+}
+
+// SelectorsAtPoint evaluates the first row, last row and transition selectors of the coset at
+// point:
+//
+//	is_first_row = Z_H(x) / (x / shift - 1)
+//	is_last_row = Z_H(x) / (x / shift - g^-1)
+//	is_transition = x / shift - g^-1
+//	inv_zeroifier = 1 / Z_H(x)
+func (d Coset) SelectorsAtPoint(chip field.SmallField, point field.ExtensionVariable) LagrangeSelectors {
+	d.checkField(chip)
+	gInv := new(big.Int).ModInverse(d.Gen(), d.modulus())
+
+	unshifted := d.unshift(chip, point)
+	zH := chip.SubEF(expPowerOf2(chip, unshifted, d.LogN), field.One())
+	lastRowDenominator := chip.SubEF(unshifted, field.NewFConst(gInv.String()))
+
+	return LagrangeSelectors{
+		IsFirstRow:   chip.DivE(zH, chip.SubEF(unshifted, field.One())),
+		IsLastRow:    chip.DivE(zH, lastRowDenominator),
+		IsTransition: lastRowDenominator,
+		InvZeroifier: chip.InvE(zH),
+	}
+}
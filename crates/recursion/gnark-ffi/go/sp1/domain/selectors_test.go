@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+type selectorsCircuit struct {
+	Point    field.ExtensionVariable
+	Expected [4]field.ExtensionVariable
+
+	coset Coset
+}
+
+func (c *selectorsCircuit) Define(api frontend.API) error {
+	chip := babybear.NewChip(api)
+	selectors := c.coset.SelectorsAtPoint(chip, c.Point)
+	chip.AssertIsEqualE(selectors.IsFirstRow, c.Expected[0])
+	chip.AssertIsEqualE(selectors.IsLastRow, c.Expected[1])
+	chip.AssertIsEqualE(selectors.IsTransition, c.Expected[2])
+	chip.AssertIsEqualE(selectors.InvZeroifier, c.Expected[3])
+	return nil
+}
+
+// expectedSelectors evaluates the selectors of coset at x natively, from their definitions as
+// Lagrange polynomials rather than from the formulas of SelectorsAtPoint: the first row selector
+// is the Lagrange polynomial of the first point, i.e. prod_{i > 0} (x - x_i) / (x_0 - x_i).
+func expectedSelectors(coset Coset, x *big.Int) [4]*big.Int {
+	points := make([]*big.Int, coset.Size())
+	points[0] = new(big.Int).SetUint64(coset.Shift)
+	for i := 1; i < len(points); i++ {
+		points[i] = mulMod(points[i-1], coset.Gen())
+	}
+	lagrange := func(j int) *big.Int {
+		result := big.NewInt(1)
+		for i, point := range points {
+			if i != j {
+				result = mulMod(result, mulMod(subMod(x, point), invMod(subMod(points[j], point))))
+			}
+		}
+		return result
+	}
+	zH := big.NewInt(1)
+	for _, point := range points {
+		zH = mulMod(zH, subMod(x, point))
+	}
+	// The vanishing polynomial and the selectors are normalized like Plonky3's, with Z_H(x) =
+	// (x / shift)^n - 1 and the first row selector scaled by Z_H(x) / (x / shift - 1).
+	scale := invMod(new(big.Int).Exp(points[0], big.NewInt(int64(len(points))), modulus))
+	zH = mulMod(zH, scale)
+	n := big.NewInt(int64(len(points)))
+	unshifted := mulMod(x, invMod(points[0]))
+	return [4]*big.Int{
+		mulMod(lagrange(0), n),
+		mulMod(mulMod(lagrange(len(points)-1), n), coset.Gen()),
+		subMod(unshifted, invMod(coset.Gen())),
+		invMod(zH),
+	}
+}
+
+func TestSelectorsAtPoint(t *testing.T) {
+	for _, coset := range []Coset{NewCoset(BabyBear, 3, 1), NewCoset(BabyBear, 3, 31)} {
+		circuit := selectorsCircuit{Point: babybear.Ext{}.Variable(), coset: coset}
+		for i := range circuit.Expected {
+			circuit.Expected[i] = babybear.Ext{}.Variable()
+		}
+		for _, x := range []int64{2, 1000003} {
+			expected := expectedSelectors(coset, big.NewInt(x))
+			assignment := selectorsCircuit{Point: baseE(big.NewInt(x))}
+			for i := range expected {
+				assignment.Expected[i] = baseE(expected[i])
+			}
+			if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+				t.Errorf("shift %d, point %d: %v", coset.Shift, x, err)
+			}
+			for i := range expected {
+				wrong := assignment
+				wrong.Expected[i] = baseE(new(big.Int).Add(expected[i], big.NewInt(1)))
+				if err := test.IsSolved(&circuit, &wrong, ecc.BN254.ScalarField()); err == nil {
+					t.Errorf("shift %d, point %d: a wrong selector %d is satisfiable", coset.Shift, x, i)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package field
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// Bool is a variable constrained to be 0 or 1. Its value can only be set through the chip, so a
+// Bool passed to a select is guaranteed to be constrained.
+type Bool struct {
+	value frontend.Variable
+}
+
+func NewBoolConst(b bool) Bool {
+	if b {
+		return Bool{value: frontend.Variable(1)}
+	}
+	return Bool{value: frontend.Variable(0)}
+}
+
+func (b Bool) Variable() frontend.Variable {
+	return b.value
+}
+
+// NewBool constrains v to be 0 or 1.
+func (c *Chip[P]) NewBool(v frontend.Variable) Bool {
+	c.api.AssertIsBoolean(v)
+	return Bool{value: v}
+}
+
+func (c *Chip[P]) And(a, b Bool) Bool {
+	return Bool{value: c.api.And(a.value, b.value)}
+}
+
+func (c *Chip[P]) Or(a, b Bool) Bool {
+	return Bool{value: c.api.Or(a.value, b.value)}
+}
+
+func (c *Chip[P]) Xor(a, b Bool) Bool {
+	return Bool{value: c.api.Xor(a.value, b.value)}
+}
+
+func (c *Chip[P]) Not(a Bool) Bool {
+	return Bool{value: c.api.Sub(1, a.value)}
+}
+
+// SelectV returns a if cond is 1 and b otherwise.
+func (c *Chip[P]) SelectV(cond Bool, a, b frontend.Variable) frontend.Variable {
+	return c.api.Select(cond.value, a, b)
+}
+
+// Bits returns the little-endian bit decomposition of the canonical representative of in.
+func (c *Chip[P]) Bits(in Variable) []Bool {
+	bits := c.ToBinary(in)
+	result := make([]Bool, len(bits))
+	for i, bit := range bits {
+		result[i] = Bool{value: bit}
+	}
+	return result
+}
+
+// IsZeroF returns 1 if x is zero modulo p, e.g. 0 or p, and 0 otherwise.
+func (c *Chip[P]) IsZeroF(x Variable) Bool {
+	return Bool{value: c.api.IsZero(c.ReduceSlow(x).Value)}
+}
+
+func (c *Chip[P]) IsEqualF(a, b Variable) Bool {
+	a2 := c.ReduceSlow(a)
+	b2 := c.ReduceSlow(b)
+	return Bool{value: c.api.IsZero(c.api.Sub(a2.Value, b2.Value))}
+}
@@ -0,0 +1,77 @@
+package field
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// Byte arrays are big-endian, as in SHA-256 and Keccak inputs and in the digests packed by the
+// recursion circuit: the first byte is the most significant one.
+
+// ToBytes returns the big-endian bytes of the canonical representative of x. The first byte holds
+// the NbBits % 8 most significant bits, so a BabyBear element takes 4 bytes, the first one at most
+// 7 bits long.
+func (c *Chip[P]) ToBytes(x Variable) []frontend.Variable {
+	return bitsToBytes(c.api, c.ToBinary(x))
+}
+
+// FromBytes returns the element with the given big-endian bytes, constraining every byte to 8 bits
+// and the result to be canonical.
+func (c *Chip[P]) FromBytes(bytes []frontend.Variable) Variable {
+	if len(bytes)*8 > c.nbBits+7 {
+		panic("too many bytes for a field element")
+	}
+	for _, b := range bytes {
+		c.rangeCheck(b, 8)
+	}
+	return c.FromNative(bytesToNative(c.api, bytes))
+}
+
+// ToBytes returns the nbBytes big-endian bytes of a BN254 variable, constraining it to fit. With
+// 32 bytes the decomposition is also constrained to be the canonical one.
+func ToBytes(api frontend.API, v frontend.Variable, nbBytes int) []frontend.Variable {
+	return bitsToBytes(api, api.ToBinary(v, nbBytes*8))
+}
+
+// FromBytes packs big-endian bytes into a BN254 variable, constraining every byte to 8 bits. At
+// most 31 bytes fit without wrapping around the modulus; use FromDigest for 32 byte digests.
+func FromBytes(api frontend.API, bytes []frontend.Variable) frontend.Variable {
+	if len(bytes) > 31 {
+		panic("at most 31 bytes fit in a BN254 variable")
+	}
+	for _, b := range bytes {
+		api.ToBinary(b, 8)
+	}
+	return bytesToNative(api, bytes)
+}
+
+// FromDigest packs a 32 byte digest into a BN254 variable, dropping its 3 most significant bits
+// like babybear_bytes_to_bn254 in the recursion circuit.
+func FromDigest(api frontend.API, digest [32]frontend.Variable) frontend.Variable {
+	bits := make([]frontend.Variable, 0, 253)
+	for i := 31; i >= 0; i-- {
+		byteBits := api.ToBinary(digest[i], 8)
+		if i == 0 {
+			byteBits = byteBits[:5]
+		}
+		bits = append(bits, byteBits...)
+	}
+	return api.FromBinary(bits...)
+}
+
+// bitsToBytes groups little-endian bits into big-endian bytes.
+func bitsToBytes(api frontend.API, bits []frontend.Variable) []frontend.Variable {
+	nbBytes := (len(bits) + 7) / 8
+	bytes := make([]frontend.Variable, nbBytes)
+	for i := 0; i < nbBytes; i++ {
+		bytes[nbBytes-1-i] = api.FromBinary(bits[8*i : min(8*i+8, len(bits))]...)
+	}
+	return bytes
+}
+
+func bytesToNative(api frontend.API, bytes []frontend.Variable) frontend.Variable {
+	result := frontend.Variable(0)
+	for _, b := range bytes {
+		result = api.Add(api.Mul(result, 256), b)
+	}
+	return result
+}
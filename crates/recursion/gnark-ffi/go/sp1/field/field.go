@@ -0,0 +1,1083 @@
+package field
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
+)
+
+func init() {
+	// These functions must be public so Gnark's hint system can access them.
+	solver.RegisterHint(InvFHint)
+	solver.RegisterHint(ReduceHint)
+	solver.RegisterHint(SplitLimbsHint)
+	solver.RegisterHint(SqrtHintF)
+	solver.RegisterHint(AssertEqualHint)
+	solver.RegisterHint(ReduceBatchHint)
+	solver.RegisterHint(UnpackHint)
+	solver.RegisterHint(DebugBoundHint)
+}
+
+const debugEnv = "SP1_FIELD_DEBUG"
+
+// fieldPackage is the import path of this package, which callSite skips.
+var fieldPackage = reflect.TypeOf(Variable{}).PkgPath()
+
+// FieldParams describes a small prime field (at most 31 bits) emulated over the BN254 scalar
+// field, together with its degree 4 binomial extension F[X]/(X^4 - W).
+type FieldParams interface {
+	// Modulus returns the field modulus p.
+	Modulus() *big.Int
+
+	// NbBits returns the number of bits of the modulus.
+	NbBits() int
+
+	// MontyInverse returns the inverse of the Montgomery factor 2^32 modulo p.
+	MontyInverse() *big.Int
+
+	// ExtW returns the non-residue W defining the degree 4 extension, or 0 if the field has no
+	// such extension, which is the case when p = 3 mod 4. The extension multiplications then panic.
+	ExtW() int
+}
+
+type Variable struct {
+	Value      frontend.Variable
+	UpperBound *big.Int
+}
+
+type ExtensionVariable struct {
+	Value [4]Variable
+}
+
+type Chip[P FieldParams] struct {
+	api frontend.API
+
+	// RangeChecker checks the quotients and limbs of the reductions. NewChip picks the
+	// log-derivative range checker of gnark, which costs far fewer constraints than decomposing
+	// each value into bits but adds a commitment to the circuit, except for Groth16 (GROTH16=1)
+	// whose verifiers do not support commitments. Set it to NewBitsRangeChecker or
+	// rangecheck.New to choose per backend.
+	RangeChecker frontend.Rangechecker
+
+	// Strict makes the chip panic when the bound of a result would overflow the native field,
+	// instead of reducing the operands first. Gadgets placing their reductions by hand set it to
+	// check at compile time that they are sufficient.
+	Strict bool
+
+	// Reduction is the strategy reducing the results the callers leave to the chip. NewChip sets
+	// it from SP1_REDUCTION_STRATEGY.
+	Reduction ReductionStrategy
+
+	// Debug makes solving the witness check the value of every result of the arithmetic against
+	// its upper bound, and fail naming the operation and the line calling the chip if it exceeds
+	// it. A value above its bound means that the bound bookkeeping is wrong and the reductions may
+	// be unsound. It adds no constraint. NewChip enables it when SP1_FIELD_DEBUG is set to 1.
+	Debug bool
+
+	modulus      *big.Int
+	modulusSub1  *big.Int
+	nbBits       int
+	extW         int
+	lowLimbBits  int
+	highLimbBits int
+
+	// The largest bit length of a bound that reduceWithMaxBits can reduce without the quotient
+	// times the modulus wrapping around the native field.
+	maxBoundBits int
+
+	// The smallest quadratic non-residue, used as a certificate by SqrtF.
+	nonResidue *big.Int
+
+	// Results of MulFConst, keyed by the multiplied variable and the constant.
+	mulFConstCache map[mulFConstKey]mulFConstEntry
+
+	// Results of Powers, keyed by the coefficients of the base.
+	powersCache map[[4]frontend.Variable]powersEntry
+
+	// The squarings of ExpE, keyed like powersCache.
+	squaresCache map[[4]frontend.Variable]powersEntry
+}
+
+type mulFConstKey struct {
+	value    frontend.Variable
+	constant int
+	reduce   bool
+}
+
+type mulFConstEntry struct {
+	inputBound *big.Int
+	result     Variable
+}
+
+type powersEntry struct {
+	inputBounds [4]*big.Int
+	powers      []ExtensionVariable
+}
+
+func NewChip[P FieldParams](api frontend.API) *Chip[P] {
+	var params P
+	modulus := params.Modulus()
+	modulusSub1 := new(big.Int).Sub(modulus, big.NewInt(1))
+
+	// The canonical check in reduceWithMaxBits splits an element into a high and a low limb, which
+	// requires p - 1 to be of the form (2^h - 1) * 2^l.
+	lowLimbBits := int(modulusSub1.TrailingZeroBits())
+	highLimbBits := params.NbBits() - lowLimbBits
+	maxHighLimb := new(big.Int).Rsh(modulusSub1, uint(lowLimbBits))
+	if maxHighLimb.Cmp(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(highLimbBits)), big.NewInt(1))) != 0 {
+		panic("modulus - 1 must be of the form (2^h - 1) * 2^l")
+	}
+
+	// Euler's criterion: x is a non-residue iff x^((p - 1) / 2) = -1.
+	nonResidue := big.NewInt(2)
+	legendreExponent := new(big.Int).Rsh(modulusSub1, 1)
+	for new(big.Int).Exp(nonResidue, legendreExponent, modulus).Cmp(modulusSub1) != 0 {
+		nonResidue.Add(nonResidue, big.NewInt(1))
+	}
+
+	// X^4 - W is irreducible iff W is a non-residue and p = 1 mod 4.
+	extW := params.ExtW()
+	if extW != 0 {
+		w := big.NewInt(int64(extW))
+		if modulus.Bit(1) != 0 || new(big.Int).Exp(w, legendreExponent, modulus).Cmp(modulusSub1) != 0 {
+			panic(fmt.Sprintf("X^4 - %d is reducible modulo %s", extW, modulus))
+		}
+	}
+
+	return &Chip[P]{
+		api:            api,
+		RangeChecker:   newRangeChecker(api),
+		Debug:          os.Getenv(debugEnv) == "1",
+		Reduction:      ReductionStrategyFromEnv(),
+		modulus:        modulus,
+		modulusSub1:    modulusSub1,
+		nbBits:         params.NbBits(),
+		extW:           extW,
+		lowLimbBits:    lowLimbBits,
+		highLimbBits:   highLimbBits,
+		maxBoundBits:   api.Compiler().Field().BitLen() - 2,
+		nonResidue:     nonResidue,
+		mulFConstCache: make(map[mulFConstKey]mulFConstEntry),
+		powersCache:    make(map[[4]frontend.Variable]powersEntry),
+		squaresCache:   make(map[[4]frontend.Variable]powersEntry),
+	}
+}
+
+func Zero() Variable {
+	return Variable{
+		Value:      frontend.Variable("0"),
+		UpperBound: new(big.Int).SetUint64(0),
+	}
+}
+
+func One() Variable {
+	return Variable{
+		Value:      frontend.Variable("1"),
+		UpperBound: new(big.Int).SetUint64(1),
+	}
+}
+
+func NewFConst(value string) Variable {
+	int_value, success := new(big.Int).SetString(value, 10)
+	if !success {
+		panic("string to int conversion failed")
+	}
+	return Variable{
+		Value:      frontend.Variable(value),
+		UpperBound: int_value,
+	}
+}
+
+func NewF(value string) Variable {
+	return Variable{
+		Value:      frontend.Variable(value),
+		UpperBound: new(big.Int).SetUint64(uint64(math.Pow(2, 32))),
+	}
+}
+
+func NewE(value []string) ExtensionVariable {
+	a := NewF(value[0])
+	b := NewF(value[1])
+	c := NewF(value[2])
+	d := NewF(value[3])
+	return ExtensionVariable{Value: [4]Variable{a, b, c, d}}
+}
+
+func NewEConst(value []string) ExtensionVariable {
+	a := NewFConst(value[0])
+	b := NewFConst(value[1])
+	c := NewFConst(value[2])
+	d := NewFConst(value[3])
+	return ExtensionVariable{Value: [4]Variable{a, b, c, d}}
+}
+
+func Felts2Ext(a, b, c, d Variable) ExtensionVariable {
+	return ExtensionVariable{Value: [4]Variable{a, b, c, d}}
+}
+
+// constantF returns the canonical representative of x if it is known at compile time.
+func (c *Chip[P]) constantF(x Variable) (*big.Int, bool) {
+	value, ok := c.api.Compiler().ConstantValue(x.Value)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).Mod(value, c.modulus), true
+}
+
+// foldF returns x as a constant with its canonical value as the bound if it is known at compile
+// time, e.g. an ImmF declared with the bound of a witness. Operations on two constants are then
+// computed natively, and the bound of an operation with a constant is as tight as it can be.
+func (c *Chip[P]) foldF(x Variable) (Variable, bool) {
+	value, ok := c.constantF(x)
+	if !ok {
+		return x, false
+	}
+	return Variable{Value: value, UpperBound: new(big.Int).Set(value)}, true
+}
+
+// foldOperands folds a and b, and reports whether both are constants.
+func (c *Chip[P]) foldOperands(a, b Variable) (Variable, Variable, bool) {
+	a, aConstant := c.foldF(a)
+	b, bConstant := c.foldF(b)
+	return a, b, aConstant && bConstant
+}
+
+func (c *Chip[P]) AddF(a, b Variable, forceReduce ...bool) Variable {
+	a, b, constant := c.foldOperands(a, b)
+	if constant {
+		folded, _ := c.foldF(Variable{Value: new(big.Int).Add(a.UpperBound, b.UpperBound)})
+		return folded
+	}
+	a, b = c.fitOperands("AddF", a, b, addBounds)
+	result := c.checkBound("AddF", Variable{
+		Value:      c.api.Add(a.Value, b.Value),
+		UpperBound: new(big.Int).Add(a.UpperBound, b.UpperBound),
+	})
+	if len(forceReduce) > 0 && !forceReduce[0] {
+		return result
+	}
+	return c.reduceResult(result)
+}
+
+func (c *Chip[P]) SubF(a, b Variable) Variable {
+	negB := c.NegF(b)
+	return c.AddF(a, negB)
+}
+
+func (c *Chip[P]) MulF(a, b Variable, forceReduce ...bool) Variable {
+	a, b, constant := c.foldOperands(a, b)
+	if constant {
+		folded, _ := c.foldF(Variable{Value: new(big.Int).Mul(a.UpperBound, b.UpperBound)})
+		return folded
+	}
+	a, b = c.fitOperands("MulF", a, b, mulBounds)
+	result := c.checkBound("MulF", Variable{
+		Value:      c.api.Mul(a.Value, b.Value),
+		UpperBound: new(big.Int).Mul(a.UpperBound, b.UpperBound),
+	})
+	if len(forceReduce) > 0 && !forceReduce[0] {
+		return result
+	}
+	return c.reduceResult(result)
+}
+
+func (c *Chip[P]) MulFConst(a Variable, b int, forceReduce ...bool) Variable {
+	reduce := len(forceReduce) == 0 || forceReduce[0]
+	constant := new(big.Int).SetUint64(uint64(b))
+	a, aConstant := c.foldF(a)
+	if aConstant {
+		folded, _ := c.foldF(Variable{Value: new(big.Int).Mul(a.UpperBound, constant)})
+		return folded
+	}
+	if !c.fits("MulFConst", new(big.Int).Mul(a.UpperBound, constant)) {
+		a = c.reduceOperand(a)
+	}
+
+	// Only variables with a comparable representation can be used as map keys (e.g. the r1cs
+	// builder uses slices for linear expressions), so the cache is skipped for the others.
+	var key mulFConstKey
+	cacheable := a.Value != nil && reflect.TypeOf(a.Value).Comparable()
+	if cacheable {
+		key = mulFConstKey{value: a.Value, constant: b, reduce: reduce}
+		if entry, ok := c.mulFConstCache[key]; ok && entry.inputBound.Cmp(a.UpperBound) == 0 {
+			return entry.result
+		}
+	}
+
+	result := c.checkBound("MulFConst", Variable{
+		Value:      c.api.Mul(a.Value, b),
+		UpperBound: new(big.Int).Mul(a.UpperBound, constant),
+	})
+	if reduce {
+		result = c.reduceResult(result)
+	}
+	if cacheable {
+		c.mulFConstCache[key] = mulFConstEntry{inputBound: a.UpperBound, result: result}
+	}
+	return result
+}
+
+// AddFConst returns a + b for a constant b, e.g. a round constant. Unlike AddF on a constant
+// Variable, the constant is neither parsed nor folded at each call, and it is added to the
+// linear expression of a natively.
+func (c *Chip[P]) AddFConst(a Variable, b int, forceReduce ...bool) Variable {
+	if b < 0 {
+		panic("AddFConst: negative constant")
+	}
+	if b == 0 {
+		return a
+	}
+	constant := big.NewInt(int64(b))
+	if a, ok := c.foldF(a); ok {
+		folded, _ := c.foldF(Variable{Value: constant.Add(constant, a.UpperBound)})
+		return folded
+	}
+	if !c.fits("AddFConst", new(big.Int).Add(a.UpperBound, constant)) {
+		a = c.reduceOperand(a)
+	}
+	result := c.checkBound("AddFConst", Variable{
+		Value:      c.api.Add(a.Value, b),
+		UpperBound: constant.Add(constant, a.UpperBound),
+	})
+	if len(forceReduce) > 0 && !forceReduce[0] {
+		return result
+	}
+	return c.reduceResult(result)
+}
+
+// SubFConst returns a - b for a constant b, as a plus the canonical representative of -b.
+func (c *Chip[P]) SubFConst(a Variable, b int, forceReduce ...bool) Variable {
+	if b < 0 {
+		panic("SubFConst: negative constant")
+	}
+	negB := new(big.Int).Sub(c.modulus, big.NewInt(int64(b)))
+	negB.Mod(negB, c.modulus)
+	return c.AddFConst(a, int(negB.Int64()), forceReduce...)
+}
+
+// NegF returns -a, as the smallest multiple of the modulus above the bound of a minus a.
+func (c *Chip[P]) NegF(a Variable) Variable {
+	if a, ok := c.foldF(a); ok {
+		folded, _ := c.foldF(Variable{Value: new(big.Int).Sub(c.modulus, a.UpperBound)})
+		return folded
+	}
+	if !c.fits("NegF", new(big.Int).Add(a.UpperBound, c.modulus)) {
+		a = c.reduceOperand(a)
+	}
+	divisor := new(big.Int).Div(a.UpperBound, c.modulus)
+	divisorPlusOne := new(big.Int).Add(divisor, big.NewInt(1))
+	liftedModulus := new(big.Int).Mul(divisorPlusOne, c.modulus)
+
+	return c.reduceResult(c.checkBound("NegF", Variable{
+		Value:      c.api.Sub(liftedModulus, a.Value),
+		UpperBound: liftedModulus,
+	}))
+}
+
+// InvF returns the inverse of in, witnessed by a hint and checked with a multiplication.
+// The circuit is unsatisfiable if in is zero.
+func (c *Chip[P]) InvF(in Variable) Variable {
+	result, err := c.api.Compiler().NewHint(InvFHint, 1, c.modulus, in.Value)
+	if err != nil {
+		panic(err)
+	}
+
+	xinv := Variable{
+		Value:      result[0],
+		UpperBound: new(big.Int).Lsh(big.NewInt(1), uint(c.nbBits)),
+	}
+	c.rangeCheck(result[0], c.nbBits)
+	product := c.MulF(in, xinv)
+	c.AssertIsEqualF(product, NewFConst("1"))
+
+	return xinv
+}
+
+// ExpF returns x^e for a constant exponent, by square and multiply from the most significant bit.
+// The intermediate results are reduced lazily by MulF, so their bounds stay tracked.
+func (c *Chip[P]) ExpF(x Variable, e uint64) Variable {
+	if e == 0 {
+		return One()
+	}
+	result := x
+	for i := bits.Len64(e) - 2; i >= 0; i-- {
+		result = c.MulF(result, result)
+		if e>>uint(i)&1 == 1 {
+			result = c.MulF(result, x)
+		}
+	}
+	return result
+}
+
+// BatchInvF inverts xs with Montgomery's trick: a single InvF of the product of all the elements,
+// and three multiplications per element to recover the individual inverses. The circuit is
+// unsatisfiable if any of the elements is zero.
+func (c *Chip[P]) BatchInvF(xs []Variable) []Variable {
+	if len(xs) == 0 {
+		return nil
+	}
+	// prefix[i] = xs[0] * ... * xs[i].
+	prefix := make([]Variable, len(xs))
+	prefix[0] = xs[0]
+	for i := 1; i < len(xs); i++ {
+		prefix[i] = c.MulF(prefix[i-1], xs[i])
+	}
+
+	// inv is (xs[0] * ... * xs[i])^-1 at the start of iteration i.
+	inv := c.InvF(prefix[len(xs)-1])
+	out := make([]Variable, len(xs))
+	for i := len(xs) - 1; i > 0; i-- {
+		out[i] = c.MulF(inv, prefix[i-1])
+		inv = c.MulF(inv, xs[i])
+	}
+	out[0] = inv
+	return out
+}
+
+// SqrtF returns a square root of x together with a boolean that is 1 if x is a square. When x is
+// not a square, the returned root is a square root of x times a fixed non-residue instead, which
+// certifies that x has no square root.
+func (c *Chip[P]) SqrtF(x Variable) (Variable, Bool) {
+	result, err := c.api.Compiler().NewHint(SqrtHintF, 2, c.modulus, c.nonResidue, x.Value)
+	if err != nil {
+		panic(err)
+	}
+
+	isSquare := c.NewBool(result[0])
+
+	// The root must be canonical, so that it is zero exactly when x * nonResidue is zero. It comes
+	// from a hint, so its bound only holds once checked: AssertCanonical would trust it.
+	c.assertCanonical(result[1])
+	root := Variable{Value: result[1], UpperBound: c.modulusSub1}
+
+	nonResidue := NewFConst(c.nonResidue.String())
+	target := c.SelectF(isSquare, x, c.MulF(x, nonResidue))
+	c.AssertIsEqualF(c.MulF(root, root), target)
+
+	// Zero is a square, so the non-residue certificate must not be used for it.
+	c.api.AssertIsEqual(c.api.Mul(c.Not(isSquare).value, c.api.IsZero(root.Value)), 0)
+
+	return root, isSquare
+}
+
+func (c *Chip[P]) DivF(a, b Variable) Variable {
+	bInv := c.InvF(b)
+	return c.MulF(a, bInv)
+}
+
+func (c *Chip[P]) AssertIsEqualF(a, b Variable) {
+	a2 := c.ReduceSlow(a)
+	b2 := c.ReduceSlow(b)
+	c.api.AssertIsEqual(a2.Value, b2.Value)
+}
+
+// AssertIsEqualFMsg is AssertIsEqualF, except that solving a witness where a and b differ fails
+// with msg and both values instead of the generic error of an unsatisfied constraint. The message
+// is passed to a hint as constants, so it is part of the compiled circuit.
+func (c *Chip[P]) AssertIsEqualFMsg(a, b Variable, msg string) {
+	a2 := c.ReduceSlow(a)
+	b2 := c.ReduceSlow(b)
+	inputs := append([]frontend.Variable{a2.Value, b2.Value}, encodeMsg(msg)...)
+	if _, err := c.api.Compiler().NewHint(AssertEqualHint, 1, inputs...); err != nil {
+		panic(err)
+	}
+	c.api.AssertIsEqual(a2.Value, b2.Value)
+}
+
+func (c *Chip[P]) AssertNotEqualF(a, b Variable) {
+	a2 := c.ReduceSlow(a)
+	b2 := c.ReduceSlow(b)
+	c.api.AssertIsDifferent(a2.Value, b2.Value)
+}
+
+func (c *Chip[P]) AssertIsEqualE(a, b ExtensionVariable) {
+	c.AssertIsEqualF(a.Value[0], b.Value[0])
+	c.AssertIsEqualF(a.Value[1], b.Value[1])
+	c.AssertIsEqualF(a.Value[2], b.Value[2])
+	c.AssertIsEqualF(a.Value[3], b.Value[3])
+}
+
+// AssertIsEqualFIf asserts a == b only when cond is 1.
+func (c *Chip[P]) AssertIsEqualFIf(cond Bool, a, b Variable) {
+	a2 := c.ReduceSlow(a)
+	b2 := c.ReduceSlow(b)
+	c.api.AssertIsEqual(c.api.Mul(cond.value, c.api.Sub(a2.Value, b2.Value)), 0)
+}
+
+func (c *Chip[P]) AssertIsEqualEIf(cond Bool, a, b ExtensionVariable) {
+	c.AssertIsEqualFIf(cond, a.Value[0], b.Value[0])
+	c.AssertIsEqualFIf(cond, a.Value[1], b.Value[1])
+	c.AssertIsEqualFIf(cond, a.Value[2], b.Value[2])
+	c.AssertIsEqualFIf(cond, a.Value[3], b.Value[3])
+}
+
+// AssertOptionalE handles a proof section that may be absent, like a chip missing from a shard.
+// If present is 1 the claimed contribution must equal the one computed by verifying the section,
+// and otherwise it must be the identity (e.g. zero for a cumulative sum). Constraints checked
+// inside the section itself should use AssertIsEqualFIf and AssertIsEqualEIf with the same flag.
+func (c *Chip[P]) AssertOptionalE(present Bool, claimed, verified, identity ExtensionVariable) {
+	c.AssertIsEqualE(claimed, c.SelectE(present, verified, identity))
+}
+
+// SelectF returns a if cond is 1 and b otherwise, bounded by the larger of their bounds.
+func (c *Chip[P]) SelectF(cond Bool, a, b Variable) Variable {
+	var UpperBound *big.Int
+	if a.UpperBound.Cmp(b.UpperBound) == -1 {
+		UpperBound = b.UpperBound
+	} else {
+		UpperBound = a.UpperBound
+	}
+	return Variable{
+		Value:      c.api.Select(cond.value, a.Value, b.Value),
+		UpperBound: UpperBound,
+	}
+}
+
+// SelectSliceF selects between two slices of the same length element-wise, e.g. to order the
+// children of a Merkle node by the direction bit of the path.
+func (c *Chip[P]) SelectSliceF(cond Bool, a, b []Variable) []Variable {
+	if len(a) != len(b) {
+		panic("SelectSliceF: slices of different lengths")
+	}
+	result := make([]Variable, len(a))
+	for i := range a {
+		result[i] = c.SelectF(cond, a[i], b[i])
+	}
+	return result
+}
+
+func (c *Chip[P]) SelectE(cond Bool, a, b ExtensionVariable) ExtensionVariable {
+	return ExtensionVariable{
+		Value: [4]Variable{
+			c.SelectF(cond, a.Value[0], b.Value[0]),
+			c.SelectF(cond, a.Value[1], b.Value[1]),
+			c.SelectF(cond, a.Value[2], b.Value[2]),
+			c.SelectF(cond, a.Value[3], b.Value[3]),
+		},
+	}
+}
+
+func (c *Chip[P]) AddEF(a ExtensionVariable, b Variable) ExtensionVariable {
+	v1 := c.AddF(a.Value[0], b)
+	return ExtensionVariable{Value: [4]Variable{v1, a.Value[1], a.Value[2], a.Value[3]}}
+}
+
+func (c *Chip[P]) AddE(a, b ExtensionVariable) ExtensionVariable {
+	v1 := c.AddF(a.Value[0], b.Value[0])
+	v2 := c.AddF(a.Value[1], b.Value[1])
+	v3 := c.AddF(a.Value[2], b.Value[2])
+	v4 := c.AddF(a.Value[3], b.Value[3])
+	return ExtensionVariable{Value: [4]Variable{v1, v2, v3, v4}}
+}
+
+func (c *Chip[P]) SubE(a, b ExtensionVariable) ExtensionVariable {
+	v1 := c.SubF(a.Value[0], b.Value[0])
+	v2 := c.SubF(a.Value[1], b.Value[1])
+	v3 := c.SubF(a.Value[2], b.Value[2])
+	v4 := c.SubF(a.Value[3], b.Value[3])
+	return ExtensionVariable{Value: [4]Variable{v1, v2, v3, v4}}
+}
+
+func (c *Chip[P]) SubEF(a ExtensionVariable, b Variable) ExtensionVariable {
+	v1 := c.SubF(a.Value[0], b)
+	return ExtensionVariable{Value: [4]Variable{v1, a.Value[1], a.Value[2], a.Value[3]}}
+}
+
+func (c *Chip[P]) MulE(a, b ExtensionVariable) ExtensionVariable {
+	c.assertHasExt("MulE")
+	v2 := [4]Variable{
+		Zero(),
+		Zero(),
+		Zero(),
+		Zero(),
+	}
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if i+j >= 4 {
+				v2[i+j-4] = c.AddF(v2[i+j-4], c.MulFConst(c.MulF(a.Value[i], b.Value[j], false), c.extW, false), false)
+			} else {
+				v2[i+j] = c.AddF(v2[i+j], c.MulF(a.Value[i], b.Value[j], false), false)
+			}
+		}
+	}
+	v2[0] = c.reduceResult(v2[0])
+	v2[1] = c.reduceResult(v2[1])
+	v2[2] = c.reduceResult(v2[2])
+	v2[3] = c.reduceResult(v2[3])
+	return ExtensionVariable{Value: v2}
+}
+
+// Powers returns alpha^0, ..., alpha^(n-1). Each power is a single multiplication by alpha, so
+// the coefficients are only reduced once their bound grows past the reduction threshold. The
+// powers are shared across callers: asking again for the same alpha extends the previously
+// computed sequence instead of starting over.
+func (c *Chip[P]) Powers(alpha ExtensionVariable, n int) []ExtensionVariable {
+	one := ExtensionVariable{Value: [4]Variable{One(), Zero(), Zero(), Zero()}}
+	return c.extSequence(c.powersCache, alpha, n, one, func(power ExtensionVariable) ExtensionVariable {
+		return c.MulE(power, alpha)
+	})
+}
+
+// ExpE returns alpha^n for a constant exponent, e.g. to lift a FRI folding challenge to the
+// arity of a layer. The squarings alpha^(2^i) are shared across calls for the same alpha like
+// Powers, and cost 10 products instead of the 16 of MulE.
+func (c *Chip[P]) ExpE(alpha ExtensionVariable, n uint64) ExtensionVariable {
+	c.assertHasExt("ExpE")
+	squares := c.extSequence(c.squaresCache, alpha, bits.Len64(n), alpha, c.squareE)
+	result := ExtensionVariable{Value: [4]Variable{One(), Zero(), Zero(), Zero()}}
+	first := true
+	for i, square := range squares {
+		if n>>uint(i)&1 == 0 {
+			continue
+		}
+		if first {
+			result, first = square, false
+		} else {
+			result = c.MulE(result, square)
+		}
+	}
+	return result
+}
+
+// extSequence returns the first n terms of the sequence starting at first and continued by next,
+// extending the one cached for alpha if its bounds match.
+func (c *Chip[P]) extSequence(
+	cache map[[4]frontend.Variable]powersEntry,
+	alpha ExtensionVariable,
+	n int,
+	first ExtensionVariable,
+	next func(ExtensionVariable) ExtensionVariable,
+) []ExtensionVariable {
+	var key [4]frontend.Variable
+	var bounds [4]*big.Int
+	cacheable := true
+	for i := 0; i < 4; i++ {
+		key[i] = alpha.Value[i].Value
+		bounds[i] = alpha.Value[i].UpperBound
+		cacheable = cacheable && key[i] != nil && reflect.TypeOf(key[i]).Comparable()
+	}
+
+	var sequence []ExtensionVariable
+	if cacheable {
+		if entry, ok := cache[key]; ok {
+			sameBounds := true
+			for i := 0; i < 4; i++ {
+				sameBounds = sameBounds && entry.inputBounds[i].Cmp(bounds[i]) == 0
+			}
+			if sameBounds {
+				sequence = entry.powers
+			}
+		}
+	}
+	if len(sequence) == 0 {
+		sequence = []ExtensionVariable{first}
+	}
+	for len(sequence) < n {
+		sequence = append(sequence, next(sequence[len(sequence)-1]))
+	}
+	if cacheable {
+		cache[key] = powersEntry{inputBounds: bounds, powers: sequence}
+	}
+
+	return sequence[:n:n]
+}
+
+// squareE returns a^2, computing each cross product a_i a_j once and doubling it.
+func (c *Chip[P]) squareE(a ExtensionVariable) ExtensionVariable {
+	v2 := [4]Variable{Zero(), Zero(), Zero(), Zero()}
+	for i := 0; i < 4; i++ {
+		for j := i; j < 4; j++ {
+			factor := 1
+			if i != j {
+				factor = 2
+			}
+			if i+j >= 4 {
+				factor *= c.extW
+			}
+			product := c.MulF(a.Value[i], a.Value[j], false)
+			if factor != 1 {
+				product = c.MulFConst(product, factor, false)
+			}
+			v2[(i+j)%4] = c.AddF(v2[(i+j)%4], product, false)
+		}
+	}
+	for i := range v2 {
+		v2[i] = c.reduceResult(v2[i])
+	}
+	return ExtensionVariable{Value: v2}
+}
+
+func (c *Chip[P]) MulEF(a ExtensionVariable, b Variable) ExtensionVariable {
+	v1 := c.MulF(a.Value[0], b)
+	v2 := c.MulF(a.Value[1], b)
+	v3 := c.MulF(a.Value[2], b)
+	v4 := c.MulF(a.Value[3], b)
+	return ExtensionVariable{Value: [4]Variable{v1, v2, v3, v4}}
+}
+
+// ScalarMulE multiplies a by the non-negative constant b, which costs no multiplication
+// constraint unlike MulEF.
+func (c *Chip[P]) ScalarMulE(a ExtensionVariable, b int) ExtensionVariable {
+	v1 := c.MulFConst(a.Value[0], b)
+	v2 := c.MulFConst(a.Value[1], b)
+	v3 := c.MulFConst(a.Value[2], b)
+	v4 := c.MulFConst(a.Value[3], b)
+	return ExtensionVariable{Value: [4]Variable{v1, v2, v3, v4}}
+}
+
+// InvE inverts an extension element through the norm map. Writing a = A + B X with A, B in
+// F_p[X^2], the conjugate a' = A - B X satisfies a * a' = A^2 - X^2 B^2 = c0 + c1 X^2, and
+// N(a) = c0^2 - W c1^2 lies in the base field. The inverse is a' * (c0 - c1 X^2) * N(a)^-1, which
+// costs a single base field inverse hint instead of a four limb hint and a full product check.
+func (c *Chip[P]) InvE(in ExtensionVariable) ExtensionVariable {
+	// Keep the degree two terms of c0 and c1 well below the native modulus.
+	a := in
+	for i := 0; i < 4; i++ {
+		if a.Value[i].UpperBound.BitLen() > 2*c.nbBits {
+			a.Value[i] = c.ReduceSlow(a.Value[i])
+		}
+	}
+	c.assertHasExt("InvE")
+	w := c.extW
+	modulusSubW := int(c.modulus.Int64()) - w
+	modulusSub2W := int(c.modulus.Int64()) - 2*w
+	modulusSub1 := int(c.modulusSub1.Int64())
+
+	// c0 = a0^2 + W a2^2 - 2 W a1 a3, c1 = 2 a0 a2 - a1^2 - W a3^2, with negations folded into
+	// the constants.
+	c0 := c.AddF(
+		c.AddF(c.MulF(a.Value[0], a.Value[0], false), c.MulFConst(c.MulF(a.Value[2], a.Value[2], false), w, false), false),
+		c.MulFConst(c.MulF(a.Value[1], a.Value[3], false), modulusSub2W, false),
+	)
+	c1 := c.AddF(
+		c.AddF(c.MulFConst(c.MulF(a.Value[0], a.Value[2], false), 2, false), c.MulFConst(c.MulF(a.Value[1], a.Value[1], false), modulusSub1, false), false),
+		c.MulFConst(c.MulF(a.Value[3], a.Value[3], false), modulusSubW, false),
+	)
+	c0 = c.ReduceSlow(c0)
+	c1 = c.ReduceSlow(c1)
+
+	norm := c.AddF(c.MulF(c0, c0, false), c.MulFConst(c.MulF(c1, c1, false), modulusSubW, false))
+	normInv := c.InvF(norm)
+
+	// d = (c0 - c1 X^2) * N(a)^-1, kept as its two non-zero coefficients.
+	d0 := c.ReduceSlow(c.MulF(c0, normInv))
+	d2 := c.ReduceSlow(c.MulF(c.MulFConst(c1, modulusSub1, false), normInv))
+
+	// out = (a0 - a1 X + a2 X^2 - a3 X^3) * (d0 + d2 X^2).
+	out0 := c.AddF(c.MulF(a.Value[0], d0, false), c.MulFConst(c.MulF(a.Value[2], d2, false), w, false))
+	out1 := c.AddF(c.MulFConst(c.MulF(a.Value[1], d0, false), modulusSub1, false), c.MulFConst(c.MulF(a.Value[3], d2, false), modulusSubW, false))
+	out2 := c.AddF(c.MulF(a.Value[2], d0, false), c.MulF(a.Value[0], d2, false))
+	out3 := c.AddF(c.MulFConst(c.MulF(a.Value[3], d0, false), modulusSub1, false), c.MulFConst(c.MulF(a.Value[1], d2, false), modulusSub1, false))
+
+	return ExtensionVariable{Value: [4]Variable{out0, out1, out2, out3}}
+}
+
+func (c *Chip[P]) Ext2Felt(in ExtensionVariable) [4]Variable {
+	return in.Value
+}
+
+func (c *Chip[P]) DivE(a, b ExtensionVariable) ExtensionVariable {
+	bInv := c.InvE(b)
+	return c.MulE(a, bInv)
+}
+
+func (c *Chip[P]) DivEF(a ExtensionVariable, b Variable) ExtensionVariable {
+	bInv := c.InvF(b)
+	return c.MulEF(a, bInv)
+}
+
+func (c *Chip[P]) NegE(a ExtensionVariable) ExtensionVariable {
+	v1 := c.NegF(a.Value[0])
+	v2 := c.NegF(a.Value[1])
+	v3 := c.NegF(a.Value[2])
+	v4 := c.NegF(a.Value[3])
+	return ExtensionVariable{Value: [4]Variable{v1, v2, v3, v4}}
+}
+
+func (c *Chip[P]) ToBinary(in Variable) []frontend.Variable {
+	return c.ToBinaryF(in, c.nbBits)
+}
+
+// ToBinaryF returns the n little-endian bits of the canonical representative of v. With fewer bits
+// than the modulus, the circuit is unsatisfiable if the representative does not fit in n bits.
+func (c *Chip[P]) ToBinaryF(v Variable, n int) []frontend.Variable {
+	return c.api.ToBinary(c.ReduceSlow(v).Value, n)
+}
+
+// FromBinaryF returns the canonical element whose little-endian bits are bits, modulo p. Every bit
+// is constrained to be boolean.
+func (c *Chip[P]) FromBinaryF(bits []frontend.Variable) Variable {
+	if len(bits) > c.maxBoundBits {
+		panic(fmt.Sprintf("FromBinaryF of %d bits overflows the native field", len(bits)))
+	}
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(len(bits)))
+	return c.ReduceSlow(Variable{
+		Value:      c.api.FromBinary(bits...),
+		UpperBound: bound.Sub(bound, big.NewInt(1)),
+	})
+}
+
+// checkBound returns x, after adding a hint checking its value against its upper bound in debug
+// mode.
+func (c *Chip[P]) checkBound(op string, x Variable) Variable {
+	if !c.Debug {
+		return x
+	}
+	msg := fmt.Sprintf("%s called from %s", op, callSite())
+	inputs := append([]frontend.Variable{x.Value, x.UpperBound}, encodeMsg(msg)...)
+	if _, err := c.api.Compiler().NewHint(DebugBoundHint, 1, inputs...); err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// callSite returns the file and line of the innermost caller outside of this package.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, fieldPackage+".") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func (c *Chip[P]) assertHasExt(op string) {
+	if c.extW == 0 {
+		panic(fmt.Sprintf("%s: the field has no degree 4 binomial extension", op))
+	}
+}
+
+func addBounds(a, b *big.Int) *big.Int { return new(big.Int).Add(a, b) }
+func mulBounds(a, b *big.Int) *big.Int { return new(big.Int).Mul(a, b) }
+
+// fits reports whether a result with the given bound can still be reduced. Otherwise, it panics
+// in strict mode, naming the operation.
+func (c *Chip[P]) fits(op string, bound *big.Int) bool {
+	if bound.BitLen() <= c.maxBoundBits {
+		return true
+	}
+	if c.Strict {
+		panic(fmt.Sprintf("%s overflows the native field: the bound of its result has %d bits, more than %d", op, bound.BitLen(), c.maxBoundBits))
+	}
+	return false
+}
+
+// fitOperands reduces the operands of op until the bound of its result fits, starting with the
+// operand with the largest bound. Operations on unreduced values therefore only pay for a
+// reduction when the next one would overflow, and callers need not track bit lengths themselves.
+func (c *Chip[P]) fitOperands(op string, a, b Variable, bound func(a, b *big.Int) *big.Int) (Variable, Variable) {
+	if c.fits(op, bound(a.UpperBound, b.UpperBound)) {
+		return a, b
+	}
+	if a.UpperBound.Cmp(b.UpperBound) >= 0 {
+		a = c.reduceOperand(a)
+	} else {
+		b = c.reduceOperand(b)
+	}
+	if bound(a.UpperBound, b.UpperBound).BitLen() > c.maxBoundBits {
+		a, b = c.reduceOperand(a), c.reduceOperand(b)
+	}
+	return a, b
+}
+
+func (p *Chip[P]) reduceLarge(x Variable) Variable {
+	if x.UpperBound.BitLen() >= 120 {
+		return Variable{
+			Value:      p.reduceWithMaxBits(x.Value, uint64(x.UpperBound.BitLen())),
+			UpperBound: p.modulusSub1,
+		}
+	}
+	return x
+}
+
+func (p *Chip[P]) ReduceSlow(x Variable) Variable {
+	if x.UpperBound.Cmp(p.modulus) == -1 {
+		return x
+	}
+	if folded, ok := p.foldF(x); ok {
+		return folded
+	}
+	return p.checkBound("ReduceSlow", Variable{
+		Value:      p.reduceWithMaxBits(x.Value, uint64(x.UpperBound.BitLen())),
+		UpperBound: p.modulusSub1,
+	})
+}
+
+// ReduceFast reduces x to a representative of at most NbBits bits, which unlike the result of
+// ReduceSlow need not be canonical: the remainder is range checked instead of decomposed into
+// limbs. It suits intermediate values that only need a small bound.
+func (c *Chip[P]) ReduceFast(x Variable) Variable {
+	if x.UpperBound.BitLen() <= c.nbBits {
+		return x
+	}
+	if folded, ok := c.foldF(x); ok {
+		return folded
+	}
+	defer c.Scope("field.reduce")()
+	result, err := c.api.Compiler().NewHint(ReduceHint, 2, c.modulus, x.Value)
+	if err != nil {
+		panic(err)
+	}
+	quotient, remainder := result[0], result[1]
+	c.rangeCheck(quotient, x.UpperBound.BitLen()-(c.nbBits-1))
+	c.rangeCheck(remainder, c.nbBits)
+	c.api.AssertIsEqual(x.Value, c.api.Add(c.api.Mul(quotient, c.modulus), remainder))
+
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(c.nbBits))
+	return c.checkBound("ReduceFast", Variable{Value: remainder, UpperBound: bound.Sub(bound, big.NewInt(1))})
+}
+
+func (p *Chip[P]) reduceWithMaxBits(x frontend.Variable, maxNbBits uint64) frontend.Variable {
+	// Every value with at most NbBits - 1 bits is already smaller than the modulus.
+	if maxNbBits <= uint64(p.nbBits-1) {
+		return x
+	}
+	defer p.Scope("field.reduce")()
+	result, err := p.api.Compiler().NewHint(ReduceHint, 2, p.modulus, x)
+	if err != nil {
+		panic(err)
+	}
+
+	quotient := result[0]
+	remainder := result[1]
+
+	p.rangeCheck(quotient, int(maxNbBits)-(p.nbBits-1))
+
+	p.assertCanonical(remainder)
+
+	p.api.AssertIsEqual(x, p.api.Add(p.api.Mul(quotient, p.modulus), remainder))
+
+	return remainder
+}
+
+// ReduceBatch is ReduceSlow on independent values, e.g. the coefficients of an extension element
+// or the state of a permutation, with the quotients, remainders and limbs of all the reductions
+// computed by a single hint call rather than two per value. The constraints are the same.
+func (p *Chip[P]) ReduceBatch(xs []Variable) []Variable {
+	result := make([]Variable, len(xs))
+	inputs := []frontend.Variable{p.modulus}
+	var reduced []int
+	for i, x := range xs {
+		// Like ReduceSlow, skip the values already known to be canonical.
+		result[i] = x
+		if x.UpperBound.Cmp(p.modulus) == -1 {
+			continue
+		}
+		inputs = append(inputs, x.Value)
+		reduced = append(reduced, i)
+	}
+	if len(reduced) == 0 {
+		return result
+	}
+	defer p.Scope("field.reduce")()
+
+	outputs, err := p.api.Compiler().NewHint(ReduceBatchHint, 4*len(reduced), inputs...)
+	if err != nil {
+		panic(err)
+	}
+	for j, i := range reduced {
+		x := xs[i]
+		quotient, remainder := outputs[4*j], outputs[4*j+1]
+		p.rangeCheck(quotient, x.UpperBound.BitLen()-(p.nbBits-1))
+		p.assertLimbs(remainder, outputs[4*j+2], outputs[4*j+3])
+		p.api.AssertIsEqual(x.Value, p.api.Add(p.api.Mul(quotient, p.modulus), remainder))
+		result[i] = p.checkBound("ReduceBatch", Variable{Value: remainder, UpperBound: p.modulusSub1})
+	}
+	return result
+}
+
+// AssertCanonical constrains x to be the canonical representative of its residue, i.e. x < p,
+// without computing a new representative like ReduceSlow. It is free when the upper bound of x
+// already proves it, and otherwise costs a decomposition into two limbs, which fails for values of
+// more than NbBits bits even if they are valid representatives. The bound is trusted, so it must
+// come from the chip or from a range check: hint outputs are checked with assertCanonical instead.
+func (c *Chip[P]) AssertCanonical(x Variable) {
+	if x.UpperBound.Cmp(c.modulus) == -1 {
+		return
+	}
+	c.assertCanonical(x.Value)
+}
+
+func (p *Chip[P]) assertCanonical(x frontend.Variable) {
+	// Check that x is less than the modulus, by decomposing it into a low limb of
+	// lowLimbBits bits and a high limb of highLimbBits bits.
+	new_result, new_err := p.api.Compiler().NewHint(SplitLimbsHint, 2, p.modulus, x)
+	if new_err != nil {
+		panic(new_err)
+	}
+	p.assertLimbs(x, new_result[0], new_result[1])
+}
+
+// assertLimbs checks that lowLimb and highLimb, given by a hint, decompose x into limbs proving
+// that it is less than the modulus.
+func (p *Chip[P]) assertLimbs(x, lowLimb, highLimb frontend.Variable) {
+	// Check that the hint is correct.
+	p.api.AssertIsEqual(
+		p.api.Add(
+			p.api.Mul(highLimb, new(big.Int).Lsh(big.NewInt(1), uint(p.lowLimbBits))),
+			lowLimb,
+		),
+		x,
+	)
+	p.rangeCheck(highLimb, p.highLimbBits)
+	p.rangeCheck(lowLimb, p.lowLimbBits)
+
+	// If the most significant bits are all 1, then we need to check that the least significant bits
+	// are all zero in order for element to be less than the modulus. Otherwise, we don't need to do
+	// any checks, since we already know that the element is less than the modulus.
+	shouldCheck := p.api.IsZero(p.api.Sub(highLimb, new(big.Int).Rsh(p.modulusSub1, uint(p.lowLimbBits))))
+	p.api.AssertIsEqual(
+		p.api.Mul(
+			shouldCheck,
+			lowLimb,
+		),
+		frontend.Variable(0),
+	)
+}
+
+// rangeCheck constrains x to nbBits bits with the range checker of the chip.
+func (p *Chip[P]) rangeCheck(x frontend.Variable, nbBits int) {
+	defer p.Scope("field.range_check")()
+	p.RangeChecker.Check(x, nbBits)
+}
+
+func newRangeChecker(api frontend.API) frontend.Rangechecker {
+	if os.Getenv("GROTH16") == "1" {
+		return NewBitsRangeChecker(api)
+	}
+	return rangecheck.New(api)
+}
+
+// NewBitsRangeChecker returns a range checker decomposing each value into bits, which needs no
+// commitment.
+func NewBitsRangeChecker(api frontend.API) frontend.Rangechecker {
+	return bitsRangeChecker{api: api}
+}
+
+type bitsRangeChecker struct {
+	api frontend.API
+}
+
+func (c bitsRangeChecker) Check(x frontend.Variable, nbBits int) {
+	c.api.ToBinary(x, nbBits)
+}
+
+func (p *Chip[P]) ReduceE(x ExtensionVariable) ExtensionVariable {
+	copy(x.Value[:], p.ReduceBatch(x.Value[:]))
+	return x
+}
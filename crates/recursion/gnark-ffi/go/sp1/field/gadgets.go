@@ -0,0 +1,233 @@
+package field
+
+import "encoding/json"
+
+//go:generate go run ./gen -out gadgets.json
+
+// The kinds of values taken and returned by the gadgets of the chip.
+const (
+	// KindBounded is a Variable whose UpperBound bounds its value, which may be any representative
+	// of its residue, i.e. not reduced. Every Variable returned by the chip is bounded.
+	KindBounded = "bounded"
+	// KindCanonical is a Variable smaller than the modulus.
+	KindCanonical = "canonical"
+	// KindExt and KindExtCanonical are extension elements with bounded or canonical coefficients.
+	KindExt          = "ext"
+	KindExtCanonical = "ext_canonical"
+	// KindBool is a Bool, constrained to be 0 or 1.
+	KindBool = "bool"
+	// KindNative is a native BN254 variable.
+	KindNative = "native"
+	// KindBytes are native variables holding big-endian bytes.
+	KindBytes = "bytes"
+	// KindConst is a Go integer known at compile time.
+	KindConst = "const"
+	// KindReduceFlag is the optional forceReduce argument: passing false skips the reduction of the
+	// result, which is then only reduced when an operation using it would overflow the native
+	// field, or rejected at compile time if the chip is strict.
+	KindReduceFlag = "reduce_flag"
+)
+
+// GadgetSpec describes the inputs and outputs of a gadget of the chip, in order, and what it
+// assumes of its inputs beyond their kinds.
+type GadgetSpec struct {
+	Inputs      []string `json:"inputs"`
+	Outputs     []string `json:"outputs"`
+	Assumptions []string `json:"assumptions,omitempty"`
+}
+
+// Gadgets annotates every exported method of Chip. The annotations are checked against the method
+// signatures by the tests, and exported to gadgets.json for circuit authors by go generate.
+var Gadgets = map[string]GadgetSpec{
+	"NewBool": {Inputs: []string{KindNative}, Outputs: []string{KindBool}},
+	"And":     {Inputs: []string{KindBool, KindBool}, Outputs: []string{KindBool}},
+	"Or":      {Inputs: []string{KindBool, KindBool}, Outputs: []string{KindBool}},
+	"Xor":     {Inputs: []string{KindBool, KindBool}, Outputs: []string{KindBool}},
+	"Not":     {Inputs: []string{KindBool}, Outputs: []string{KindBool}},
+	"SelectV": {Inputs: []string{KindBool, KindNative, KindNative}, Outputs: []string{KindNative}},
+	"Bits": {
+		Inputs:      []string{KindBounded},
+		Outputs:     []string{"[]" + KindBool},
+		Assumptions: []string{"bits are little-endian, of the canonical representative"},
+	},
+	"IsEqualF": {Inputs: []string{KindBounded, KindBounded}, Outputs: []string{KindBool}},
+	"IsZeroF":  {Inputs: []string{KindBounded}, Outputs: []string{KindBool}},
+
+	"ToBytes": {Inputs: []string{KindBounded}, Outputs: []string{KindBytes}},
+	"FromBytes": {
+		Inputs:      []string{KindBytes},
+		Outputs:     []string{KindCanonical},
+		Assumptions: []string{"at most ceil(NbBits / 8) bytes; fails if they do not encode a canonical element"},
+	},
+
+	"AddF": {Inputs: []string{KindBounded, KindBounded, KindReduceFlag}, Outputs: []string{KindBounded}},
+	"SubF": {Inputs: []string{KindBounded, KindBounded}, Outputs: []string{KindBounded}},
+	"NegF": {Inputs: []string{KindBounded}, Outputs: []string{KindBounded}},
+	"AddFConst": {
+		Inputs:      []string{KindBounded, KindConst, KindReduceFlag},
+		Outputs:     []string{KindBounded},
+		Assumptions: []string{"the constant is non-negative"},
+	},
+	"SubFConst": {
+		Inputs:      []string{KindBounded, KindConst, KindReduceFlag},
+		Outputs:     []string{KindBounded},
+		Assumptions: []string{"the constant is non-negative"},
+	},
+	"MulF": {Inputs: []string{KindBounded, KindBounded, KindReduceFlag}, Outputs: []string{KindBounded}},
+	"MulFConst": {
+		Inputs:      []string{KindBounded, KindConst, KindReduceFlag},
+		Outputs:     []string{KindBounded},
+		Assumptions: []string{"the constant is non-negative"},
+	},
+	"SqrtF": {
+		Inputs:      []string{KindBounded},
+		Outputs:     []string{KindCanonical, KindBool},
+		Assumptions: []string{"if x is not a square, the root is a root of x times a fixed non-residue"},
+	},
+	"InvF": {
+		Inputs:      []string{KindBounded},
+		Outputs:     []string{KindBounded},
+		Assumptions: []string{"the input is non-zero, otherwise the circuit is unsatisfiable"},
+	},
+	"ExpF": {Inputs: []string{KindBounded, KindConst}, Outputs: []string{KindBounded}},
+	"BatchInvF": {
+		Inputs:      []string{"[]" + KindBounded},
+		Outputs:     []string{"[]" + KindBounded},
+		Assumptions: []string{"the inputs are non-zero, otherwise the circuit is unsatisfiable"},
+	},
+	"DivF": {
+		Inputs:      []string{KindBounded, KindBounded},
+		Outputs:     []string{KindBounded},
+		Assumptions: []string{"the divisor is non-zero, otherwise the circuit is unsatisfiable"},
+	},
+	"AssertIsEqualF": {Inputs: []string{KindBounded, KindBounded}, Outputs: []string{}},
+	"AssertIsEqualFMsg": {
+		Inputs:      []string{KindBounded, KindBounded, KindConst},
+		Outputs:     []string{},
+		Assumptions: []string{"the message is a string known at compile time"},
+	},
+	"AssertNotEqualF":  {Inputs: []string{KindBounded, KindBounded}, Outputs: []string{}},
+	"AssertIsEqualE":   {Inputs: []string{KindExt, KindExt}, Outputs: []string{}},
+	"AssertIsEqualFIf": {Inputs: []string{KindBool, KindBounded, KindBounded}, Outputs: []string{}},
+	"AssertIsEqualEIf": {Inputs: []string{KindBool, KindExt, KindExt}, Outputs: []string{}},
+	"AssertOptionalE":  {Inputs: []string{KindBool, KindExt, KindExt, KindExt}, Outputs: []string{}},
+	"SelectF":          {Inputs: []string{KindBool, KindBounded, KindBounded}, Outputs: []string{KindBounded}},
+	"SelectSliceF": {
+		Inputs:      []string{KindBool, "[]" + KindBounded, "[]" + KindBounded},
+		Outputs:     []string{"[]" + KindBounded},
+		Assumptions: []string{"the slices have the same length"},
+	},
+	"SelectE": {Inputs: []string{KindBool, KindExt, KindExt}, Outputs: []string{KindExt}},
+
+	"AddEF": {Inputs: []string{KindExt, KindBounded}, Outputs: []string{KindExt}},
+	"AddE":  {Inputs: []string{KindExt, KindExt}, Outputs: []string{KindExt}},
+	"SubE":  {Inputs: []string{KindExt, KindExt}, Outputs: []string{KindExt}},
+	"SubEF": {Inputs: []string{KindExt, KindBounded}, Outputs: []string{KindExt}},
+	"MulE":  {Inputs: []string{KindExt, KindExt}, Outputs: []string{KindExt}},
+	"MulEF": {Inputs: []string{KindExt, KindBounded}, Outputs: []string{KindExt}},
+	"ScalarMulE": {
+		Inputs:      []string{KindExt, KindConst},
+		Outputs:     []string{KindExt},
+		Assumptions: []string{"the constant is non-negative"},
+	},
+	"ExpE": {
+		Inputs:      []string{KindExt, KindConst},
+		Outputs:     []string{KindExt},
+		Assumptions: []string{"the squarings are shared with later calls for the same alpha"},
+	},
+	"Powers": {
+		Inputs:      []string{KindExt, KindConst},
+		Outputs:     []string{"[]" + KindExt},
+		Assumptions: []string{"the returned slice is shared with later calls for the same alpha and must not be modified"},
+	},
+	"InvE": {
+		Inputs:      []string{KindExt},
+		Outputs:     []string{KindExt},
+		Assumptions: []string{"the input is non-zero, otherwise the circuit is unsatisfiable"},
+	},
+	"DivE": {
+		Inputs:      []string{KindExt, KindExt},
+		Outputs:     []string{KindExt},
+		Assumptions: []string{"the divisor is non-zero, otherwise the circuit is unsatisfiable"},
+	},
+	"DivEF": {
+		Inputs:      []string{KindExt, KindBounded},
+		Outputs:     []string{KindExt},
+		Assumptions: []string{"the divisor is non-zero, otherwise the circuit is unsatisfiable"},
+	},
+	"NegE":     {Inputs: []string{KindExt}, Outputs: []string{KindExt}},
+	"Ext2Felt": {Inputs: []string{KindExt}, Outputs: []string{"[4]" + KindBounded}},
+
+	"ToBinary": {
+		Inputs:      []string{KindBounded},
+		Outputs:     []string{"[]" + KindNative},
+		Assumptions: []string{"bits are little-endian, of the canonical representative"},
+	},
+	"ToBinaryF": {
+		Inputs:      []string{KindBounded, KindConst},
+		Outputs:     []string{"[]" + KindNative},
+		Assumptions: []string{"bits are little-endian, of the canonical representative", "the representative fits in n bits"},
+	},
+	"FromBinaryF": {
+		Inputs:      []string{"[]" + KindNative},
+		Outputs:     []string{KindCanonical},
+		Assumptions: []string{"bits are little-endian and constrained to be boolean"},
+	},
+	"ReduceSlow": {Inputs: []string{KindBounded}, Outputs: []string{KindCanonical}},
+	"ReduceFast": {
+		Inputs:      []string{KindBounded},
+		Outputs:     []string{KindBounded},
+		Assumptions: []string{"the result has at most NbBits bits, but may not be canonical"},
+	},
+	"ReduceBatch": {Inputs: []string{"[]" + KindBounded}, Outputs: []string{"[]" + KindCanonical}},
+	"ReduceE":     {Inputs: []string{KindExt}, Outputs: []string{KindExtCanonical}},
+	"AssertCanonical": {
+		Inputs:  []string{KindBounded},
+		Outputs: []string{},
+		Assumptions: []string{
+			"fails for any value not smaller than the modulus, even if it is a valid representative",
+			"adds no constraint if the upper bound is smaller than the modulus: the bound must be enforced, not claimed for a hint output",
+		},
+	},
+
+	"Native":     {Inputs: []string{KindBounded}, Outputs: []string{KindNative}},
+	"NativeE":    {Inputs: []string{KindExt}, Outputs: []string{"[4]" + KindNative}},
+	"FromNative": {Inputs: []string{KindNative}, Outputs: []string{KindCanonical}},
+	"FromCanonical": {
+		Inputs:      []string{KindNative},
+		Outputs:     []string{KindCanonical},
+		Assumptions: []string{"the input is smaller than the modulus; this is not constrained"},
+	},
+	"FromCanonicalE": {
+		Inputs:      []string{"[4]" + KindNative},
+		Outputs:     []string{KindExtCanonical},
+		Assumptions: []string{"the inputs are smaller than the modulus; this is not constrained"},
+	},
+	"PackCapacity":      {Outputs: []string{KindConst}},
+	"Modulus":           {Outputs: []string{KindConst}},
+	"ReductionStrategy": {Outputs: []string{KindConst}},
+	"Scope": {
+		Inputs:      []string{KindConst},
+		Outputs:     []string{KindConst},
+		Assumptions: []string{"adds no constraint; the returned function closes the scope"},
+	},
+	"PackF": {
+		Inputs:      []string{"[]" + KindBounded},
+		Outputs:     []string{KindNative},
+		Assumptions: []string{"at most PackCapacity elements"},
+	},
+	"UnpackF": {
+		Inputs:      []string{KindNative, KindConst},
+		Outputs:     []string{"[]" + KindCanonical},
+		Assumptions: []string{"at most PackCapacity elements"},
+	},
+}
+
+// GadgetsJSON returns the content of gadgets.json.
+func GadgetsJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Gadgets, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,23 @@
+// Command gen writes the annotations of the gadgets of the field chip to a JSON file.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+func main() {
+	out := flag.String("out", "gadgets.json", "generated JSON file")
+	flag.Parse()
+
+	data, err := field.GadgetsJSON()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,168 @@
+package field
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// The hints below receive the field modulus as their first input, so that a single registered
+// hint serves every field the chip is instantiated with.
+
+// The hint used to compute Reduce.
+func ReduceHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) != 2 {
+		panic("reduceHint expects 2 input operands")
+	}
+	modulus := inputs[0]
+	input := inputs[1]
+	quotient := new(big.Int).Div(input, modulus)
+	remainder := new(big.Int).Rem(input, modulus)
+	results[0] = quotient
+	results[1] = remainder
+	return nil
+}
+
+func InvFHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	modulus := inputs[0]
+	a := new(big.Int).Mod(inputs[1], modulus)
+	ainv := new(big.Int).ModInverse(a, modulus)
+	if ainv == nil {
+		// Let the multiplication check in the circuit fail for zero.
+		ainv = new(big.Int)
+	}
+	results[0].Set(ainv)
+	return nil
+}
+
+// The hint used by ReduceBatch. For each input after the modulus, it returns the quotient and the
+// remainder of its division by the modulus, then the limbs of the remainder as SplitLimbsHint.
+func ReduceBatchHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	if len(results) != 4*(len(inputs)-1) {
+		panic("ReduceBatchHint expects 4 results per input operand")
+	}
+	modulus := inputs[0]
+	for i, input := range inputs[1:] {
+		if err := ReduceHint(nil, []*big.Int{modulus, input}, results[4*i:4*i+2]); err != nil {
+			return err
+		}
+		if err := SplitLimbsHint(nil, []*big.Int{modulus, results[4*i+1]}, results[4*i+2:4*i+4]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The hint used by UnpackF. Unlike the other hints, it receives the number of bits of the modulus
+// as its first input and returns the successive limbs of that many bits of the second one.
+func UnpackHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) != 2 {
+		panic("UnpackHint expects 2 input operands")
+	}
+	nbBits := uint(inputs[0].Uint64())
+	mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), nbBits), big.NewInt(1))
+	packed := new(big.Int).Set(inputs[1])
+	for i := range results {
+		results[i].And(packed, mask)
+		packed.Rsh(packed, nbBits)
+	}
+	return nil
+}
+
+// The hint used to split a field element into a high limb (the most significant bits) and a low
+// limb made of the trailing zero bits of modulus - 1.
+func SplitLimbsHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) != 2 {
+		panic("SplitLimbsHint expects 2 input operands")
+	}
+
+	modulus := inputs[0]
+
+	// The field element
+	input := inputs[1]
+
+	if input.Cmp(modulus) == 0 || input.Cmp(modulus) == 1 {
+		return fmt.Errorf("input is not in the field")
+	}
+
+	lowLimbBits := new(big.Int).Sub(modulus, big.NewInt(1)).TrailingZeroBits()
+	lowLimbModulus := new(big.Int).Lsh(big.NewInt(1), lowLimbBits)
+
+	// The least significant bits
+	results[0] = new(big.Int).Rem(input, lowLimbModulus)
+	// The most significant bits
+	results[1] = new(big.Int).Quo(input, lowLimbModulus)
+
+	return nil
+}
+
+// The hint used to compute SqrtF. It returns 1 and a square root of x if x is a square, and 0 and
+// a square root of x * nonResidue otherwise.
+func SqrtHintF(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) != 3 {
+		panic("SqrtHintF expects 3 input operands")
+	}
+	modulus := inputs[0]
+	nonResidue := inputs[1]
+	x := new(big.Int).Mod(inputs[2], modulus)
+
+	if root := new(big.Int).ModSqrt(x, modulus); root != nil {
+		results[0].SetUint64(1)
+		results[1].Set(root)
+		return nil
+	}
+	results[0].SetUint64(0)
+	results[1].ModSqrt(x.Mul(x, nonResidue).Mod(x, modulus), modulus)
+	return nil
+}
+
+// The number of bytes of the message of AssertIsEqualFMsg packed in each input of its hint, so
+// that every chunk fits in the native field.
+const assertMsgChunkSize = 31
+
+// The hint used by AssertIsEqualFMsg. Its inputs are the two values and the message encoded by
+// encodeMsg. It fails with the message if the values differ, and its result is unused.
+func AssertEqualHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) < 3 {
+		panic("AssertEqualHint expects at least 3 input operands")
+	}
+	if inputs[0].Cmp(inputs[1]) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: %s != %s", decodeMsg(inputs[2:]), inputs[0], inputs[1])
+}
+
+// The hint used by the debug mode of the chip. Its inputs are a value, its upper bound and the
+// message encoded by encodeMsg. It fails with the message if the value exceeds the bound, and its
+// result is unused.
+func DebugBoundHint(_ *big.Int, inputs []*big.Int, results []*big.Int) error {
+	if len(inputs) < 3 {
+		panic("DebugBoundHint expects at least 3 input operands")
+	}
+	if inputs[0].Cmp(inputs[1]) <= 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: %s exceeds its bound %s", decodeMsg(inputs[2:]), inputs[0], inputs[1])
+}
+
+// encodeMsg encodes a message as hint inputs: its length, then its bytes in big-endian chunks of
+// assertMsgChunkSize bytes, which fit in a native element.
+func encodeMsg(msg string) []frontend.Variable {
+	inputs := []frontend.Variable{len(msg)}
+	for i := 0; i < len(msg); i += assertMsgChunkSize {
+		chunk := []byte(msg[i:min(i+assertMsgChunkSize, len(msg))])
+		inputs = append(inputs, new(big.Int).SetBytes(chunk))
+	}
+	return inputs
+}
+
+func decodeMsg(inputs []*big.Int) string {
+	length := int(inputs[0].Int64())
+	msg := make([]byte, 0, length)
+	for i, chunk := range inputs[1:] {
+		size := min(assertMsgChunkSize, length-i*assertMsgChunkSize)
+		msg = append(msg, chunk.FillBytes(make([]byte, size))...)
+	}
+	return string(msg)
+}
@@ -0,0 +1,82 @@
+package field
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// The functions below let generic gnark gadgets (std/selector, std/math/cmp, std/lookup, ...) run
+// on field elements: inputs are handed over as their canonical representatives, which are native
+// variables smaller than the modulus, and outputs are wrapped back into the chip's types.
+
+// Native returns the canonical representative of x.
+func (c *Chip[P]) Native(x Variable) frontend.Variable {
+	return c.ReduceSlow(x).Value
+}
+
+// NativeE returns the canonical representatives of the coefficients of x.
+func (c *Chip[P]) NativeE(x ExtensionVariable) [4]frontend.Variable {
+	x = c.ReduceE(x)
+	return [4]frontend.Variable{x.Value[0].Value, x.Value[1].Value, x.Value[2].Value, x.Value[3].Value}
+}
+
+// FromNative wraps an arbitrary native variable, constraining it to be canonical.
+func (c *Chip[P]) FromNative(v frontend.Variable) Variable {
+	c.assertCanonical(v)
+	return Variable{Value: v, UpperBound: c.modulusSub1}
+}
+
+// FromCanonical wraps a native variable that is canonical by construction, e.g. the output of a
+// selector whose inputs all come from Native. It adds no constraints, so the caller is responsible
+// for the bound.
+func (c *Chip[P]) FromCanonical(v frontend.Variable) Variable {
+	return Variable{Value: v, UpperBound: c.modulusSub1}
+}
+
+// FromCanonicalE is FromCanonical for the coefficients of an extension element.
+func (c *Chip[P]) FromCanonicalE(v [4]frontend.Variable) ExtensionVariable {
+	return ExtensionVariable{Value: [4]Variable{
+		c.FromCanonical(v[0]), c.FromCanonical(v[1]), c.FromCanonical(v[2]), c.FromCanonical(v[3]),
+	}}
+}
+
+// PackCapacity returns the number of canonical elements PackF fits in a native variable, e.g. 8
+// BabyBear elements of 31 bits in a BN254 element.
+func (c *Chip[P]) PackCapacity() int {
+	return (c.api.Compiler().Field().BitLen() - 1) / c.nbBits
+}
+
+// PackF packs the canonical representatives of xs into a single native variable, xs[i] taking the
+// bits [i*NbBits, (i+1)*NbBits), so that absorbing them into a native hash costs one element
+// instead of len(xs). It adds no constraints beyond the reduction of xs.
+func (c *Chip[P]) PackF(xs []Variable) frontend.Variable {
+	if len(xs) > c.PackCapacity() {
+		panic(fmt.Sprintf("cannot pack %d elements, at most %d fit in a native variable", len(xs), c.PackCapacity()))
+	}
+	xs = c.ReduceBatch(xs)
+	packed := frontend.Variable(0)
+	for i := len(xs) - 1; i >= 0; i-- {
+		packed = c.api.Add(c.api.Mul(packed, new(big.Int).Lsh(big.NewInt(1), uint(c.nbBits))), xs[i].Value)
+	}
+	return packed
+}
+
+// UnpackF is the inverse of PackF: it returns the n canonical elements packed into v, constraining
+// each of them to be canonical so that the decomposition is unique.
+func (c *Chip[P]) UnpackF(v frontend.Variable, n int) []Variable {
+	if n > c.PackCapacity() {
+		panic(fmt.Sprintf("cannot unpack %d elements, at most %d fit in a native variable", n, c.PackCapacity()))
+	}
+	limbs, err := c.api.Compiler().NewHint(UnpackHint, n, c.nbBits, v)
+	if err != nil {
+		panic(err)
+	}
+	result := make([]Variable, n)
+	for i, limb := range limbs {
+		result[i] = c.FromNative(limb)
+	}
+	c.api.AssertIsEqual(c.PackF(result), v)
+	return result
+}
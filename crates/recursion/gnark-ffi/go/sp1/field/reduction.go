@@ -0,0 +1,94 @@
+package field
+
+import (
+	"fmt"
+	"os"
+)
+
+// ReductionStrategy chooses how the chip reduces the results of the arithmetic that callers leave
+// to it, i.e. unless they pass forceReduce = false. It changes the constraints, hence the vkey, so
+// it is picked when the circuit is built: NewChip reads it from SP1_REDUCTION_STRATEGY, and every
+// gadget built on the chip follows it.
+type ReductionStrategy int
+
+const (
+	// ReductionDeferred keeps results unreduced until their bound reaches 120 bits or the next
+	// operation would overflow the native field, and then reduces them to canonical values. It is
+	// the default.
+	ReductionDeferred ReductionStrategy = iota
+	// ReductionEager reduces every result to its canonical value. It costs the most constraints,
+	// and keeps every intermediate value below the modulus.
+	ReductionEager
+	// ReductionAggressiveUnsafe defers like ReductionDeferred but reduces with ReduceFast, whose
+	// results are only bounded by 2^NbBits, and makes the Poseidon2 chips use FastReduction. The
+	// gadgets have not been reviewed for soundness with non-canonical intermediate values: it is
+	// meant to measure the savings, not to build release circuits.
+	ReductionAggressiveUnsafe
+)
+
+const reductionStrategyEnv = "SP1_REDUCTION_STRATEGY"
+
+var reductionStrategyNames = map[ReductionStrategy]string{
+	ReductionDeferred:         "deferred",
+	ReductionEager:            "eager",
+	ReductionAggressiveUnsafe: "aggressive-unsafe",
+}
+
+func (s ReductionStrategy) String() string {
+	if name, ok := reductionStrategyNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("ReductionStrategy(%d)", int(s))
+}
+
+// ParseReductionStrategy parses "deferred", "eager" or "aggressive-unsafe". The empty string is
+// the default, ReductionDeferred.
+func ParseReductionStrategy(name string) (ReductionStrategy, error) {
+	if name == "" {
+		return ReductionDeferred, nil
+	}
+	for strategy, strategyName := range reductionStrategyNames {
+		if name == strategyName {
+			return strategy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown reduction strategy %q", name)
+}
+
+// ReductionStrategyFromEnv returns the strategy set by SP1_REDUCTION_STRATEGY, and panics if it is
+// not a valid one.
+func ReductionStrategyFromEnv() ReductionStrategy {
+	strategy, err := ParseReductionStrategy(os.Getenv(reductionStrategyEnv))
+	if err != nil {
+		panic(err)
+	}
+	return strategy
+}
+
+// ReductionStrategy returns the strategy of the chip, for the gadgets built on it.
+func (c *Chip[P]) ReductionStrategy() ReductionStrategy {
+	return c.Reduction
+}
+
+// reduceResult reduces the result of an operation according to the strategy of the chip.
+func (c *Chip[P]) reduceResult(x Variable) Variable {
+	switch c.Reduction {
+	case ReductionEager:
+		return c.ReduceSlow(x)
+	case ReductionAggressiveUnsafe:
+		if x.UpperBound.BitLen() >= 120 {
+			return c.ReduceFast(x)
+		}
+		return x
+	default:
+		return c.reduceLarge(x)
+	}
+}
+
+// reduceOperand reduces an operand whose bound would make the result of an operation overflow.
+func (c *Chip[P]) reduceOperand(x Variable) Variable {
+	if c.Reduction == ReductionAggressiveUnsafe {
+		return c.ReduceFast(x)
+	}
+	return c.ReduceSlow(x)
+}
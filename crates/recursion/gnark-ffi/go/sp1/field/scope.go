@@ -0,0 +1,179 @@
+package field
+
+import (
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/google/pprof/profile"
+)
+
+// ScopeProfile attributes the constraints of the circuits compiled while it is active to the named
+// scopes opened with Scope, like gnark/profile attributes them to the Go functions adding them.
+// Scopes nest, and the constraints added directly in a scope are counted under its path, so that
+// pprof shows both the flat and cumulative cost of each gadget. Constraints added outside of any
+// scope, or after Define such as the batched checks of the log-derivative range checker, are not
+// counted.
+type ScopeProfile struct {
+	mu          sync.Mutex
+	stacks      map[frontend.Compiler]*scopeStack
+	constraints map[string]int
+}
+
+type scopeStack struct {
+	countConstraints func() int
+	open             []openScope
+}
+
+type openScope struct {
+	name  string
+	start int
+	// The constraints added by the scopes nested in this one.
+	nested int
+}
+
+var activeScopeProfile atomic.Pointer[ScopeProfile]
+
+// StartScopeProfile starts recording the scopes of the circuits compiled until Stop is called. A
+// single profile can be active at a time.
+func StartScopeProfile() *ScopeProfile {
+	p := &ScopeProfile{
+		stacks:      make(map[frontend.Compiler]*scopeStack),
+		constraints: make(map[string]int),
+	}
+	if !activeScopeProfile.CompareAndSwap(nil, p) {
+		panic("a scope profile is already active")
+	}
+	return p
+}
+
+// Stop stops recording. Scopes still open are dropped.
+func (p *ScopeProfile) Stop() {
+	activeScopeProfile.CompareAndSwap(p, nil)
+}
+
+// Constraints returns the number of constraints added directly in each scope, keyed by the names
+// of the enclosing scopes and of the scope joined with "/".
+func (p *ScopeProfile) Constraints() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	constraints := make(map[string]int, len(p.constraints))
+	for path, n := range p.constraints {
+		constraints[path] = n
+	}
+	return constraints
+}
+
+// Write writes the profile in the gzipped protobuf format of pprof, the one gnark/profile writes,
+// with a function per scope name.
+func (p *ScopeProfile) Write(w io.Writer) error {
+	constraints := p.Constraints()
+	paths := make([]string, 0, len(constraints))
+	for path := range constraints {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	prof := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "constraints", Unit: "count"}},
+		PeriodType: &profile.ValueType{Type: "constraints", Unit: "count"},
+		Period:     1,
+	}
+	locations := make(map[string]*profile.Location)
+	for _, path := range paths {
+		names := strings.Split(path, "/")
+		sample := &profile.Sample{Value: []int64{int64(constraints[path])}}
+		// pprof lists the locations of a sample from the innermost one.
+		for i := len(names) - 1; i >= 0; i-- {
+			location, ok := locations[names[i]]
+			if !ok {
+				id := uint64(len(locations) + 1)
+				function := &profile.Function{ID: id, Name: names[i], SystemName: names[i]}
+				location = &profile.Location{ID: id, Line: []profile.Line{{Function: function}}}
+				locations[names[i]] = location
+				prof.Function = append(prof.Function, function)
+				prof.Location = append(prof.Location, location)
+			}
+			sample.Location = append(sample.Location, location)
+		}
+		prof.Sample = append(prof.Sample, sample)
+	}
+	return prof.Write(w)
+}
+
+// Scope opens a scope named name, e.g. "poseidon2.external_round", in the circuit being compiled
+// with api, and returns the function closing it:
+//
+//	defer field.Scope(api, "poseidon2.external_round")()
+//
+// It adds no constraint, and does nothing unless a scope profile is active.
+func Scope(api frontend.API, name string) func() {
+	p := activeScopeProfile.Load()
+	if p == nil {
+		return func() {}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	compiler := api.Compiler()
+	stack, ok := p.stacks[compiler]
+	if !ok {
+		stack = &scopeStack{countConstraints: ConstraintCounter(api)}
+		p.stacks[compiler] = stack
+	}
+	if stack.countConstraints == nil {
+		return func() {}
+	}
+	stack.open = append(stack.open, openScope{name: name, start: stack.countConstraints()})
+	depth := len(stack.open)
+	return func() { p.close(stack, depth) }
+}
+
+func (p *ScopeProfile) close(stack *scopeStack, depth int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(stack.open) != depth {
+		panic("scopes must be closed in the reverse order of their opening")
+	}
+	scope := stack.open[depth-1]
+	total := stack.countConstraints() - scope.start
+
+	names := make([]string, depth)
+	for i, open := range stack.open {
+		names[i] = open.name
+	}
+	p.constraints[strings.Join(names, "/")] += total - scope.nested
+
+	stack.open = stack.open[:depth-1]
+	if depth > 1 {
+		stack.open[depth-2].nested += total
+	}
+}
+
+// Scope opens a named scope in the circuit of the chip, see the Scope function.
+func (c *Chip[P]) Scope(name string) func() {
+	return Scope(c.api, name)
+}
+
+// ConstraintCounter returns a function counting the constraints added so far by the builder of
+// api, or nil if it is not one of gnark's builders. gnark does not expose the count during Define,
+// so it is read from the constraint system the builder keeps in its cs field.
+func ConstraintCounter(api frontend.API) func() int {
+	builder := reflect.ValueOf(api.Compiler())
+	if builder.Kind() != reflect.Pointer || builder.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	field := builder.Elem().FieldByName("cs")
+	if !field.IsValid() {
+		return nil
+	}
+	cs, ok := reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface().(interface{ GetNbConstraints() int })
+	if !ok {
+		return nil
+	}
+	return cs.GetNbConstraints
+}
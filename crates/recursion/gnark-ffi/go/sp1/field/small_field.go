@@ -0,0 +1,44 @@
+package field
+
+import "math/big"
+
+// SmallField is the arithmetic of a small field and of its extension, as used by the gadgets built
+// on top of the chip. Chip implements it for every FieldParams, so gadgets written against it
+// rather than a concrete chip work over any field. Constants are created with NewF and NewFConst,
+// which do not depend on the field.
+type SmallField interface {
+	Modulus() *big.Int
+	ReductionStrategy() ReductionStrategy
+
+	AddF(a, b Variable, forceReduce ...bool) Variable
+	AddFConst(a Variable, b int, forceReduce ...bool) Variable
+	SubF(a, b Variable) Variable
+	SubFConst(a Variable, b int, forceReduce ...bool) Variable
+	NegF(a Variable) Variable
+	MulF(a, b Variable, forceReduce ...bool) Variable
+	MulFConst(a Variable, b int, forceReduce ...bool) Variable
+	InvF(in Variable) Variable
+	DivF(a, b Variable) Variable
+	ReduceSlow(x Variable) Variable
+	ReduceFast(x Variable) Variable
+	ReduceBatch(xs []Variable) []Variable
+	AssertIsEqualF(a, b Variable)
+
+	AddE(a, b ExtensionVariable) ExtensionVariable
+	AddEF(a ExtensionVariable, b Variable) ExtensionVariable
+	SubE(a, b ExtensionVariable) ExtensionVariable
+	SubEF(a ExtensionVariable, b Variable) ExtensionVariable
+	MulE(a, b ExtensionVariable) ExtensionVariable
+	MulEF(a ExtensionVariable, b Variable) ExtensionVariable
+	InvE(in ExtensionVariable) ExtensionVariable
+	Powers(alpha ExtensionVariable, n int) []ExtensionVariable
+	ExpE(alpha ExtensionVariable, n uint64) ExtensionVariable
+	DivE(a, b ExtensionVariable) ExtensionVariable
+	ReduceE(x ExtensionVariable) ExtensionVariable
+	AssertIsEqualE(a, b ExtensionVariable)
+}
+
+// Modulus returns the modulus p of the field.
+func (c *Chip[P]) Modulus() *big.Int {
+	return new(big.Int).Set(c.modulus)
+}
@@ -0,0 +1,56 @@
+package sp1
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FriConfig holds the FRI parameters of the proofs verified by the circuit. The Rust build writes
+// them next to constraints.json; the number of queries is set with the FRI_QUERIES environment
+// variable when the constraints are generated.
+type FriConfig struct {
+	LogBlowup       int    `json:"log_blowup"`
+	NumQueries      int    `json:"num_queries"`
+	ProofOfWorkBits int    `json:"proof_of_work_bits"`
+	Profile         string `json:"profile"`
+}
+
+// IsDev reports whether the circuit was built with the dev profile, which verifies proofs with a
+// single FRI query. Such circuits are insecure and only meant for integration testing.
+func (c FriConfig) IsDev() bool {
+	return c.Profile == "dev"
+}
+
+// ConjecturedSoundnessBits returns the conjectured security level of FRI: each query contributes
+// log_blowup bits and the proof of work grinding adds its bits on top.
+func (c FriConfig) ConjecturedSoundnessBits() int {
+	return c.NumQueries*c.LogBlowup + c.ProofOfWorkBits
+}
+
+func ReadFriConfig(dataDir string) (FriConfig, error) {
+	data, err := os.ReadFile(dataDir + "/" + friConfigPath)
+	if err != nil {
+		return FriConfig{}, err
+	}
+	var config FriConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return FriConfig{}, fmt.Errorf("error deserializing FRI config: %w", err)
+	}
+	return config, nil
+}
+
+func printFriSoundness(dataDir string) {
+	config, err := ReadFriConfig(dataDir)
+	if err != nil {
+		fmt.Printf("FRI soundness unknown: %v\n", err)
+		return
+	}
+	fmt.Printf(
+		"FRI: %d queries, log blowup %d, %d proof of work bits: %d conjectured bits of security\n",
+		config.NumQueries, config.LogBlowup, config.ProofOfWorkBits, config.ConjecturedSoundnessBits(),
+	)
+	if config.IsDev() {
+		fmt.Println("WARNING: building with the dev profile, the circuit and its vkey are insecure")
+	}
+}
@@ -0,0 +1,381 @@
+package sp1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"text/template"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"golang.org/x/crypto/sha3"
+)
+
+var groth16BatchVerifierContractPath string = "Groth16BatchVerifier.sol"
+
+// groth16BatchVerifierEnv enables the export of the batch verifier when building the Groth16
+// circuit.
+const groth16BatchVerifierEnv = "SP1_GROTH16_BATCH_VERIFIER"
+
+// Batch verification checks k proofs with a random linear combination of their verification
+// equations, using k + 3 pairings instead of 4k:
+//
+//	prod e(r_i A_i, B_i) * e(sum r_i C_i, -delta) * e(sum r_i L_i, -gamma) * e((sum r_i) alpha, -beta) = 1
+//
+// where L_i = k[0] + sum_j input_ij k[j+1] is computed once as (sum r_i) k[0] + sum_j (sum_i r_i
+// input_ij) k[j+1]. The coefficients r_i are derived from the keccak256 hash of abi.encode(proofs,
+// inputs, seed), the canonical encoding of the arguments of verifyBatch, so that they are fixed
+// only once the proofs are. Hashing the calldata instead would let a caller pick other
+// coefficients for the same proofs by changing its non-canonical parts, e.g. the offsets. The seed
+// can mix in block data (e.g. block.prevrandao) or be chosen by the caller.
+
+// Groth16BatchSelector returns the selector of verifyBatch for nbPublicInputs public inputs.
+func Groth16BatchSelector(nbPublicInputs int) []byte {
+	h := sha3.NewLegacyKeccak256()
+	fmt.Fprintf(h, "verifyBatch(uint256[8][],uint256[%d][],uint256)", nbPublicInputs)
+	return h.Sum(nil)[:4]
+}
+
+// EncodeGroth16Batch encodes the calldata of verifyBatch(proofs, publicInputs, seed). The proofs
+// are 256 byte EIP-197 encoded proofs, as returned by MarshalSolidity.
+func EncodeGroth16Batch(proofs [][]byte, publicInputs [][]*big.Int, seed *big.Int) ([]byte, error) {
+	if len(proofs) == 0 || len(proofs) != len(publicInputs) {
+		return nil, fmt.Errorf("expected a non-empty batch with one input per proof, got %d proofs and %d inputs", len(proofs), len(publicInputs))
+	}
+	nbPublicInputs := len(publicInputs[0])
+	word := func(out []byte, v *big.Int) ([]byte, error) {
+		if v.Sign() < 0 || v.BitLen() > 256 {
+			return nil, fmt.Errorf("%s does not fit in a uint256", v)
+		}
+		var buf [32]byte
+		return append(out, v.FillBytes(buf[:])...), nil
+	}
+	uint64Word := func(out []byte, v uint64) []byte {
+		var buf [32]byte
+		binary.BigEndian.PutUint64(buf[24:], v)
+		return append(out, buf[:]...)
+	}
+
+	out := Groth16BatchSelector(nbPublicInputs)
+	k := uint64(len(proofs))
+	out = uint64Word(out, 3*32)
+	out = uint64Word(out, 3*32+32+k*256)
+	out, err := word(out, seed)
+	if err != nil {
+		return nil, err
+	}
+	out = uint64Word(out, k)
+	for i, proof := range proofs {
+		if len(proof) != 256 {
+			return nil, fmt.Errorf("proof %d: expected 256 bytes, got %d", i, len(proof))
+		}
+		out = append(out, proof...)
+	}
+	out = uint64Word(out, k)
+	for i, inputs := range publicInputs {
+		if len(inputs) != nbPublicInputs {
+			return nil, fmt.Errorf("proof %d: expected %d public inputs, got %d", i, nbPublicInputs, len(inputs))
+		}
+		for _, input := range inputs {
+			if out, err = word(out, input); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// batchCoefficients derives the coefficients of the linear combination from the ABI encoding of
+// the arguments of verifyBatch.
+func batchCoefficients(arguments []byte, k int) []*big.Int {
+	transcript := sha3.NewLegacyKeccak256()
+	transcript.Write(arguments)
+	seed := transcript.Sum(nil)
+
+	coefficients := make([]*big.Int, k)
+	for i := range coefficients {
+		var index [32]byte
+		binary.BigEndian.PutUint64(index[24:], uint64(i))
+		h := sha3.NewLegacyKeccak256()
+		h.Write(seed)
+		h.Write(index[:])
+		coefficients[i] = new(big.Int).SetBytes(h.Sum(nil))
+		coefficients[i].Mod(coefficients[i], ecc.BN254.ScalarField())
+	}
+	return coefficients
+}
+
+// VerifyBatch is the reference implementation of verifyBatch, computing the same coefficients and
+// pairing check as the contract.
+func (vk *RawGroth16VerifyingKey) VerifyBatch(proofs [][]byte, publicInputs [][]*big.Int, seed *big.Int) error {
+	calldata, err := EncodeGroth16Batch(proofs, publicInputs, seed)
+	if err != nil {
+		return err
+	}
+	if len(publicInputs[0])+1 != len(vk.K) {
+		return fmt.Errorf("expected %d public inputs, got %d", len(vk.K)-1, len(publicInputs[0]))
+	}
+	modulus := ecc.BN254.ScalarField()
+	// EncodeGroth16Batch encodes the arguments like abi.encode, after the selector.
+	coefficients := batchCoefficients(calldata[4:], len(proofs))
+
+	var g1 []bn254.G1Affine
+	var g2 []bn254.G2Affine
+	var cSum bn254.G1Affine
+	rSum := new(big.Int)
+	inputSums := make([]*big.Int, len(vk.K)-1)
+	for j := range inputSums {
+		inputSums[j] = new(big.Int)
+	}
+	for i, proof := range proofs {
+		r := rawReader{data: proof}
+		a, b, c := r.g1(), r.g2(), r.g1()
+		if r.err != nil {
+			return fmt.Errorf("proof %d: %w", i, r.err)
+		}
+		coefficient := coefficients[i]
+		rSum.Add(rSum, coefficient)
+		for j, input := range publicInputs[i] {
+			if input.Cmp(modulus) >= 0 {
+				return fmt.Errorf("proof %d: public input %d is not a scalar field element", i, j)
+			}
+			inputSums[j].Add(inputSums[j], new(big.Int).Mul(coefficient, input))
+		}
+
+		a.ScalarMultiplication(&a, coefficient)
+		c.ScalarMultiplication(&c, coefficient)
+		cSum.Add(&cSum, &c)
+		g1 = append(g1, a)
+		g2 = append(g2, b)
+	}
+
+	var l, alpha bn254.G1Affine
+	l.ScalarMultiplication(&vk.K[0], rSum.Mod(rSum, modulus))
+	for j, sum := range inputSums {
+		var term bn254.G1Affine
+		term.ScalarMultiplication(&vk.K[j+1], sum.Mod(sum, modulus))
+		l.Add(&l, &term)
+	}
+	alpha.ScalarMultiplication(&vk.Alpha, rSum)
+
+	var betaNeg, gammaNeg, deltaNeg bn254.G2Affine
+	betaNeg.Neg(&vk.Beta)
+	gammaNeg.Neg(&vk.Gamma)
+	deltaNeg.Neg(&vk.Delta)
+	ok, err := bn254.PairingCheck(append(g1, cSum, l, alpha), append(g2, deltaNeg, gammaNeg, betaNeg))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("batch pairing check failed")
+	}
+	return nil
+}
+
+// ExportGroth16BatchVerifier writes a Solidity contract with a verifyBatch entry point for vk.
+// Like ExportGroth16VerifyingKey, keys with commitments are rejected.
+func ExportGroth16BatchVerifier(vk groth16.VerifyingKey, w io.Writer) error {
+	key, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return fmt.Errorf("expected a BN254 verifying key, got %T", vk)
+	}
+	if len(key.CommitmentKeys) != 0 {
+		return errors.New("verifying keys with commitments are not supported")
+	}
+	var betaNeg, gammaNeg, deltaNeg bn254.G2Affine
+	betaNeg.Neg(&key.G2.Beta)
+	gammaNeg.Neg(&key.G2.Gamma)
+	deltaNeg.Neg(&key.G2.Delta)
+
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"fp": func(x fp.Element) string { return x.String() },
+	}).Parse(groth16BatchVerifierTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, struct {
+		Alpha                       bn254.G1Affine
+		BetaNeg, GammaNeg, DeltaNeg bn254.G2Affine
+		Constant                    bn254.G1Affine
+		Public                      []bn254.G1Affine
+		R                           string
+	}{
+		Alpha:    key.G1.Alpha,
+		BetaNeg:  betaNeg,
+		GammaNeg: gammaNeg,
+		DeltaNeg: deltaNeg,
+		Constant: key.G1.K[0],
+		Public:   key.G1.K[1:],
+		R:        ecc.BN254.ScalarField().String(),
+	})
+}
+
+const groth16BatchVerifierTemplate = `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// @title Groth16 batch verifier
+/// @notice Verifies several Groth16 proofs with a random linear combination of their verification
+/// equations, using one pairing per proof plus three instead of four per proof.
+contract Groth16BatchVerifier {
+    /// Some of the proofs are invalid.
+    error ProofInvalid();
+
+    /// A public input is not in the scalar field.
+    error PublicInputNotInField();
+
+    /// The batch is empty, or the number of proofs and public inputs differ.
+    error BatchInvalid();
+
+    uint256 constant PRECOMPILE_ADD = 0x06;
+    uint256 constant PRECOMPILE_MUL = 0x07;
+    uint256 constant PRECOMPILE_VERIFY = 0x08;
+
+    uint256 constant R = {{ .R }};
+
+    uint256 constant ALPHA_X = {{ fp .Alpha.X }};
+    uint256 constant ALPHA_Y = {{ fp .Alpha.Y }};
+    uint256 constant BETA_NEG_X_0 = {{ fp .BetaNeg.X.A0 }};
+    uint256 constant BETA_NEG_X_1 = {{ fp .BetaNeg.X.A1 }};
+    uint256 constant BETA_NEG_Y_0 = {{ fp .BetaNeg.Y.A0 }};
+    uint256 constant BETA_NEG_Y_1 = {{ fp .BetaNeg.Y.A1 }};
+    uint256 constant GAMMA_NEG_X_0 = {{ fp .GammaNeg.X.A0 }};
+    uint256 constant GAMMA_NEG_X_1 = {{ fp .GammaNeg.X.A1 }};
+    uint256 constant GAMMA_NEG_Y_0 = {{ fp .GammaNeg.Y.A0 }};
+    uint256 constant GAMMA_NEG_Y_1 = {{ fp .GammaNeg.Y.A1 }};
+    uint256 constant DELTA_NEG_X_0 = {{ fp .DeltaNeg.X.A0 }};
+    uint256 constant DELTA_NEG_X_1 = {{ fp .DeltaNeg.X.A1 }};
+    uint256 constant DELTA_NEG_Y_0 = {{ fp .DeltaNeg.Y.A0 }};
+    uint256 constant DELTA_NEG_Y_1 = {{ fp .DeltaNeg.Y.A1 }};
+
+    uint256 constant CONSTANT_X = {{ fp .Constant.X }};
+    uint256 constant CONSTANT_Y = {{ fp .Constant.Y }};
+{{- range $i, $k := .Public }}
+    uint256 constant PUB_{{ $i }}_X = {{ fp $k.X }};
+    uint256 constant PUB_{{ $i }}_Y = {{ fp $k.Y }};
+{{- end }}
+
+    function ecAdd(uint256 ax, uint256 ay, uint256 bx, uint256 by) internal view returns (uint256 x, uint256 y) {
+        bool success;
+        assembly ("memory-safe") {
+            let f := mload(0x40)
+            mstore(f, ax)
+            mstore(add(f, 0x20), ay)
+            mstore(add(f, 0x40), bx)
+            mstore(add(f, 0x60), by)
+            success := staticcall(gas(), PRECOMPILE_ADD, f, 0x80, f, 0x40)
+            x := mload(f)
+            y := mload(add(f, 0x20))
+        }
+        if (!success) {
+            revert ProofInvalid();
+        }
+    }
+
+    function ecMul(uint256 px, uint256 py, uint256 s) internal view returns (uint256 x, uint256 y) {
+        bool success;
+        assembly ("memory-safe") {
+            let f := mload(0x40)
+            mstore(f, px)
+            mstore(add(f, 0x20), py)
+            mstore(add(f, 0x40), s)
+            success := staticcall(gas(), PRECOMPILE_MUL, f, 0x60, f, 0x40)
+            x := mload(f)
+            y := mload(add(f, 0x20))
+        }
+        if (!success) {
+            revert ProofInvalid();
+        }
+    }
+
+    /// @notice Verifies a batch of proofs, reverting if any of them is invalid.
+    /// @param proofs The points (A, B, C) of each proof in EIP-197 format, as for verifyProof.
+    /// @param inputs The public inputs of each proof, reduced modulo R.
+    /// @param seed Mixed into the coefficients of the linear combination, e.g. block.prevrandao.
+    function verifyBatch(
+        uint256[8][] calldata proofs,
+        uint256[{{ len .Public }}][] calldata inputs,
+        uint256 seed
+    ) public view {
+        uint256 k = proofs.length;
+        if (k == 0 || inputs.length != k) {
+            revert BatchInvalid();
+        }
+        // The coefficients depend on the canonical encoding of all the arguments, including the
+        // seed, rather than on msg.data, whose offsets the caller can choose.
+        bytes32 transcript = keccak256(abi.encode(proofs, inputs, seed));
+
+        uint256[] memory pairing = new uint256[](6 * (k + 3));
+        uint256 rSum;
+        uint256[{{ len .Public }}] memory inputSums;
+        uint256 cx;
+        uint256 cy;
+        for (uint256 i = 0; i < k; i++) {
+            uint256 r = uint256(keccak256(abi.encodePacked(transcript, i))) % R;
+            rSum = addmod(rSum, r, R);
+            for (uint256 j = 0; j < {{ len .Public }}; j++) {
+                uint256 input = inputs[i][j];
+                if (input >= R) {
+                    revert PublicInputNotInField();
+                }
+                inputSums[j] = addmod(inputSums[j], mulmod(r, input, R), R);
+            }
+
+            (uint256 ax, uint256 ay) = ecMul(proofs[i][0], proofs[i][1], r);
+            pairing[6 * i] = ax;
+            pairing[6 * i + 1] = ay;
+            pairing[6 * i + 2] = proofs[i][2];
+            pairing[6 * i + 3] = proofs[i][3];
+            pairing[6 * i + 4] = proofs[i][4];
+            pairing[6 * i + 5] = proofs[i][5];
+
+            (uint256 rcx, uint256 rcy) = ecMul(proofs[i][6], proofs[i][7], r);
+            (cx, cy) = ecAdd(cx, cy, rcx, rcy);
+        }
+
+        (uint256 lx, uint256 ly) = ecMul(CONSTANT_X, CONSTANT_Y, rSum);
+        uint256 px;
+        uint256 py;
+{{- range $i, $k := .Public }}
+        (px, py) = ecMul(PUB_{{ $i }}_X, PUB_{{ $i }}_Y, inputSums[{{ $i }}]);
+        (lx, ly) = ecAdd(lx, ly, px, py);
+{{- end }}
+        (uint256 alphaX, uint256 alphaY) = ecMul(ALPHA_X, ALPHA_Y, rSum);
+
+        // Note: The precompile expects the F2 coefficients in big-endian order.
+        uint256 o = 6 * k;
+        pairing[o] = cx;
+        pairing[o + 1] = cy;
+        pairing[o + 2] = DELTA_NEG_X_1;
+        pairing[o + 3] = DELTA_NEG_X_0;
+        pairing[o + 4] = DELTA_NEG_Y_1;
+        pairing[o + 5] = DELTA_NEG_Y_0;
+        pairing[o + 6] = lx;
+        pairing[o + 7] = ly;
+        pairing[o + 8] = GAMMA_NEG_X_1;
+        pairing[o + 9] = GAMMA_NEG_X_0;
+        pairing[o + 10] = GAMMA_NEG_Y_1;
+        pairing[o + 11] = GAMMA_NEG_Y_0;
+        pairing[o + 12] = alphaX;
+        pairing[o + 13] = alphaY;
+        pairing[o + 14] = BETA_NEG_X_1;
+        pairing[o + 15] = BETA_NEG_X_0;
+        pairing[o + 16] = BETA_NEG_Y_1;
+        pairing[o + 17] = BETA_NEG_Y_0;
+
+        bool success;
+        assembly ("memory-safe") {
+            let f := mload(0x40)
+            success := staticcall(gas(), PRECOMPILE_VERIFY, add(pairing, 0x20), mul(mload(pairing), 0x20), f, 0x20)
+            success := and(success, mload(f))
+        }
+        if (!success) {
+            revert ProofInvalid();
+        }
+    }
+}
+`
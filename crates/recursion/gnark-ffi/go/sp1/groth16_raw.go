@@ -0,0 +1,201 @@
+package sp1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// The raw Groth16 verifying key layout lets runtimes without the EVM precompiles (e.g. Solana's
+// BN254 syscalls) verify proofs from the build output alone. All integers are big-endian, base
+// field elements take 32 bytes, and points use the EIP-197 encoding:
+//
+//	magic     "SP1G16VK" (8 bytes)
+//	version   uint32, currently 1
+//	alpha     G1: x, y
+//	beta      G2: x.a1, x.a0, y.a1, y.a0
+//	gamma     G2
+//	delta     G2
+//	n         uint32, the number of public inputs + 1
+//	k[0..n]   G1
+//
+// Proofs are verified in the EncodedProof layout of Proof: A (G1), B (G2), C (G1), 256 bytes.
+
+const groth16RawVkMagic = "SP1G16VK"
+const groth16RawVkVersion = 1
+
+type RawGroth16VerifyingKey struct {
+	Alpha              bn254.G1Affine
+	Beta, Gamma, Delta bn254.G2Affine
+	K                  []bn254.G1Affine
+}
+
+// ExportGroth16VerifyingKey writes vk in the raw layout. Keys with commitments are rejected, as
+// the layout has no room for them and the SP1 circuit does not use any for Groth16.
+func ExportGroth16VerifyingKey(vk groth16.VerifyingKey, w io.Writer) error {
+	key, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return fmt.Errorf("expected a BN254 verifying key, got %T", vk)
+	}
+	if len(key.CommitmentKeys) != 0 {
+		return errors.New("verifying keys with commitments are not supported")
+	}
+
+	var out []byte
+	out = append(out, groth16RawVkMagic...)
+	out = binary.BigEndian.AppendUint32(out, groth16RawVkVersion)
+	out = appendG1(out, &key.G1.Alpha)
+	out = appendG2(out, &key.G2.Beta)
+	out = appendG2(out, &key.G2.Gamma)
+	out = appendG2(out, &key.G2.Delta)
+	out = binary.BigEndian.AppendUint32(out, uint32(len(key.G1.K)))
+	for i := range key.G1.K {
+		out = appendG1(out, &key.G1.K[i])
+	}
+	_, err := w.Write(out)
+	return err
+}
+
+func ParseGroth16VerifyingKey(data []byte) (*RawGroth16VerifyingKey, error) {
+	r := rawReader{data: data}
+	if string(r.next(len(groth16RawVkMagic))) != groth16RawVkMagic {
+		return nil, errors.New("not a raw Groth16 verifying key")
+	}
+	if version := r.uint32(); version != groth16RawVkVersion {
+		return nil, fmt.Errorf("unsupported raw verifying key version %d", version)
+	}
+	var vk RawGroth16VerifyingKey
+	vk.Alpha = r.g1()
+	vk.Beta = r.g2()
+	vk.Gamma = r.g2()
+	vk.Delta = r.g2()
+	n := r.uint32()
+	if r.err == nil && uint64(n)*64 != uint64(len(r.data)) {
+		return nil, fmt.Errorf("expected %d public input points, got %d bytes", n, len(r.data))
+	}
+	vk.K = make([]bn254.G1Affine, 0, n)
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		vk.K = append(vk.K, r.g1())
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &vk, nil
+}
+
+// Verify is a reference verifier working on curve operations only, checking
+// e(A, B) = e(alpha, beta) e(k[0] + sum(input[i] k[i+1]), gamma) e(C, delta).
+func (vk *RawGroth16VerifyingKey) Verify(encodedProof []byte, publicInputs []*big.Int) error {
+	if len(encodedProof) != 256 {
+		return fmt.Errorf("expected a 256 byte proof, got %d bytes", len(encodedProof))
+	}
+	if len(publicInputs)+1 != len(vk.K) {
+		return fmt.Errorf("expected %d public inputs, got %d", len(vk.K)-1, len(publicInputs))
+	}
+	r := rawReader{data: encodedProof}
+	a, b, c := r.g1(), r.g2(), r.g1()
+	if r.err != nil {
+		return r.err
+	}
+
+	l := vk.K[0]
+	for i, input := range publicInputs {
+		if input.Sign() < 0 || input.Cmp(ecc.BN254.ScalarField()) >= 0 {
+			return fmt.Errorf("public input %d is not a scalar field element", i)
+		}
+		var term bn254.G1Affine
+		term.ScalarMultiplication(&vk.K[i+1], input)
+		l.Add(&l, &term)
+	}
+
+	var alphaNeg, lNeg, cNeg bn254.G1Affine
+	alphaNeg.Neg(&vk.Alpha)
+	lNeg.Neg(&l)
+	cNeg.Neg(&c)
+	ok, err := bn254.PairingCheck(
+		[]bn254.G1Affine{a, alphaNeg, lNeg, cNeg},
+		[]bn254.G2Affine{b, vk.Beta, vk.Gamma, vk.Delta},
+	)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("pairing check failed")
+	}
+	return nil
+}
+
+func appendFp(out []byte, e *fp.Element) []byte {
+	bytes := e.Bytes()
+	return append(out, bytes[:]...)
+}
+
+func appendG1(out []byte, p *bn254.G1Affine) []byte {
+	return appendFp(appendFp(out, &p.X), &p.Y)
+}
+
+func appendG2(out []byte, p *bn254.G2Affine) []byte {
+	out = appendFp(appendFp(out, &p.X.A1), &p.X.A0)
+	return appendFp(appendFp(out, &p.Y.A1), &p.Y.A0)
+}
+
+// rawReader decodes the raw layout, keeping the first error.
+type rawReader struct {
+	data []byte
+	err  error
+}
+
+func (r *rawReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.data) < n {
+		r.err = io.ErrUnexpectedEOF
+		return make([]byte, n)
+	}
+	out := r.data[:n]
+	r.data = r.data[n:]
+	return out
+}
+
+func (r *rawReader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.next(4))
+}
+
+func (r *rawReader) fp() fp.Element {
+	var e fp.Element
+	if err := e.SetBytesCanonical(r.next(fp.Bytes)); err != nil && r.err == nil {
+		r.err = err
+	}
+	return e
+}
+
+func (r *rawReader) g1() bn254.G1Affine {
+	var p bn254.G1Affine
+	p.X = r.fp()
+	p.Y = r.fp()
+	if r.err == nil && !p.IsInSubGroup() {
+		r.err = errors.New("G1 point is not in the subgroup")
+	}
+	return p
+}
+
+func (r *rawReader) g2() bn254.G2Affine {
+	var p bn254.G2Affine
+	p.X.A1 = r.fp()
+	p.X.A0 = r.fp()
+	p.Y.A1 = r.fp()
+	p.Y.A0 = r.fp()
+	if r.err == nil && !p.IsInSubGroup() {
+		r.err = errors.New("G2 point is not in the subgroup")
+	}
+	return p
+}
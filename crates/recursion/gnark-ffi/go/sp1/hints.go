@@ -0,0 +1,46 @@
+package sp1
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
+)
+
+// proverHints returns the prover option wrapping the registered hints for a prove of cs, and the
+// function to call once the prove is done. The solver calls hints from its worker goroutines,
+// where a panic is out of reach of the recover of the export and aborts the process embedding the
+// library: the wrapped hints return it as an error instead. With SP1_SOLVER_STALL_TIMEOUT set, the
+// hints are also watched for stalls (see solverWatch).
+func proverHints(cs constraint.ConstraintSystem) (backend.ProverOption, func()) {
+	var watch *solverWatch
+	stop := func() {}
+	if timeout := solverStallTimeout(); timeout > 0 {
+		watch = newSolverWatch(cs, timeout)
+		stop = watch.stop
+	}
+	var options []solver.Option
+	for _, hint := range solver.GetRegisteredHints() {
+		name := solver.GetHintName(hint)
+		wrapped := recoverHintPanics(name, hint)
+		if watch != nil {
+			wrapped = watch.wrap(name, wrapped)
+		}
+		options = append(options, solver.OverrideHint(solver.GetHintID(hint), wrapped))
+	}
+	return backend.WithSolverOptions(options...), stop
+}
+
+// recoverHintPanics returns hint, returning its panics as errors.
+func recoverHintPanics(name string, hint solver.Hint) solver.Hint {
+	return func(field *big.Int, inputs []*big.Int, outputs []*big.Int) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("hint %s panicked: %v", name, r)
+			}
+		}()
+		return hint(field, inputs, outputs)
+	}
+}
@@ -0,0 +1,71 @@
+package koalabear
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Ext is an extension element outside of the circuit. Coefficients are canonical and in the
+// order of as_base_slice on the Rust side: Ext{a, b, c, d} is a + bX + cX^2 + dX^3.
+//
+// In the witness, and in JSON, an Ext is encoded as the decimal strings of its coefficients, which
+// is how GnarkWitness serializes its exts.
+type Ext [4]uint32
+
+// ParseF parses the witness encoding of a felt, rejecting non-canonical values.
+func ParseF(value string) (uint32, error) {
+	v, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || v >= modulus.Uint64() || strconv.FormatUint(v, 10) != value {
+		return 0, fmt.Errorf("invalid KoalaBear element %q", value)
+	}
+	return uint32(v), nil
+}
+
+// ParseExt parses the witness encoding of an extension element, rejecting non-canonical
+// coefficients.
+func ParseExt(value []string) (Ext, error) {
+	if len(value) != 4 {
+		return Ext{}, fmt.Errorf("extension element must have 4 coefficients, got %d", len(value))
+	}
+	var e Ext
+	for i, coefficient := range value {
+		v, err := ParseF(coefficient)
+		if err != nil {
+			return Ext{}, err
+		}
+		e[i] = v
+	}
+	return e, nil
+}
+
+// Strings returns the witness encoding of e.
+func (e Ext) Strings() []string {
+	value := make([]string, 4)
+	for i, coefficient := range e {
+		value[i] = strconv.FormatUint(uint64(coefficient), 10)
+	}
+	return value
+}
+
+// Variable returns e as a witness variable.
+func (e Ext) Variable() ExtensionVariable {
+	return NewE(e.Strings())
+}
+
+func (e Ext) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.Strings())
+}
+
+func (e *Ext) UnmarshalJSON(data []byte) error {
+	var value []string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := ParseExt(value)
+	if err != nil {
+		return err
+	}
+	*e = parsed
+	return nil
+}
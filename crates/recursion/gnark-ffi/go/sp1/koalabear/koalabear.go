@@ -0,0 +1,68 @@
+// Package koalabear instantiates the field chip with KoalaBear, the field of SP1's newer
+// configurations, mirroring package babybear.
+package koalabear
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+var modulus = new(big.Int).SetUint64(2130706433)
+var montyInverse = new(big.Int).SetUint64(1057030144)
+
+// Params are the parameters of the KoalaBear field p = 127 * 2^24 + 1 and of its degree 4
+// extension F[X]/(X^4 - 3), as in Plonky3.
+type Params struct{}
+
+func (Params) Modulus() *big.Int      { return modulus }
+func (Params) NbBits() int            { return 31 }
+func (Params) MontyInverse() *big.Int { return montyInverse }
+func (Params) ExtW() int              { return 3 }
+
+type Variable = field.Variable
+
+type ExtensionVariable = field.ExtensionVariable
+
+type Bool = field.Bool
+
+type Chip = field.Chip[Params]
+
+var _ field.SmallField = (*Chip)(nil)
+
+func NewChip(api frontend.API) *Chip {
+	return field.NewChip[Params](api)
+}
+
+func NewBitsRangeChecker(api frontend.API) frontend.Rangechecker {
+	return field.NewBitsRangeChecker(api)
+}
+
+func Zero() Variable {
+	return field.Zero()
+}
+
+func One() Variable {
+	return field.One()
+}
+
+func NewFConst(value string) Variable {
+	return field.NewFConst(value)
+}
+
+func NewF(value string) Variable {
+	return field.NewF(value)
+}
+
+func NewE(value []string) ExtensionVariable {
+	return field.NewE(value)
+}
+
+func NewEConst(value []string) ExtensionVariable {
+	return field.NewEConst(value)
+}
+
+func Felts2Ext(a, b, c, d Variable) ExtensionVariable {
+	return field.Felts2Ext(a, b, c, d)
+}
@@ -0,0 +1,46 @@
+package koalabear
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type arithmeticCircuit struct {
+	A, Inverse                Variable
+	X, Y, Product, InverseOfX ExtensionVariable
+}
+
+func (c *arithmeticCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualF(chip.InvF(c.A), c.Inverse)
+	chip.AssertIsEqualE(chip.MulE(c.X, c.Y), c.Product)
+	chip.AssertIsEqualE(chip.InvE(c.X), c.InverseOfX)
+	return nil
+}
+
+func TestArithmetic(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	zero := NewE([]string{"0", "0", "0", "0"})
+	circuit := arithmeticCircuit{A: NewF("0"), Inverse: NewF("0"), X: zero, Y: zero, Product: zero, InverseOfX: zero}
+
+	// 2^-1 = (p + 1) / 2, with A given as its non-canonical representative 2 + p. The extension
+	// elements are reduced modulo X^4 - 3.
+	assignment := arithmeticCircuit{
+		A:          NewF("2130706435"),
+		Inverse:    NewF("1065353217"),
+		X:          NewE([]string{"1", "2", "3", "2130706432"}),
+		Y:          NewE([]string{"5", "6", "7", "8"}),
+		Product:    NewE([]string{"98", "67", "10", "35"}),
+		InverseOfX: NewE([]string{"1135398356", "955282283", "933935193", "663093987"}),
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// The product with the BabyBear non-residue, X^4 = 11.
+	assignment.Product = NewE([]string{"346", "203", "2130706379", "35"})
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
@@ -0,0 +1,82 @@
+// Package merkle verifies the Merkle paths of the commitments of the inner proof system.
+package merkle
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/poseidon2"
+)
+
+// Digest is a node of a Merkle tree hashed with the Poseidon2 compression function.
+type Digest = [poseidon2.BABYBEAR_DIGEST_WIDTH]babybear.Variable
+
+// VerifyMerkleProof asserts that leaf is in the tree of the given root at the index given by
+// indexBits, from the leaf up: indexBits[i] is 1 when the node at level i is a right child, and
+// siblings[i] is its sibling. The height of the tree is len(siblings).
+func VerifyMerkleProof(api frontend.API, leaf Digest, siblings []Digest, indexBits []babybear.Bool, root Digest) {
+	if len(siblings) != len(indexBits) {
+		panic("the Merkle path and the index do not have the same length")
+	}
+	fieldApi := babybear.NewChip(api)
+	poseidon2Chip := poseidon2.NewBabyBearChip(api)
+
+	node := leaf
+	for i := range siblings {
+		node = compressChildren(fieldApi, poseidon2Chip, indexBits[i], node, siblings[i])
+	}
+	assertDigestsEqual(fieldApi, node, root)
+}
+
+// VerifyMerkleProofHeight is VerifyMerkleProof for a tree whose height is only known when solving,
+// e.g. the tree of a matrix of variable height. The proof is padded to the maximal height: the
+// siblings and index bits from level height on are ignored. height must be at most len(siblings).
+func VerifyMerkleProofHeight(
+	api frontend.API,
+	leaf Digest,
+	siblings []Digest,
+	indexBits []babybear.Bool,
+	height frontend.Variable,
+	root Digest,
+) {
+	if len(siblings) != len(indexBits) {
+		panic("the Merkle path and the index do not have the same length")
+	}
+	api.AssertIsLessOrEqual(height, len(siblings))
+	fieldApi := babybear.NewChip(api)
+	poseidon2Chip := poseidon2.NewBabyBearChip(api)
+
+	// active is 1 while the level is below height.
+	active := fieldApi.Not(fieldApi.NewBool(api.IsZero(height)))
+	node := leaf
+	for i := range siblings {
+		if i > 0 {
+			active = fieldApi.And(active, fieldApi.Not(fieldApi.NewBool(api.IsZero(api.Sub(height, i)))))
+		}
+		parent := compressChildren(fieldApi, poseidon2Chip, indexBits[i], node, siblings[i])
+		for j := range node {
+			node[j] = fieldApi.SelectF(active, parent[j], node[j])
+		}
+	}
+	assertDigestsEqual(fieldApi, node, root)
+}
+
+// compressChildren hashes node with its sibling, in the order given by isRight.
+func compressChildren(
+	fieldApi *babybear.Chip,
+	poseidon2Chip *poseidon2.Poseidon2BabyBearChip,
+	isRight babybear.Bool,
+	node, sibling Digest,
+) Digest {
+	var left, right Digest
+	for j := range node {
+		left[j] = fieldApi.SelectF(isRight, sibling[j], node[j])
+		right[j] = fieldApi.SelectF(isRight, node[j], sibling[j])
+	}
+	return poseidon2Chip.Compress(left, right)
+}
+
+func assertDigestsEqual(fieldApi *babybear.Chip, a, b Digest) {
+	for j := range a {
+		fieldApi.AssertIsEqualF(a[j], b[j])
+	}
+}
@@ -0,0 +1,156 @@
+package merkle
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/poseidon2"
+)
+
+const treeHeight = 3
+const maxHeight = 5
+
+type nativeDigest = [poseidon2.BABYBEAR_DIGEST_WIDTH]uint32
+
+type merkleCircuit struct {
+	Leaf      Digest
+	Siblings  [maxHeight]Digest
+	IndexBits [maxHeight]frontend.Variable
+	Height    frontend.Variable
+	Root      Digest
+}
+
+func (c *merkleCircuit) Define(api frontend.API) error {
+	fieldApi := babybear.NewChip(api)
+	indexBits := make([]babybear.Bool, maxHeight)
+	for i := range indexBits {
+		indexBits[i] = fieldApi.NewBool(c.IndexBits[i])
+	}
+	VerifyMerkleProof(api, c.Leaf, c.Siblings[:treeHeight], indexBits[:treeHeight], c.Root)
+	VerifyMerkleProofHeight(api, c.Leaf, c.Siblings[:], indexBits, c.Height, c.Root)
+	return nil
+}
+
+func newDigest(d nativeDigest) Digest {
+	var result Digest
+	for i := range d {
+		result[i] = babybear.NewF(strconv.FormatUint(uint64(d[i]), 10))
+	}
+	return result
+}
+
+// merkleWitness builds a tree of 2^height leaves and returns the witness of the path of leaf
+// index, padded with garbage up to maxHeight.
+func merkleWitness(index int, height int) *merkleCircuit {
+	leaves := make([]nativeDigest, 1<<height)
+	for i := range leaves {
+		for j := range leaves[i] {
+			leaves[i][j] = uint32((i*poseidon2.BABYBEAR_DIGEST_WIDTH + j + 1) * 7919)
+		}
+	}
+
+	w := &merkleCircuit{Leaf: newDigest(leaves[index]), Height: height}
+	level := leaves
+	for h := 0; h < maxHeight; h++ {
+		if h >= height {
+			w.Siblings[h] = newDigest(nativeDigest{uint32(h)})
+			w.IndexBits[h] = h % 2
+			continue
+		}
+		w.Siblings[h] = newDigest(level[index^1])
+		w.IndexBits[h] = index & 1
+		parents := make([]nativeDigest, len(level)/2)
+		for i := range parents {
+			parents[i] = poseidon2.CompressBabyBear(level[2*i], level[2*i+1])
+		}
+		level = parents
+		index >>= 1
+	}
+	w.Root = newDigest(level[0])
+	return w
+}
+
+func newMerkleCircuit() merkleCircuit {
+	var circuit merkleCircuit
+	for i := range circuit.Leaf {
+		circuit.Leaf[i] = babybear.NewF("0")
+		circuit.Root[i] = babybear.NewF("0")
+		for h := range circuit.Siblings {
+			circuit.Siblings[h][i] = babybear.NewF("0")
+		}
+	}
+	return circuit
+}
+
+func TestVerifyMerkleProof(t *testing.T) {
+	assert := test.NewAssert(t)
+	circuit := newMerkleCircuit()
+
+	assert.ProverSucceeded(&circuit, merkleWitness(5, treeHeight), test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// Every leaf, so that every level is checked both as a left and as a right child.
+	for index := 0; index < 1<<treeHeight; index++ {
+		if err := test.IsSolved(&circuit, merkleWitness(index, treeHeight), ecc.BN254.ScalarField()); err != nil {
+			t.Errorf("leaf %d: %v", index, err)
+		}
+	}
+
+	wrongIndex := merkleWitness(5, treeHeight)
+	wrongIndex.IndexBits[1] = 1
+	assert.ProverFailed(&circuit, wrongIndex, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	wrongSibling := merkleWitness(2, treeHeight)
+	wrongSibling.Siblings[2][3] = babybear.NewF("1")
+	assert.ProverFailed(&circuit, wrongSibling, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	wrongHeight := merkleWitness(5, treeHeight)
+	wrongHeight.Height = treeHeight + 1
+	assert.ProverFailed(&circuit, wrongHeight, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type merkleHeightCircuit struct {
+	merkleCircuit
+}
+
+func (c *merkleHeightCircuit) Define(api frontend.API) error {
+	fieldApi := babybear.NewChip(api)
+	indexBits := make([]babybear.Bool, maxHeight)
+	for i := range indexBits {
+		indexBits[i] = fieldApi.NewBool(c.IndexBits[i])
+	}
+	VerifyMerkleProofHeight(api, c.Leaf, c.Siblings[:], indexBits, c.Height, c.Root)
+	return nil
+}
+
+func TestVerifyMerkleProofHeight(t *testing.T) {
+	circuit := merkleHeightCircuit{newMerkleCircuit()}
+
+	// Trees shorter than the padded proof, down to a single leaf which is the root, and a tree of
+	// the maximal height.
+	for _, height := range []int{0, 1, 2, maxHeight} {
+		for _, index := range []int{0, (1 << height) - 1} {
+			w := merkleWitness(index, height)
+			if err := test.IsSolved(&circuit, &merkleHeightCircuit{*w}, ecc.BN254.ScalarField()); err != nil {
+				t.Errorf("height %d, leaf %d: %v", height, index, err)
+			}
+		}
+
+		w := merkleWitness(0, height)
+		w.Height = height + 1
+		if err := test.IsSolved(&circuit, &merkleHeightCircuit{*w}, ecc.BN254.ScalarField()); err == nil {
+			t.Errorf("height %d: accepted the proof as one of height %d", height, height+1)
+		}
+		if height > 0 {
+			w := merkleWitness(0, height)
+			w.Siblings[height-1][0] = babybear.NewF("1")
+			if err := test.IsSolved(&circuit, &merkleHeightCircuit{*w}, ecc.BN254.ScalarField()); err == nil {
+				t.Errorf("height %d: accepted a wrong sibling", height)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package mersenne31 instantiates the field chip with the Mersenne prime p = 2^31 - 1 of
+// Circle-STARKs, mirroring package babybear for the base field.
+//
+// p = 3 mod 4, so M31 has no degree 4 binomial extension: Circle-STARKs use the tower
+// CM31 = F[i]/(i^2 + 1), QM31 = CM31[u]/(u^2 - 2 - i) instead, which the chip does not implement
+// yet. The extension multiplications of the chip panic.
+package mersenne31
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+var modulus = new(big.Int).SetUint64(2147483647)
+
+// 2^-32 = 2^-1 = 2^30, as 2^31 = 1. Plonky3 does not store M31 elements in Montgomery form, but the
+// factor is still defined.
+var montyInverse = new(big.Int).SetUint64(1 << 30)
+
+// Params are the parameters of the Mersenne31 field, without extension.
+type Params struct{}
+
+func (Params) Modulus() *big.Int      { return modulus }
+func (Params) NbBits() int            { return 31 }
+func (Params) MontyInverse() *big.Int { return montyInverse }
+func (Params) ExtW() int              { return 0 }
+
+type Variable = field.Variable
+
+type Bool = field.Bool
+
+type Chip = field.Chip[Params]
+
+func NewChip(api frontend.API) *Chip {
+	return field.NewChip[Params](api)
+}
+
+func NewBitsRangeChecker(api frontend.API) frontend.Rangechecker {
+	return field.NewBitsRangeChecker(api)
+}
+
+func Zero() Variable {
+	return field.Zero()
+}
+
+func One() Variable {
+	return field.One()
+}
+
+func NewFConst(value string) Variable {
+	return field.NewFConst(value)
+}
+
+func NewF(value string) Variable {
+	return field.NewF(value)
+}
@@ -0,0 +1,62 @@
+package mersenne31
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+type arithmeticCircuit struct {
+	A, B, Product, Inverse Variable
+}
+
+func (c *arithmeticCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.AssertIsEqualF(chip.MulF(c.A, c.B), c.Product)
+	chip.AssertIsEqualF(chip.InvF(c.A), c.Inverse)
+	return nil
+}
+
+func TestArithmetic(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := arithmeticCircuit{A: NewF("0"), B: NewF("0"), Product: NewF("0"), Inverse: NewF("0")}
+
+	// 2 * 2^30 = 2^31 = 1 and 2^-1 = 2^30, with A given as its non-canonical representative 2 + p.
+	assignment := arithmeticCircuit{
+		A:       NewF("2147483649"),
+		B:       NewF("1073741824"),
+		Product: NewF("1"),
+		Inverse: NewF("1073741824"),
+	}
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	assignment.Product = NewF("2")
+	assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type extensionCircuit struct {
+	X field.ExtensionVariable
+}
+
+func (c *extensionCircuit) Define(api frontend.API) error {
+	chip := NewChip(api)
+	chip.MulE(c.X, c.X)
+	return nil
+}
+
+func TestNoExtension(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var circuit extensionCircuit
+	for i := range circuit.X.Value {
+		circuit.X.Value[i] = NewF("0")
+	}
+	_, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+	assert.Error(err)
+}
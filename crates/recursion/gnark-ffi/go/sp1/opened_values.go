@@ -0,0 +1,76 @@
+package sp1
+
+import "fmt"
+
+// The types below mirror the opened values of a shard proof (see crates/stark/src/types.rs), as
+// DecodeReduceProof fills them in. Every value is an extension element, encoded like the exts of
+// the witness: four decimal BabyBear coefficients in as_base_slice order.
+
+type AirOpenedValues struct {
+	Local [][]string
+	Next  [][]string
+}
+
+type ChipOpenedValues struct {
+	Preprocessed        AirOpenedValues
+	Main                AirOpenedValues
+	Permutation         AirOpenedValues
+	Quotient            [][][]string
+	GlobalCumulativeSum []string
+	LocalCumulativeSum  []string
+	LogDegree           int
+}
+
+type ShardOpenedValues struct {
+	Chips []ChipOpenedValues
+}
+
+// ChipShape is the expected layout of the opened values of a single chip.
+type ChipShape struct {
+	PreprocessedWidth int
+	MainWidth         int
+	PermutationWidth  int
+	QuotientChunks    int
+}
+
+// ValidateShape checks the opened values against the expected per-chip layout.
+func (v ShardOpenedValues) ValidateShape(shapes []ChipShape) error {
+	if len(v.Chips) != len(shapes) {
+		return fmt.Errorf("expected %d chips, got %d", len(shapes), len(v.Chips))
+	}
+	for i, chip := range v.Chips {
+		if err := chip.validateShape(shapes[i]); err != nil {
+			return fmt.Errorf("chip %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c ChipOpenedValues) validateShape(shape ChipShape) error {
+	if err := c.Preprocessed.validateWidth(shape.PreprocessedWidth); err != nil {
+		return fmt.Errorf("preprocessed: %w", err)
+	}
+	if err := c.Main.validateWidth(shape.MainWidth); err != nil {
+		return fmt.Errorf("main: %w", err)
+	}
+	if err := c.Permutation.validateWidth(shape.PermutationWidth); err != nil {
+		return fmt.Errorf("permutation: %w", err)
+	}
+	if len(c.Quotient) != shape.QuotientChunks {
+		return fmt.Errorf("expected %d quotient chunks, got %d", shape.QuotientChunks, len(c.Quotient))
+	}
+	for i, chunk := range c.Quotient {
+		// The quotient of each chunk is opened as its four base field components.
+		if len(chunk) != 4 {
+			return fmt.Errorf("quotient chunk %d: expected 4 values, got %d", i, len(chunk))
+		}
+	}
+	return nil
+}
+
+func (a AirOpenedValues) validateWidth(width int) error {
+	if len(a.Local) != width || len(a.Next) != width {
+		return fmt.Errorf("expected width %d, got %d local and %d next values", width, len(a.Local), len(a.Next))
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package sp1
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// memoryPerThreadEnv overrides defaultMemoryPerThread, in bytes.
+const memoryPerThreadEnv = "SP1_PROVER_MEMORY_PER_THREAD"
+
+// defaultMemoryPerThread is a conservative estimate of the scratch memory of a prover thread: the
+// buckets of its MSM chunks and the buffers of its FFTs.
+const defaultMemoryPerThread = 512 << 20
+
+// warnTightMemory logs a warning at the start of a prove when the available memory, minus the
+// required bytes that are about to be allocated (e.g. a proving key to read), cannot cover the
+// scratch memory of every prover thread.
+//
+// It only warns: gnark sizes its MSM tasks from runtime.NumCPU, not GOMAXPROCS, and exposes no
+// option to lower them, so no setting of this process reduces the memory of a prove. Restricting
+// the CPUs of the process (e.g. with taskset or a cgroup cpuset) does, as runtime.NumCPU follows
+// its CPU affinity.
+func warnTightMemory(required int64) {
+	available, err := availableMemory()
+	if err != nil {
+		return
+	}
+	threads := runtime.NumCPU()
+	fitting := fittingParallelism(available, required, memoryPerThread(), threads)
+	if fitting == threads {
+		return
+	}
+	fmt.Printf("Only %d bytes of memory are available, which may not be enough to prove with %d CPUs; restrict the process to %d CPUs if it gets OOM-killed\n", available, threads, fitting)
+}
+
+// fittingParallelism returns the number of threads, at most threads and at least 1, whose scratch
+// memory fits in what is left of available once required is allocated.
+func fittingParallelism(available int64, required int64, perThread int64, threads int) int {
+	fitting := (available - required) / perThread
+	if fitting >= int64(threads) {
+		return threads
+	}
+	return int(max(fitting, 1))
+}
+
+func memoryPerThread() int64 {
+	if value, err := strconv.ParseInt(os.Getenv(memoryPerThreadEnv), 10, 64); err == nil && value > 0 {
+		return value
+	}
+	return defaultMemoryPerThread
+}
+
+// provingKeyMemory returns the bytes a prove is about to allocate for its proving key: its size on
+// disk, unless it is already loaded.
+func provingKeyMemory(dataDir string, pkPath string, keyLoaded bool) int64 {
+	if keyLoaded {
+		return 0
+	}
+	info, err := os.Stat(dataDir + "/" + pkPath)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,96 @@
+// Package pcs holds the gadgets of the polynomial commitment scheme verifier.
+package pcs
+
+import (
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+// MaxLogHeight bounds the log2 of the height of the committed matrices, blowup included.
+const MaxLogHeight = 32
+
+// MatrixOpening is a row of a committed matrix opened at a FRI query, together with the claimed
+// evaluations of the columns of the matrix at the out-of-domain point.
+type MatrixOpening struct {
+	// LogHeight is the log2 of the height of the matrix, blowup included.
+	LogHeight int
+	// X is the domain point of the opened row.
+	X field.Variable
+	// AtX holds the values of the row.
+	AtX []field.Variable
+	// AtZ holds the claimed evaluations of the columns at the out-of-domain point.
+	AtZ []field.ExtensionVariable
+}
+
+// ReducedOpenings computes the reduced openings of a FRI query like the Rust verifier does: for
+// every log height h,
+//
+//	ro[h] = sum over the matrices of height 2^h of sum_j alpha^k_j (p_j(z) - p_j(x)) / (z - x)
+//
+// where k_j counts the columns of height 2^h before column j, in the order of the openings. The
+// powers of alpha are computed once for all the matrices, each matrix takes a single division,
+// and the results are reduced together at the end. Heights without any matrix are left at zero.
+func ReducedOpenings(
+	chip field.SmallField,
+	alpha, z field.ExtensionVariable,
+	openings []MatrixOpening,
+) [MaxLogHeight]field.ExtensionVariable {
+	var nbColumns [MaxLogHeight]int
+	maxColumns := 0
+	for _, opening := range openings {
+		if opening.LogHeight < 0 || opening.LogHeight >= MaxLogHeight {
+			panic("matrix height out of range")
+		}
+		if len(opening.AtX) != len(opening.AtZ) {
+			panic("opened row and claimed evaluations differ in width")
+		}
+		nbColumns[opening.LogHeight] += len(opening.AtX)
+		maxColumns = max(maxColumns, nbColumns[opening.LogHeight])
+	}
+	alphaPows := chip.Powers(alpha, maxColumns)
+
+	var ro [MaxLogHeight]field.ExtensionVariable
+	var used [MaxLogHeight]bool
+	var pow [MaxLogHeight]int
+	for _, opening := range openings {
+		h := opening.LogHeight
+		if len(opening.AtX) == 0 {
+			continue
+		}
+
+		acc := zeroE()
+		for j := range opening.AtX {
+			diff := chip.SubEF(opening.AtZ[j], opening.AtX[j])
+			acc = chip.AddE(acc, chip.MulE(alphaPows[pow[h]], diff))
+			pow[h]++
+		}
+		quotient := chip.DivE(acc, chip.SubEF(z, opening.X))
+
+		if used[h] {
+			ro[h] = chip.AddE(ro[h], quotient)
+		} else {
+			ro[h] = quotient
+			used[h] = true
+		}
+	}
+
+	var coefficients []field.Variable
+	for h := range ro {
+		if used[h] {
+			coefficients = append(coefficients, ro[h].Value[:]...)
+		}
+	}
+	reduced := chip.ReduceBatch(coefficients)
+	for h := range ro {
+		if !used[h] {
+			ro[h] = zeroE()
+			continue
+		}
+		copy(ro[h].Value[:], reduced[:4])
+		reduced = reduced[4:]
+	}
+	return ro
+}
+
+func zeroE() field.ExtensionVariable {
+	return field.Felts2Ext(field.Zero(), field.Zero(), field.Zero(), field.Zero())
+}
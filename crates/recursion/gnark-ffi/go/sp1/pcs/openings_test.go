@@ -0,0 +1,85 @@
+package pcs
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+var logHeights = []int{3, 5, 3}
+var widths = []int{2, 1, 3}
+
+type reducedOpeningsCircuit struct {
+	Alpha, Z field.ExtensionVariable
+	X        [3]field.Variable
+	AtX      [3][]field.Variable
+	AtZ      [3][]field.ExtensionVariable
+}
+
+func (c *reducedOpeningsCircuit) Define(api frontend.API) error {
+	chip := babybear.NewChip(api)
+
+	openings := make([]MatrixOpening, len(logHeights))
+	for i := range openings {
+		openings[i] = MatrixOpening{LogHeight: logHeights[i], X: c.X[i], AtX: c.AtX[i], AtZ: c.AtZ[i]}
+	}
+	ro := ReducedOpenings(chip, c.Alpha, c.Z, openings)
+
+	// The column by column computation of the Rust verifier.
+	var expected [MaxLogHeight]field.ExtensionVariable
+	for h := range expected {
+		expected[h] = zeroE()
+	}
+	alphaPow := map[int]field.ExtensionVariable{}
+	for _, opening := range openings {
+		h := opening.LogHeight
+		if _, ok := alphaPow[h]; !ok {
+			alphaPow[h] = field.Felts2Ext(field.One(), field.Zero(), field.Zero(), field.Zero())
+		}
+		for j := range opening.AtX {
+			quotient := chip.DivE(chip.SubEF(opening.AtZ[j], opening.AtX[j]), chip.SubEF(c.Z, opening.X))
+			expected[h] = chip.AddE(expected[h], chip.MulE(alphaPow[h], quotient))
+			alphaPow[h] = chip.MulE(alphaPow[h], c.Alpha)
+		}
+	}
+	for h := range ro {
+		chip.AssertIsEqualE(ro[h], expected[h])
+	}
+	return nil
+}
+
+func newExt(seed int) field.ExtensionVariable {
+	value := make([]string, 4)
+	for i := range value {
+		value[i] = strconv.Itoa((seed*1000003 + i*7919) % 2013265921)
+	}
+	return field.NewE(value)
+}
+
+func newCircuit(seed int) reducedOpeningsCircuit {
+	c := reducedOpeningsCircuit{Alpha: newExt(seed + 1), Z: newExt(seed + 2)}
+	for i, width := range widths {
+		c.X[i] = field.NewF(strconv.Itoa(seed + 31*i + 5))
+		c.AtX[i] = make([]field.Variable, width)
+		c.AtZ[i] = make([]field.ExtensionVariable, width)
+		for j := 0; j < width; j++ {
+			c.AtX[i][j] = field.NewF(strconv.Itoa(seed*17 + 101*i + j))
+			c.AtZ[i][j] = newExt(seed + 10*i + j + 3)
+		}
+	}
+	return c
+}
+
+func TestReducedOpenings(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	circuit := newCircuit(0)
+	assignment := newCircuit(12345)
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
@@ -0,0 +1,35 @@
+package poseidon2
+
+import (
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+)
+
+// BABYBEAR_DIGEST_WIDTH is the number of elements of the digests of the Merkle trees of the inner
+// proof system.
+const BABYBEAR_DIGEST_WIDTH = 8
+
+// Compress hashes two digests into one with the TruncatedPermutation of Plonky3: it permutes the
+// concatenation of left and right and keeps the first half of the state. It only applies to the
+// width 16 chip of NewBabyBearChip.
+func (p *SmallFieldChip) Compress(left, right [BABYBEAR_DIGEST_WIDTH]babybear.Variable) [BABYBEAR_DIGEST_WIDTH]babybear.Variable {
+	var state [BABYBEAR_WIDTH]babybear.Variable
+	copy(state[:BABYBEAR_DIGEST_WIDTH], left[:])
+	copy(state[BABYBEAR_DIGEST_WIDTH:], right[:])
+	p.PermuteMut(&state)
+
+	var digest [BABYBEAR_DIGEST_WIDTH]babybear.Variable
+	copy(digest[:], state[:BABYBEAR_DIGEST_WIDTH])
+	return digest
+}
+
+// CompressBabyBear computes Compress natively, on canonical elements.
+func CompressBabyBear(left, right [BABYBEAR_DIGEST_WIDTH]uint32) [BABYBEAR_DIGEST_WIDTH]uint32 {
+	var state [BABYBEAR_WIDTH]uint32
+	copy(state[:BABYBEAR_DIGEST_WIDTH], left[:])
+	copy(state[BABYBEAR_DIGEST_WIDTH:], right[:])
+	state = PermuteBabyBear(state)
+
+	var digest [BABYBEAR_DIGEST_WIDTH]uint32
+	copy(digest[:], state[:BABYBEAR_DIGEST_WIDTH])
+	return digest
+}
@@ -2,6 +2,7 @@ package poseidon2
 
 import (
 	"github.com/consensys/gnark/frontend"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
 )
 
 const width = 3
@@ -35,27 +36,35 @@ func (p *Poseidon2Chip) PermuteMut(state *[width]frontend.Variable) {
 	rounds := numExternalRounds + numInternalRounds
 	rounds_f_beginning := numExternalRounds / 2
 	for r := 0; r < rounds_f_beginning; r++ {
-		p.addRc(state, rc3[r])
-		p.sbox(state)
-		p.matrixPermuteMut(state)
+		p.externalRound(state, r)
 	}
 
 	// The internal rounds.
 	p_end := rounds_f_beginning + numInternalRounds
 	for r := rounds_f_beginning; r < p_end; r++ {
-		state[0] = p.api.Add(state[0], rc3[r][0])
-		state[0] = p.sboxP(state[0])
-		p.diffusionPermuteMut(state)
+		p.internalRound(state, r)
 	}
 
 	// The second half of the external rounds.
 	for r := p_end; r < rounds; r++ {
-		p.addRc(state, rc3[r])
-		p.sbox(state)
-		p.matrixPermuteMut(state)
+		p.externalRound(state, r)
 	}
 }
 
+func (p *Poseidon2Chip) externalRound(state *[width]frontend.Variable, r int) {
+	defer field.Scope(p.api, "poseidon2.external_round")()
+	p.addRc(state, rc3[r])
+	p.sbox(state)
+	p.matrixPermuteMut(state)
+}
+
+func (p *Poseidon2Chip) internalRound(state *[width]frontend.Variable, r int) {
+	defer field.Scope(p.api, "poseidon2.internal_round")()
+	state[0] = p.api.Add(state[0], rc3[r][0])
+	state[0] = p.sboxP(state[0])
+	p.diffusionPermuteMut(state)
+}
+
 func (p *Poseidon2Chip) addRc(state *[width]frontend.Variable, rc [width]frontend.Variable) {
 	for i := 0; i < width; i++ {
 		state[i] = p.api.Add(state[i], rc[i])
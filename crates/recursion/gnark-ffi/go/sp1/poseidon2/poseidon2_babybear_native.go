@@ -0,0 +1,87 @@
+package poseidon2
+
+import (
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/constants"
+)
+
+const babybearModulus = 2013265921
+
+// PermuteBabyBear computes the BabyBear Poseidon2 permutation natively, on canonical elements. It
+// follows the definition of Plonky3 rather than the circuit, e.g. the internal layer is
+// (sum + diagM1[i] * x_i) * montyInverse, and serves as the reference of Poseidon2BabyBearChip
+// in tests and for computing hashes outside of the circuit.
+func PermuteBabyBear(state [BABYBEAR_WIDTH]uint32) [BABYBEAR_WIDTH]uint32 {
+	permuteBabyBearNative(
+		state[:], babybearNumInternalRounds,
+		roundConstantRows(constants.Poseidon2RoundConstants16[:]), constants.Poseidon2InternalDiagM1[:],
+	)
+	return state
+}
+
+// permuteBabyBearNative permutes state in place, with 8 external rounds like every BabyBear
+// permutation of Plonky3.
+func permuteBabyBearNative(state []uint32, numInternalRounds int, roundConstants [][]uint32, internalDiagM1 []uint32) {
+	s := make([]uint64, len(state))
+	for i := range state {
+		s[i] = uint64(state[i]) % babybearModulus
+	}
+
+	externalLinearLayerNative(s)
+	for r := 0; r < babybearNumExternalRounds+numInternalRounds; r++ {
+		rc := roundConstants[r]
+		if r < babybearNumExternalRounds/2 || r >= babybearNumExternalRounds/2+numInternalRounds {
+			for i := range s {
+				s[i] = sboxNative((s[i] + uint64(rc[i])) % babybearModulus)
+			}
+			externalLinearLayerNative(s)
+			continue
+		}
+		s[0] = sboxNative((s[0] + uint64(rc[0])) % babybearModulus)
+		internalLinearLayerNative(s, internalDiagM1)
+	}
+
+	for i := range s {
+		state[i] = uint32(s[i])
+	}
+}
+
+func sboxNative(x uint64) uint64 {
+	x2 := x * x % babybearModulus
+	x4 := x2 * x2 % babybearModulus
+	return x4 * x2 % babybearModulus * x % babybearModulus
+}
+
+// externalLinearLayerNative applies the 4x4 MDS matrix to each chunk of the state, then adds to
+// each element the sum of the elements at the same position in every chunk.
+func externalLinearLayerNative(state []uint64) {
+	for i := 0; i < len(state); i += 4 {
+		x := state[i : i+4]
+		t01 := x[0] + x[1]
+		t23 := x[2] + x[3]
+		t0123 := t01 + t23
+		t01123 := t0123 + x[1]
+		t01233 := t0123 + x[3]
+		x[3] = (t01233 + 2*x[0]) % babybearModulus
+		x[1] = (t01123 + 2*x[2]) % babybearModulus
+		x[0] = (t01123 + t01) % babybearModulus
+		x[2] = (t01233 + t23) % babybearModulus
+	}
+	var sums [4]uint64
+	for i := 0; i < len(state); i++ {
+		sums[i%4] += state[i]
+	}
+	for i := 0; i < len(state); i++ {
+		state[i] = (state[i] + sums[i%4]) % babybearModulus
+	}
+}
+
+func internalLinearLayerNative(state []uint64, internalDiagM1 []uint32) {
+	var sum uint64
+	for i := 0; i < len(state); i++ {
+		sum += state[i]
+	}
+	for i := 0; i < len(state); i++ {
+		diag := uint64(internalDiagM1[i])
+		state[i] = (sum%babybearModulus + diag*state[i]%babybearModulus) % babybearModulus * constants.MontyInverse % babybearModulus
+	}
+}
@@ -0,0 +1,58 @@
+package poseidon2
+
+import (
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// bn254InternalDiagonal is the diagonal of the internal linear layer minus the identity, the
+// internal_linear_layer of Poseidon2Chip.
+var bn254InternalDiagonal = [width]uint64{1, 1, 2}
+
+// PermuteBn254 computes the BN254 Poseidon2 permutation of Poseidon2Chip natively. It is the
+// reference of the chip in tests and computes the outer commitments outside of the circuit.
+func PermuteBn254(state [width]fr.Element) [width]fr.Element {
+	externalLinearLayerBn254(&state)
+	for r := 0; r < numExternalRounds+numInternalRounds; r++ {
+		if r < numExternalRounds/2 || r >= numExternalRounds/2+numInternalRounds {
+			for i := range state {
+				state[i].Add(&state[i], &rc3Native[r][i])
+				sboxBn254(&state[i])
+			}
+			externalLinearLayerBn254(&state)
+			continue
+		}
+		state[0].Add(&state[0], &rc3Native[r][0])
+		sboxBn254(&state[0])
+		internalLinearLayerBn254(&state)
+	}
+	return state
+}
+
+func sboxBn254(x *fr.Element) {
+	var x2, x4 fr.Element
+	x2.Square(x)
+	x4.Square(&x2)
+	x.Mul(x, &x4)
+}
+
+func externalLinearLayerBn254(state *[width]fr.Element) {
+	var sum fr.Element
+	for i := range state {
+		sum.Add(&sum, &state[i])
+	}
+	for i := range state {
+		state[i].Add(&state[i], &sum)
+	}
+}
+
+func internalLinearLayerBn254(state *[width]fr.Element) {
+	var sum fr.Element
+	for i := range state {
+		sum.Add(&sum, &state[i])
+	}
+	for i := range state {
+		var diagonal fr.Element
+		diagonal.SetUint64(bn254InternalDiagonal[i])
+		state[i].Mul(&state[i], &diagonal).Add(&state[i], &sum)
+	}
+}
@@ -0,0 +1,237 @@
+package poseidon2
+
+import (
+	"math/big"
+	"math/bits"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+const SMALL_FIELD_WIDTH = 16
+
+// SmallFieldParams are the parameters of a Poseidon2 permutation over a small field, with the
+// internal layer of Plonky3 that works on Montgomery representations. The width is a multiple of 4,
+// e.g. SMALL_FIELD_WIDTH.
+type SmallFieldParams struct {
+	Width             int
+	NumExternalRounds int
+	NumInternalRounds int
+	SboxDegree        uint64
+
+	// RoundConstants holds the constants of the first external, internal and last external rounds,
+	// in order. Internal rounds only use the first constant.
+	RoundConstants [][]int
+
+	// InternalDiag is the diagonal of the internal matrix minus the identity, times MontyInverse.
+	InternalDiag []int
+	MontyInverse int
+}
+
+// NewSmallFieldParams derives the parameters of the circuit from the ones of Plonky3. The width is
+// the one of internalDiagM1.
+func NewSmallFieldParams(
+	params field.FieldParams,
+	numExternalRounds, numInternalRounds int,
+	sboxDegree uint64,
+	roundConstants [][]uint32,
+	internalDiagM1 []uint32,
+) *SmallFieldParams {
+	width := len(internalDiagM1)
+	if width == 0 || width%4 != 0 {
+		panic("the width must be a positive multiple of 4")
+	}
+	if len(roundConstants) < numExternalRounds+numInternalRounds {
+		panic("missing round constants")
+	}
+	modulus := params.Modulus()
+	montyInverse := params.MontyInverse()
+
+	p := &SmallFieldParams{
+		Width:             width,
+		NumExternalRounds: numExternalRounds,
+		NumInternalRounds: numInternalRounds,
+		SboxDegree:        sboxDegree,
+		RoundConstants:    make([][]int, len(roundConstants)),
+		InternalDiag:      make([]int, width),
+		MontyInverse:      int(montyInverse.Int64()),
+	}
+	for r, rc := range roundConstants {
+		if len(rc) != width {
+			panic("the round constants do not match the width")
+		}
+		p.RoundConstants[r] = make([]int, width)
+		for i := range rc {
+			p.RoundConstants[r][i] = int(rc[i])
+		}
+	}
+	for i, d := range internalDiagM1 {
+		diag := new(big.Int).Mul(new(big.Int).SetUint64(uint64(d)), montyInverse)
+		p.InternalDiag[i] = int(diag.Mod(diag, modulus).Int64())
+	}
+	return p
+}
+
+// SmallFieldChip computes a Poseidon2 permutation over any small field, of the width of its
+// parameters.
+type SmallFieldChip struct {
+	api      frontend.API
+	fieldApi field.SmallField
+	params   *SmallFieldParams
+
+	// FastReduction reduces the inputs and outputs of the S-boxes with ReduceFast rather than to
+	// canonical values, which takes fewer constraints.
+	FastReduction bool
+}
+
+// NewSmallFieldChip returns a chip computing the permutation with fieldApi, which should be strict:
+// the permutation places its reductions by hand, which the strict mode checks. FastReduction is
+// enabled when fieldApi uses the aggressive reduction strategy.
+func NewSmallFieldChip(api frontend.API, fieldApi field.SmallField, params *SmallFieldParams) *SmallFieldChip {
+	return &SmallFieldChip{
+		api:           api,
+		fieldApi:      fieldApi,
+		params:        params,
+		FastReduction: fieldApi.ReductionStrategy() == field.ReductionAggressiveUnsafe,
+	}
+}
+
+func (p *SmallFieldChip) PermuteMut(state *[SMALL_FIELD_WIDTH]field.Variable) {
+	p.Permute(state[:])
+}
+
+// Permute permutes state in place. It must have the width of the parameters of the chip.
+func (p *SmallFieldChip) Permute(state []field.Variable) {
+	if len(state) != p.params.Width {
+		panic("the state does not have the width of the permutation")
+	}
+
+	// The initial linear layer.
+	p.externalLinearLayer(state)
+
+	// The first half of the external rounds.
+	rounds := p.params.NumExternalRounds + p.params.NumInternalRounds
+	roundsFBeginning := p.params.NumExternalRounds / 2
+	for r := 0; r < roundsFBeginning; r++ {
+		p.externalRound(state, r)
+	}
+
+	// The internal rounds.
+	p_end := roundsFBeginning + p.params.NumInternalRounds
+	for r := roundsFBeginning; r < p_end; r++ {
+		p.internalRound(state, r)
+	}
+
+	// The second half of the external rounds.
+	for r := p_end; r < rounds; r++ {
+		p.externalRound(state, r)
+	}
+}
+
+func (p *SmallFieldChip) externalRound(state []field.Variable, r int) {
+	defer field.Scope(p.api, "poseidon2.external_round")()
+	p.addRc(state, p.params.RoundConstants[r])
+	p.sbox(state)
+	p.externalLinearLayer(state)
+}
+
+func (p *SmallFieldChip) internalRound(state []field.Variable, r int) {
+	defer field.Scope(p.api, "poseidon2.internal_round")()
+	state[0] = p.fieldApi.AddFConst(state[0], p.params.RoundConstants[r][0])
+	state[0] = p.sboxP(state[0])
+	p.diffusionPermuteMut(state)
+}
+
+func (p *SmallFieldChip) addRc(state []field.Variable, rc []int) {
+	for i := 0; i < len(state); i++ {
+		state[i] = p.fieldApi.AddFConst(state[i], rc[i])
+	}
+}
+
+// sboxP computes x^d with a single reduction: (p - 1)^d fits in the native field, so the
+// intermediate products are left unreduced.
+func (p *SmallFieldChip) sboxP(input field.Variable) field.Variable {
+	if p.FastReduction {
+		return p.fieldApi.ReduceFast(p.pow(p.fieldApi.ReduceFast(input)))
+	}
+	x := p.fieldApi.ReduceSlow(input)
+	return p.fieldApi.ReduceSlow(p.pow(x))
+}
+
+// sbox applies sboxP to the whole state, batching the reductions of the elements.
+func (p *SmallFieldChip) sbox(state []field.Variable) {
+	if p.FastReduction {
+		for i := range state {
+			state[i] = p.sboxP(state[i])
+		}
+		return
+	}
+	xs := p.fieldApi.ReduceBatch(state)
+	for i, x := range xs {
+		xs[i] = p.pow(x)
+	}
+	copy(state, p.fieldApi.ReduceBatch(xs))
+}
+
+// pow computes x^d without reducing, by square and multiply from the most significant bit of d.
+func (p *SmallFieldChip) pow(x field.Variable) field.Variable {
+	d := p.params.SboxDegree
+	result := x
+	for i := bits.Len64(d) - 2; i >= 0; i-- {
+		result = p.fieldApi.MulF(result, result, false)
+		if d>>uint(i)&1 == 1 {
+			result = p.fieldApi.MulF(result, x, false)
+		}
+	}
+	return result
+}
+
+func (p *SmallFieldChip) mdsLightPermutation4x4(state []field.Variable) {
+	t01 := p.fieldApi.AddF(state[0], state[1])
+	t23 := p.fieldApi.AddF(state[2], state[3])
+	t0123 := p.fieldApi.AddF(t01, t23)
+	t01123 := p.fieldApi.AddF(t0123, state[1])
+	t01233 := p.fieldApi.AddF(t0123, state[3])
+	state[3] = p.fieldApi.AddF(t01233, p.fieldApi.MulFConst(state[0], 2))
+	state[1] = p.fieldApi.AddF(t01123, p.fieldApi.MulFConst(state[2], 2))
+	state[0] = p.fieldApi.AddF(t01123, t01)
+	state[2] = p.fieldApi.AddF(t01233, t23)
+}
+
+func (p *SmallFieldChip) externalLinearLayer(state []field.Variable) {
+	for i := 0; i < len(state); i += 4 {
+		p.mdsLightPermutation4x4(state[i : i+4])
+	}
+
+	sums := [4]field.Variable{
+		state[0],
+		state[1],
+		state[2],
+		state[3],
+	}
+	for i := 4; i < len(state); i += 4 {
+		sums[0] = p.fieldApi.AddF(sums[0], state[i])
+		sums[1] = p.fieldApi.AddF(sums[1], state[i+1])
+		sums[2] = p.fieldApi.AddF(sums[2], state[i+2])
+		sums[3] = p.fieldApi.AddF(sums[3], state[i+3])
+	}
+
+	for i := 0; i < len(state); i++ {
+		state[i] = p.fieldApi.AddF(state[i], sums[i%4])
+	}
+}
+
+// diffusionPermuteMut applies the internal linear layer, state[i] = (sum + diagM1[i] * state[i]) *
+// montyInverse, as sum * montyInverse + internalDiag[i] * state[i] with the Montgomery factor folded
+// into the constants, so that it only takes constant multiplications.
+func (p *SmallFieldChip) diffusionPermuteMut(state []field.Variable) {
+	sum := state[0]
+	for i := 1; i < len(state); i++ {
+		sum = p.fieldApi.AddF(sum, state[i])
+	}
+	sum = p.fieldApi.MulFConst(sum, p.params.MontyInverse)
+
+	for i := 0; i < len(state); i++ {
+		state[i] = p.fieldApi.AddF(p.fieldApi.MulFConst(state[i], p.params.InternalDiag[i]), sum)
+	}
+}
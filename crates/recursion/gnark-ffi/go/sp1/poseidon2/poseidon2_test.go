@@ -1,12 +1,20 @@
 package poseidon2
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/constants"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/koalabear"
 )
 
 type TestPoseidon2Circuit struct {
@@ -50,3 +58,387 @@ func TestPoseidon2(t *testing.T) {
 	witness = TestPoseidon2Circuit{Input: input, ExpectedOutput: expected_output}
 	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
 }
+
+func TestPermuteBn254(t *testing.T) {
+	expected := [width]string{
+		"0x2ED1DA00B14D635BD35B88AB49390D5C13C90DA7E9E3A5F1EA69CD87A0AA3E82",
+		"0x1E21E979CC3FD844B88C2016FD18F4DB07A698AA27DECA67CA509F5B0A4480D0",
+		"0x2C40D0115DA2C9B55553B231BE55295F411E628ED0CD0E187917066515F0A060",
+	}
+	output := PermuteBn254([width]fr.Element{})
+	for i := range output {
+		var e fr.Element
+		e.SetString(expected[i])
+		if !output[i].Equal(&e) {
+			t.Fatalf("unexpected permutation of zero: %v", output)
+		}
+	}
+}
+
+func TestPoseidon2Bn254(t *testing.T) {
+	assert := test.NewAssert(t)
+	var input [width]fr.Element
+	for i := range input {
+		input[i].SetRandom()
+	}
+	output := PermuteBn254(input)
+
+	var witness TestPoseidon2Circuit
+	for i := range input {
+		witness.Input[i] = input[i].String()
+		witness.ExpectedOutput[i] = output[i].String()
+	}
+	assert.ProverSucceeded(&TestPoseidon2Circuit{}, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type TestPoseidon2BabyBearCircuit struct {
+	Input, ExpectedOutput [BABYBEAR_WIDTH]babybear.Variable
+
+	fastReduction bool
+}
+
+func (circuit *TestPoseidon2BabyBearCircuit) Define(api frontend.API) error {
+	poseidon2Chip := NewBabyBearChip(api)
+	poseidon2Chip.FastReduction = circuit.fastReduction
+	fieldApi := babybear.NewChip(api)
+
+	state := circuit.Input
+	poseidon2Chip.PermuteMut(&state)
+
+	for i := 0; i < BABYBEAR_WIDTH; i++ {
+		fieldApi.AssertIsEqualF(circuit.ExpectedOutput[i], state[i])
+	}
+
+	return nil
+}
+
+// TestPermuteBabyBear checks the native reference against the output of Plonky3 for the zero
+// state, the same vector as TestPoseidonBabyBear2.
+func TestPermuteBabyBear(t *testing.T) {
+	expected := [BABYBEAR_WIDTH]uint32{
+		348670919, 1568590631, 1535107508, 186917780, 587749971, 1827585060, 1218809104, 691692291,
+		1480664293, 1491566329, 366224457, 490018300, 732772134, 560796067, 484676252, 405025962,
+	}
+	if output := PermuteBabyBear([BABYBEAR_WIDTH]uint32{}); output != expected {
+		t.Fatalf("unexpected permutation of zero: %v", output)
+	}
+}
+
+func TestPoseidon2BabyBear(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var input [BABYBEAR_WIDTH]uint32
+	for i := range input {
+		input[i] = uint32(uint64(i) * 123456789 % babybearModulus)
+	}
+	output := PermuteBabyBear(input)
+
+	var circuit, witness TestPoseidon2BabyBearCircuit
+	for i := 0; i < BABYBEAR_WIDTH; i++ {
+		circuit.Input[i] = babybear.NewF("0")
+		circuit.ExpectedOutput[i] = babybear.NewF("0")
+		witness.Input[i] = babybear.NewF(strconv.FormatUint(uint64(input[i]), 10))
+		witness.ExpectedOutput[i] = babybear.NewF(strconv.FormatUint(uint64(output[i]), 10))
+	}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	witness.ExpectedOutput[0] = babybear.NewF(strconv.FormatUint((uint64(output[0])+1)%babybearModulus, 10))
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+func TestPoseidon2BabyBearFastReduction(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var input [BABYBEAR_WIDTH]uint32
+	for i := range input {
+		input[i] = uint32(uint64(i) * 987654321 % babybearModulus)
+	}
+	output := PermuteBabyBear(input)
+
+	circuit := TestPoseidon2BabyBearCircuit{fastReduction: true}
+	var witness TestPoseidon2BabyBearCircuit
+	for i := 0; i < BABYBEAR_WIDTH; i++ {
+		circuit.Input[i] = babybear.NewF("0")
+		circuit.ExpectedOutput[i] = babybear.NewF("0")
+		witness.Input[i] = babybear.NewF(strconv.FormatUint(uint64(input[i]), 10))
+		witness.ExpectedOutput[i] = babybear.NewF(strconv.FormatUint(uint64(output[i]), 10))
+	}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	witness.ExpectedOutput[3] = babybear.NewF(strconv.FormatUint((uint64(output[3])+1)%babybearModulus, 10))
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// The fast reductions must pay for themselves, on both backends.
+	for _, builder := range []frontend.NewBuilder{scs.NewBuilder, r1cs.NewBuilder} {
+		slow := circuit
+		slow.fastReduction = false
+		slowCs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &slow)
+		assert.NoError(err)
+		fastCs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &circuit)
+		assert.NoError(err)
+		t.Logf("%d constraints with ReduceSlow, %d with ReduceFast", slowCs.GetNbConstraints(), fastCs.GetNbConstraints())
+		if fastCs.GetNbConstraints() >= slowCs.GetNbConstraints() {
+			t.Errorf("ReduceFast does not save constraints")
+		}
+	}
+}
+
+type TestSpongeCircuit struct {
+	Input          [14]babybear.Variable
+	ExpectedOutput [12]babybear.Variable
+}
+
+func (circuit *TestSpongeCircuit) Define(api frontend.API) error {
+	sponge := NewSponge(api)
+	fieldApi := babybear.NewChip(api)
+
+	sponge.Absorb(circuit.Input[:11])
+	output := sponge.Squeeze(10)
+	sponge.Absorb(circuit.Input[11:])
+	output = append(output, sponge.Squeeze(2)...)
+
+	for i := range output {
+		fieldApi.AssertIsEqualF(circuit.ExpectedOutput[i], output[i])
+	}
+	return nil
+}
+
+// spongeNative absorbs and squeezes like Poseidon2Sponge for blocks of whole calls.
+type spongeNative struct {
+	state  [BABYBEAR_WIDTH]uint32
+	input  []uint32
+	output []uint32
+}
+
+func (s *spongeNative) absorb(xs []uint32) {
+	s.output = nil
+	s.input = append(s.input, xs...)
+	for len(s.input) >= BABYBEAR_RATE {
+		copy(s.state[:], s.input[:BABYBEAR_RATE])
+		s.input = s.input[BABYBEAR_RATE:]
+		s.state = PermuteBabyBear(s.state)
+	}
+}
+
+func (s *spongeNative) squeeze(n int) []uint32 {
+	if s.output == nil {
+		block := append(append([]uint32(nil), s.input...), 1)
+		for len(block) < BABYBEAR_RATE {
+			block = append(block, 0)
+		}
+		s.input = nil
+		copy(s.state[:], block)
+		s.state = PermuteBabyBear(s.state)
+		s.output = append([]uint32{}, s.state[:BABYBEAR_RATE]...)
+	}
+	var result []uint32
+	for len(result) < n {
+		if len(s.output) == 0 {
+			s.state = PermuteBabyBear(s.state)
+			s.output = append([]uint32{}, s.state[:BABYBEAR_RATE]...)
+		}
+		result = append(result, s.output[0])
+		s.output = s.output[1:]
+	}
+	return result
+}
+
+func TestSponge(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var input [14]uint32
+	for i := range input {
+		input[i] = uint32(uint64(i+1) * 123456789 % babybearModulus)
+	}
+	var native spongeNative
+	native.absorb(input[:11])
+	output := native.squeeze(10)
+	native.absorb(input[11:])
+	output = append(output, native.squeeze(2)...)
+
+	var circuit, witness TestSpongeCircuit
+	for i := range input {
+		circuit.Input[i] = babybear.NewF("0")
+		witness.Input[i] = babybear.NewF(strconv.FormatUint(uint64(input[i]), 10))
+	}
+	for i := range output {
+		circuit.ExpectedOutput[i] = babybear.NewF("0")
+		witness.ExpectedOutput[i] = babybear.NewF(strconv.FormatUint(uint64(output[i]), 10))
+	}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// Without padding, an input of 8 elements would absorb the same block as the 7 first ones
+	// followed by a zero.
+	var short, long spongeNative
+	short.absorb(input[:7])
+	long.absorb(append(append([]uint32{}, input[:7]...), 0))
+	if short.squeeze(1)[0] == long.squeeze(1)[0] {
+		t.Error("the padding does not separate inputs ending with zeros")
+	}
+}
+
+type TestCompressCircuit struct {
+	Left, Right, ExpectedDigest [BABYBEAR_DIGEST_WIDTH]babybear.Variable
+}
+
+func (circuit *TestCompressCircuit) Define(api frontend.API) error {
+	fieldApi := babybear.NewChip(api)
+	digest := NewBabyBearChip(api).Compress(circuit.Left, circuit.Right)
+	for i := range digest {
+		fieldApi.AssertIsEqualF(circuit.ExpectedDigest[i], digest[i])
+	}
+	return nil
+}
+
+func TestCompress(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	var left, right [BABYBEAR_DIGEST_WIDTH]uint32
+	for i := range left {
+		left[i] = uint32(uint64(i+1) * 123456789 % babybearModulus)
+		right[i] = uint32(uint64(i+1) * 987654321 % babybearModulus)
+	}
+	digest := CompressBabyBear(left, right)
+	if digest == CompressBabyBear(right, left) {
+		t.Fatal("the compression is symmetric")
+	}
+
+	var circuit, witness TestCompressCircuit
+	for i := 0; i < BABYBEAR_DIGEST_WIDTH; i++ {
+		circuit.Left[i] = babybear.NewF("0")
+		circuit.Right[i] = babybear.NewF("0")
+		circuit.ExpectedDigest[i] = babybear.NewF("0")
+		witness.Left[i] = babybear.NewF(strconv.FormatUint(uint64(left[i]), 10))
+		witness.Right[i] = babybear.NewF(strconv.FormatUint(uint64(right[i]), 10))
+		witness.ExpectedDigest[i] = babybear.NewF(strconv.FormatUint(uint64(digest[i]), 10))
+	}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	witness.Left[0], witness.Right[0] = witness.Right[0], witness.Left[0]
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
+
+type TestPoseidon2SmallFieldCircuit struct {
+	Input, ExpectedOutput [SMALL_FIELD_WIDTH]field.Variable
+
+	params *SmallFieldParams
+}
+
+func (circuit *TestPoseidon2SmallFieldCircuit) Define(api frontend.API) error {
+	fieldApi := koalabear.NewChip(api)
+	fieldApi.Strict = true
+	state := circuit.Input
+	NewSmallFieldChip(api, fieldApi, circuit.params).PermuteMut(&state)
+	for i := range state {
+		fieldApi.AssertIsEqualF(circuit.ExpectedOutput[i], state[i])
+	}
+	return nil
+}
+
+// permuteSmallFieldNative permutes state like Plonky3 over the field of the given modulus, from the
+// definition of the rounds rather than the circuit.
+func permuteSmallFieldNative(modulus, montyInverse, sboxDegree uint64, numExternalRounds, numInternalRounds int, roundConstants [][]uint32, internalDiagM1 []uint32, state []uint64) {
+	sbox := func(x uint64) uint64 {
+		result := uint64(1)
+		for i := uint64(0); i < sboxDegree; i++ {
+			result = result * x % modulus
+		}
+		return result
+	}
+	externalLayer := func() {
+		mds := [4][4]uint64{{2, 3, 1, 1}, {1, 2, 3, 1}, {1, 1, 2, 3}, {3, 1, 1, 2}}
+		for i := 0; i < len(state); i += 4 {
+			var chunk [4]uint64
+			for j := range chunk {
+				for k := range chunk {
+					chunk[j] = (chunk[j] + mds[j][k]*state[i+k]) % modulus
+				}
+			}
+			copy(state[i:i+4], chunk[:])
+		}
+		var sums [4]uint64
+		for i := range state {
+			sums[i%4] = (sums[i%4] + state[i]) % modulus
+		}
+		for i := range state {
+			state[i] = (state[i] + sums[i%4]) % modulus
+		}
+	}
+
+	externalLayer()
+	for r, rc := range roundConstants[:numExternalRounds+numInternalRounds] {
+		if r >= numExternalRounds/2 && r < numExternalRounds/2+numInternalRounds {
+			state[0] = sbox((state[0] + uint64(rc[0])) % modulus)
+			var sum uint64
+			for i := range state {
+				sum = (sum + state[i]) % modulus
+			}
+			for i := range state {
+				state[i] = (sum + uint64(internalDiagM1[i])*state[i]) % modulus * montyInverse % modulus
+			}
+			continue
+		}
+		for i := range state {
+			state[i] = sbox((state[i] + uint64(rc[i])) % modulus)
+		}
+		externalLayer()
+	}
+}
+
+func TestPoseidon2KoalaBear(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// The reference agrees with the BabyBear permutation.
+	var babybearState [BABYBEAR_WIDTH]uint32
+	native := make([]uint64, BABYBEAR_WIDTH)
+	for i := range babybearState {
+		babybearState[i] = uint32(i)
+		native[i] = uint64(i)
+	}
+	permuteSmallFieldNative(
+		babybearModulus, constants.MontyInverse, babybearSboxDegree, babybearNumExternalRounds, babybearNumInternalRounds,
+		roundConstantRows(constants.Poseidon2RoundConstants16[:]), constants.Poseidon2InternalDiagM1[:], native,
+	)
+	for i, x := range PermuteBabyBear(babybearState) {
+		if native[i] != uint64(x) {
+			t.Fatalf("the reference permutation differs from PermuteBabyBear at %d", i)
+		}
+	}
+
+	// The KoalaBear permutation of Plonky3 has 8 external and 20 internal rounds, with x^3 as
+	// S-box. The constants are arbitrary.
+	modulus := koalabear.Params{}.Modulus().Uint64()
+	roundConstants := make([][]uint32, 28)
+	for r := range roundConstants {
+		roundConstants[r] = make([]uint32, SMALL_FIELD_WIDTH)
+		for i := range roundConstants[r] {
+			roundConstants[r][i] = uint32((uint64(r)*SMALL_FIELD_WIDTH + uint64(i) + 1) * 2654435761 % modulus)
+		}
+	}
+	internalDiagM1 := make([]uint32, SMALL_FIELD_WIDTH)
+	for i := range internalDiagM1 {
+		internalDiagM1[i] = uint32(1) << i
+	}
+	internalDiagM1[0] = uint32(modulus - 2)
+	params := NewSmallFieldParams(koalabear.Params{}, 8, 20, 3, roundConstants, internalDiagM1)
+
+	state := make([]uint64, SMALL_FIELD_WIDTH)
+	for i := range state {
+		state[i] = uint64(i) * 123456789 % modulus
+	}
+	circuit := TestPoseidon2SmallFieldCircuit{params: params}
+	witness := TestPoseidon2SmallFieldCircuit{params: params}
+	for i := range state {
+		circuit.Input[i] = koalabear.NewF("0")
+		circuit.ExpectedOutput[i] = koalabear.NewF("0")
+		witness.Input[i] = koalabear.NewF(strconv.FormatUint(state[i], 10))
+	}
+	permuteSmallFieldNative(modulus, koalabear.Params{}.MontyInverse().Uint64(), 3, 8, 20, roundConstants, internalDiagM1, state)
+	for i := range state {
+		witness.ExpectedOutput[i] = koalabear.NewF(strconv.FormatUint(state[i], 10))
+	}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	witness.ExpectedOutput[0] = koalabear.NewF(strconv.FormatUint((state[0]+1)%modulus, 10))
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+}
@@ -0,0 +1,79 @@
+package poseidon2
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+)
+
+// BABYBEAR_RATE is the number of elements a Poseidon2Sponge absorbs or squeezes per permutation,
+// the rate of the DuplexChallenger of Plonky3. The other half of the state is the capacity.
+const BABYBEAR_RATE = 8
+
+// Poseidon2Sponge hashes BabyBear elements with the width 16 permutation. Absorbed elements
+// overwrite the rate part of the state, like in the DuplexChallenger, a rate block at a time. The
+// first Squeeze after absorbing pads the last block with a one and zeros, so that inputs of
+// different lengths, including empty ones, never absorb the same blocks. Absorbing after
+// squeezing drops the elements left to squeeze and starts a new block.
+type Poseidon2Sponge struct {
+	chip   *Poseidon2BabyBearChip
+	state  [BABYBEAR_WIDTH]babybear.Variable
+	input  []babybear.Variable
+	output []babybear.Variable
+	// squeezing is set once the absorbed elements are padded, until the next Absorb.
+	squeezing bool
+}
+
+// NewSponge returns a sponge with an all-zero state.
+func NewSponge(api frontend.API) *Poseidon2Sponge {
+	s := &Poseidon2Sponge{chip: NewBabyBearChip(api)}
+	for i := range s.state {
+		s.state[i] = babybear.Zero()
+	}
+	return s
+}
+
+// Absorb absorbs xs, permuting the state for every full rate block.
+func (s *Poseidon2Sponge) Absorb(xs []babybear.Variable) {
+	if s.squeezing {
+		s.squeezing = false
+		s.output = nil
+	}
+	for _, x := range xs {
+		s.input = append(s.input, x)
+		if len(s.input) == BABYBEAR_RATE {
+			s.absorbBlock()
+		}
+	}
+}
+
+// Squeeze returns the next n elements of the output, permuting the state whenever the rate part
+// is used up.
+func (s *Poseidon2Sponge) Squeeze(n int) []babybear.Variable {
+	if !s.squeezing {
+		// 10* padding: a one, then zeros up to the rate.
+		s.input = append(s.input, babybear.One())
+		for len(s.input) < BABYBEAR_RATE {
+			s.input = append(s.input, babybear.Zero())
+		}
+		s.absorbBlock()
+		s.squeezing = true
+		s.output = append([]babybear.Variable(nil), s.state[:BABYBEAR_RATE]...)
+	}
+
+	result := make([]babybear.Variable, 0, n)
+	for len(result) < n {
+		if len(s.output) == 0 {
+			s.chip.PermuteMut(&s.state)
+			s.output = append([]babybear.Variable(nil), s.state[:BABYBEAR_RATE]...)
+		}
+		result = append(result, s.output[0])
+		s.output = s.output[1:]
+	}
+	return result
+}
+
+func (s *Poseidon2Sponge) absorbBlock() {
+	copy(s.state[:BABYBEAR_RATE], s.input)
+	s.input = s.input[:0]
+	s.chip.PermuteMut(&s.state)
+}
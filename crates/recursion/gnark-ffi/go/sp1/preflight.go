@@ -0,0 +1,165 @@
+package sp1
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+)
+
+// Preflight error categories.
+const (
+	PreflightArtifacts = "artifacts"
+	PreflightWitness   = "witness"
+	PreflightVersion   = "version"
+	PreflightResources = "resources"
+)
+
+// PreflightError is returned by Preflight. Category is one of the Preflight* constants.
+type PreflightError struct {
+	Category string
+	Err      error
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("preflight %s check failed: %v", e.Category, e.Err)
+}
+
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+func preflightError(category string, format string, args ...any) error {
+	return &PreflightError{Category: category, Err: fmt.Errorf(format, args...)}
+}
+
+// Preflight runs the cheap checks of a prove before any key is loaded: the artifacts exist, the
+// witness is well formed and was generated for these constraints, the vkey was built for the
+// circuit version in SP1_CIRCUIT_VERSION (when both it and vkeys.json are present), and the
+// proving key fits in the available memory unless it is already loaded (keyLoaded).
+func Preflight(dataDir string, witnessPath string, circuitPath string, pkPath string, vkPath string, keyLoaded bool) error {
+	var pkSize int64
+	for _, path := range []string{constraintsJsonFile, circuitPath, pkPath, vkPath} {
+		info, err := os.Stat(dataDir + "/" + path)
+		if err != nil {
+			return preflightError(PreflightArtifacts, "%w", err)
+		}
+		if path == pkPath {
+			pkSize = info.Size()
+		}
+	}
+
+	if err := preflightWitness(dataDir, witnessPath); err != nil {
+		return err
+	}
+	if err := preflightVersion(dataDir, vkPath); err != nil {
+		return err
+	}
+
+	if !keyLoaded {
+		available, err := availableMemory()
+		if err == nil && available < pkSize {
+			return preflightError(PreflightResources, "proving key needs %d bytes but only %d are available", pkSize, available)
+		}
+	}
+	return nil
+}
+
+func preflightWitness(dataDir string, witnessPath string) error {
+	data, err := os.ReadFile(witnessPath)
+	if err != nil {
+		return preflightError(PreflightWitness, "%w", err)
+	}
+	var witnessInput WitnessInput
+	if err := json.Unmarshal(data, &witnessInput); err != nil {
+		return preflightError(PreflightWitness, "%w", err)
+	}
+
+	bn254Modulus := ecc.BN254.ScalarField()
+	checkNative := func(name string, value string) error {
+		v, ok := new(big.Int).SetString(value, 10)
+		if !ok || v.Sign() < 0 || v.Cmp(bn254Modulus) >= 0 {
+			return preflightError(PreflightWitness, "invalid %s %q", name, value)
+		}
+		return nil
+	}
+	if err := checkNative("vkey hash", witnessInput.VkeyHash); err != nil {
+		return err
+	}
+	if err := checkNative("committed values digest", witnessInput.CommittedValuesDigest); err != nil {
+		return err
+	}
+	for i, value := range witnessInput.Vars {
+		if err := checkNative(fmt.Sprintf("var %d", i), value); err != nil {
+			return err
+		}
+	}
+	for i, value := range witnessInput.Felts {
+		if _, err := babybear.ParseF(value); err != nil {
+			return preflightError(PreflightWitness, "felt %d: %w", i, err)
+		}
+	}
+	for i, value := range witnessInput.Exts {
+		if _, err := babybear.ParseExt(value); err != nil {
+			return preflightError(PreflightWitness, "ext %d: %w", i, err)
+		}
+	}
+
+	constraints, err := os.ReadFile(dataDir + "/" + constraintsJsonFile)
+	if err != nil {
+		return preflightError(PreflightArtifacts, "%w", err)
+	}
+	if witnessInput.ShapeDigest != ShapeDigest(constraints) {
+		return preflightError(PreflightWitness, "witness was generated for other constraints (shape digest %q)", witnessInput.ShapeDigest)
+	}
+	return nil
+}
+
+func preflightVersion(dataDir string, vkPath string) error {
+	version := os.Getenv("SP1_CIRCUIT_VERSION")
+	if version == "" {
+		return nil
+	}
+	if _, err := os.Stat(dataDir + "/" + vkeyRegistryPath); err != nil {
+		return nil
+	}
+	registry, err := ReadVkeyRegistry(dataDir + "/" + vkeyRegistryPath)
+	if err != nil {
+		return preflightError(PreflightVersion, "%w", err)
+	}
+	vkeyHash, err := fileDigest(dataDir + "/" + vkPath)
+	if err != nil {
+		return preflightError(PreflightArtifacts, "%w", err)
+	}
+	if !registry.IsAccepted(version, "0x"+vkeyHash) && !registry.IsAccepted(version+"-dev-insecure", "0x"+vkeyHash) {
+		return preflightError(PreflightVersion, "vkey 0x%s was built for %v, not %s", vkeyHash, registry.Versions("0x"+vkeyHash), version)
+	}
+	return nil
+}
+
+// availableMemory returns MemAvailable from /proc/meminfo, in bytes.
+func availableMemory() (int64, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+			kib, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return kib * 1024, nil
+		}
+	}
+	return 0, fmt.Errorf("MemAvailable not found in /proc/meminfo")
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 )
@@ -22,39 +24,25 @@ var globalPk groth16.ProvingKey = groth16.NewProvingKey(ecc.BN254)
 var globalPkInitialized = false
 
 func ProvePlonk(dataDir string, witnessPath string) Proof {
+	audit := startAudit("prove", "plonk")
+	defer audit.finishOnPanic()
+
 	// Sanity check the required arguments have been provided.
 	if dataDir == "" {
 		panic("dataDirStr is required")
 	}
-	os.Setenv("CONSTRAINTS_JSON", dataDir+"/"+constraintsJsonFile)
-
-	// Read the R1CS.
-	scsFile, err := os.Open(dataDir + "/" + plonkCircuitPath)
-	if err != nil {
+	plonkMutex.Lock()
+	keyLoaded := plonkLoaded != nil && plonkLoaded.dataDir == dataDir
+	plonkMutex.Unlock()
+	if err := Preflight(dataDir, witnessPath, plonkCircuitPath, plonkPkPath, plonkVkPath, keyLoaded); err != nil {
 		panic(err)
 	}
-	scs := plonk.NewCS(ecc.BN254)
-	scs.ReadFrom(scsFile)
-	defer scsFile.Close()
-
-	// Read the proving key.
-	pkFile, err := os.Open(dataDir + "/" + plonkPkPath)
-	if err != nil {
-		panic(err)
-	}
-	pk := plonk.NewProvingKey(ecc.BN254)
-	bufReader := bufio.NewReaderSize(pkFile, 1024*1024)
-	pk.UnsafeReadFrom(bufReader)
-	defer pkFile.Close()
+	warnTightMemory(provingKeyMemory(dataDir, plonkPkPath, keyLoaded))
+	os.Setenv("CONSTRAINTS_JSON", dataDir+"/"+constraintsJsonFile)
 
-	// Read the verifier key.
-	vkFile, err := os.Open(dataDir + "/" + plonkVkPath)
-	if err != nil {
-		panic(err)
-	}
-	vk := plonk.NewVerifyingKey(ecc.BN254)
-	vk.ReadFrom(vkFile)
-	defer vkFile.Close()
+	// Read the constraint system and the keys, unless a previous proof already did.
+	artifacts := loadPlonkArtifacts(dataDir)
+	scs, pk, vk := artifacts.scs, artifacts.pk, artifacts.vk
 
 	// Read the file.
 	data, err := os.ReadFile(witnessPath)
@@ -68,6 +56,9 @@ func ProvePlonk(dataDir string, witnessPath string) Proof {
 	if err != nil {
 		panic(err)
 	}
+	audit.WitnessDigest = digest(data)
+	audit.VkeyHash = witnessInput.VkeyHash
+	audit.CommittedValuesDigest = witnessInput.CommittedValuesDigest
 
 	// Generate the witness.
 	assignment := NewCircuit(witnessInput)
@@ -80,10 +71,22 @@ func ProvePlonk(dataDir string, witnessPath string) Proof {
 		panic(err)
 	}
 
-	// Generate the proof.
-	proof, err := plonk.Prove(scs, pk, witness)
-	if err != nil {
-		panic(err)
+	// Generate the proof, unless an interrupted run already did.
+	checkpoint := openProofCheckpoint("plonk", dataDir+"/"+plonkVkPath, data)
+	proof := plonk.NewProof(ecc.BN254)
+	resumed := checkpoint.load(proof)
+	if resumed && plonk.Verify(proof, vk, publicWitness) != nil {
+		checkpoint.discard()
+		resumed = false
+	}
+	if !resumed {
+		hints, stopHints := proverHints(scs)
+		proof, err = plonk.Prove(scs, pk, witness, hints)
+		stopHints()
+		if err != nil {
+			panic(err)
+		}
+		checkpoint.save(proof)
 	}
 
 	// Verify proof.
@@ -92,50 +95,125 @@ func ProvePlonk(dataDir string, witnessPath string) Proof {
 		panic(err)
 	}
 
+	audit.finish(nil)
 	return NewSP1PlonkBn254Proof(&proof, witnessInput)
 }
 
-func ProveGroth16(dataDir string, witnessPath string) Proof {
-	// Sanity check the required arguments have been provided.
-	if dataDir == "" {
-		panic("dataDirStr is required")
+// plonkArtifacts are the compiled constraint system and keys of a PLONK circuit, read from the
+// files written by BuildPlonk. Define and compile only run when building: provers deserialize the
+// constraint system instead.
+type plonkArtifacts struct {
+	dataDir string
+	scs     constraint.ConstraintSystem
+	pk      plonk.ProvingKey
+	vk      plonk.VerifyingKey
+}
+
+// The artifacts of the last PLONK circuit proven with, kept for the next proofs like the Groth16
+// ones are. Only one circuit is kept, since its proving key takes gigabytes.
+var plonkMutex sync.Mutex
+var plonkLoaded *plonkArtifacts
+
+// loadPlonkArtifacts returns the artifacts in dataDir, reading them on the first call only.
+func loadPlonkArtifacts(dataDir string) *plonkArtifacts {
+	plonkMutex.Lock()
+	defer plonkMutex.Unlock()
+	if plonkLoaded != nil && plonkLoaded.dataDir == dataDir {
+		return plonkLoaded
 	}
+	plonkLoaded = nil
 
 	start := time.Now()
-	os.Setenv("CONSTRAINTS_JSON", dataDir+"/"+constraintsJsonFile)
-	os.Setenv("GROTH16", "1")
-	fmt.Printf("Setting environment variables took %s\n", time.Since(start))
+	artifacts := &plonkArtifacts{
+		dataDir: dataDir,
+		scs:     plonk.NewCS(ecc.BN254),
+		pk:      plonk.NewProvingKey(ecc.BN254),
+		vk:      plonk.NewVerifyingKey(ecc.BN254),
+	}
+	readArtifact(dataDir+"/"+plonkCircuitPath, artifacts.scs.ReadFrom)
+	readArtifact(dataDir+"/"+plonkPkPath, artifacts.pk.UnsafeReadFrom)
+	readArtifact(dataDir+"/"+plonkVkPath, artifacts.vk.ReadFrom)
+	fmt.Printf("Reading the PLONK circuit and keys took %s\n", time.Since(start))
+
+	plonkLoaded = artifacts
+	return artifacts
+}
+
+// forgetPlonkArtifacts drops the cached artifacts of dataDir, e.g. when they are rebuilt.
+func forgetPlonkArtifacts(dataDir string) {
+	plonkMutex.Lock()
+	defer plonkMutex.Unlock()
+	if plonkLoaded != nil && plonkLoaded.dataDir == dataDir {
+		plonkLoaded = nil
+	}
+}
 
-	// Read the R1CS.
+// loadGroth16Artifacts reads the R1CS and the proving key in dataDir into globalR1cs and globalPk,
+// unless they are already loaded. They are kept for the process whatever its data dir.
+func loadGroth16Artifacts(dataDir string) {
 	globalMutex.Lock()
+	defer globalMutex.Unlock()
 	if !globalR1csInitialized {
-		start = time.Now()
-		r1csFile, err := os.Open(dataDir + "/" + groth16CircuitPath)
-		if err != nil {
-			panic(err)
-		}
-		r1csReader := bufio.NewReaderSize(r1csFile, 1024*1024)
-		globalR1cs.ReadFrom(r1csReader)
-		defer r1csFile.Close()
+		start := time.Now()
+		readArtifact(dataDir+"/"+groth16CircuitPath, globalR1cs.ReadFrom)
 		globalR1csInitialized = true
 		fmt.Printf("Reading R1CS took %s\n", time.Since(start))
 	}
-	globalMutex.Unlock()
-
-	// Read the proving key.
-	globalMutex.Lock()
 	if !globalPkInitialized {
-		start = time.Now()
-		pkFile, err := os.Open(dataDir + "/" + groth16PkPath)
-		if err != nil {
-			panic(err)
-		}
-		pkReader := bufio.NewReaderSize(pkFile, 1024*1024)
-		globalPk.ReadDump(pkReader)
-		defer pkFile.Close()
+		start := time.Now()
+		readArtifact(dataDir+"/"+groth16PkPath, func(r io.Reader) (int64, error) {
+			return 0, globalPk.ReadDump(r)
+		})
+		globalPkInitialized = true
 		fmt.Printf("Reading proving key took %s\n", time.Since(start))
 	}
-	globalMutex.Unlock()
+}
+
+// forgetGroth16Artifacts drops the R1CS and the proving key kept by ProveGroth16.
+func forgetGroth16Artifacts() {
+	globalMutex.Lock()
+	defer globalMutex.Unlock()
+	globalR1cs = groth16.NewCS(ecc.BN254)
+	globalR1csInitialized = false
+	globalPk = groth16.NewProvingKey(ecc.BN254)
+	globalPkInitialized = false
+}
+
+func readArtifact(path string, read func(io.Reader) (int64, error)) {
+	file, err := os.Open(path)
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	if _, err := read(bufio.NewReaderSize(file, 1024*1024)); err != nil {
+		panic(fmt.Errorf("reading %s: %w", path, err))
+	}
+}
+
+func ProveGroth16(dataDir string, witnessPath string) Proof {
+	audit := startAudit("prove", "groth16")
+	defer audit.finishOnPanic()
+
+	// Sanity check the required arguments have been provided.
+	if dataDir == "" {
+		panic("dataDirStr is required")
+	}
+
+	globalMutex.RLock()
+	keyLoaded := globalPkInitialized
+	globalMutex.RUnlock()
+	if err := Preflight(dataDir, witnessPath, groth16CircuitPath, groth16PkPath, groth16VkPath, keyLoaded); err != nil {
+		panic(err)
+	}
+	warnTightMemory(provingKeyMemory(dataDir, groth16PkPath, keyLoaded))
+
+	start := time.Now()
+	os.Setenv("CONSTRAINTS_JSON", dataDir+"/"+constraintsJsonFile)
+	os.Setenv("GROTH16", "1")
+	fmt.Printf("Setting environment variables took %s\n", time.Since(start))
+
+	// Read the R1CS and the proving key, unless a previous proof already did.
+	loadGroth16Artifacts(dataDir)
 
 	start = time.Now()
 	// Read the file.
@@ -152,6 +230,9 @@ func ProveGroth16(dataDir string, witnessPath string) Proof {
 	if err != nil {
 		panic(err)
 	}
+	audit.WitnessDigest = digest(data)
+	audit.VkeyHash = witnessInput.VkeyHash
+	audit.CommittedValuesDigest = witnessInput.CommittedValuesDigest
 	fmt.Printf("Deserializing JSON data took %s\n", time.Since(start))
 
 	start = time.Now()
@@ -163,14 +244,46 @@ func ProveGroth16(dataDir string, witnessPath string) Proof {
 	}
 	fmt.Printf("Generating witness took %s\n", time.Since(start))
 
-	start = time.Now()
-	// Generate the proof.
-	proof, err := groth16.Prove(globalR1cs, globalPk, witness)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		panic(err)
+	// Resume from the proof of an interrupted run, if any.
+	checkpoint := openProofCheckpoint("groth16", dataDir+"/"+groth16VkPath, data)
+	proof := groth16.NewProof(ecc.BN254)
+	resumed := checkpoint.load(proof)
+	if resumed && !verifyGroth16Checkpoint(dataDir, proof, witness) {
+		checkpoint.discard()
+		resumed = false
+	}
+
+	if !resumed {
+		start = time.Now()
+		// Generate the proof.
+		proof, err = proveGroth16(globalR1cs, globalPk, witness, testProverRandomness())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			panic(err)
+		}
+		fmt.Printf("Generating proof took %s\n", time.Since(start))
+		checkpoint.save(proof)
 	}
-	fmt.Printf("Generating proof took %s\n", time.Since(start))
 
+	audit.finish(nil)
 	return NewSP1Groth16Proof(&proof, witnessInput)
 }
+
+// verifyGroth16Checkpoint verifies a checkpointed proof, which unlike a fresh one may have been
+// computed for another circuit or corrupted on disk.
+func verifyGroth16Checkpoint(dataDir string, proof groth16.Proof, fullWitness witness.Witness) bool {
+	vkFile, err := os.Open(dataDir + "/" + groth16VkPath)
+	if err != nil {
+		return false
+	}
+	defer vkFile.Close()
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(vkFile); err != nil {
+		return false
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return false
+	}
+	return groth16.Verify(proof, vk, publicWitness) == nil
+}
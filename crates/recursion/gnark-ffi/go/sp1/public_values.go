@@ -0,0 +1,27 @@
+package sp1
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+// CommittedValuesDigest returns the digest of the raw public values of a proof, the public input
+// committed by the circuit: their SHA-256 hash with the top 3 bits masked so that it fits in the
+// BN254 scalar field, like hashPublicValues in the Solidity verifier.
+func CommittedValuesDigest(publicValues []byte) string {
+	hash := sha256.Sum256(publicValues)
+	hash[0] &= 0x1f
+	return new(big.Int).SetBytes(hash[:]).String()
+}
+
+// VerifyPlonkPublicValues is VerifyPlonk against the raw public values rather than their digest,
+// so that the proof cannot be checked against a digest of other values by mistake.
+func VerifyPlonkPublicValues(dataDir string, proof string, vkeyHash string, publicValues []byte) error {
+	return VerifyPlonk(dataDir, proof, vkeyHash, CommittedValuesDigest(publicValues))
+}
+
+// VerifyGroth16PublicValues is VerifyGroth16 against the raw public values rather than their
+// digest.
+func VerifyGroth16PublicValues(dataDir string, proof string, vkeyHash string, publicValues []byte) error {
+	return VerifyGroth16(dataDir, proof, vkeyHash, CommittedValuesDigest(publicValues))
+}
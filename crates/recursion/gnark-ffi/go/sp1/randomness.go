@@ -0,0 +1,56 @@
+package sp1
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+)
+
+// NewDeterministicReader returns an endless stream of SHA-256(seed || counter) blocks, for
+// reproducible proofs in tests.
+func NewDeterministicReader(seed []byte) io.Reader {
+	return &deterministicReader{seed: seed}
+}
+
+type deterministicReader struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (r *deterministicReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			var counter [8]byte
+			binary.BigEndian.PutUint64(counter[:], r.counter)
+			r.counter++
+			block := sha256.Sum256(append(append([]byte{}, r.seed...), counter[:]...))
+			r.buf = block[:]
+		}
+		copied := copy(p[n:], r.buf)
+		r.buf = r.buf[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+// proveGroth16 proves with blinding factors sampled from randomness, or from crypto/rand by gnark
+// itself if it is nil. Any other source is for tests only, and only accepted in builds with the
+// sp1_test_seed tag: proofs blinded with predictable randomness are not zero-knowledge.
+func proveGroth16(r1cs constraint.ConstraintSystem, pk groth16.ProvingKey, fullWitness witness.Witness, randomness io.Reader) (groth16.Proof, error) {
+	if randomness != nil {
+		restore, err := useProverRandomness(randomness)
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+	}
+	hints, stopHints := proverHints(r1cs)
+	defer stopHints()
+	return groth16.Prove(r1cs, pk, fullWitness, hints)
+}
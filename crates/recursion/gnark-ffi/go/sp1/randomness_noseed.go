@@ -0,0 +1,18 @@
+//go:build !sp1_test_seed
+
+package sp1
+
+import (
+	"errors"
+	"io"
+)
+
+// testProverRandomness always returns nil: production builds cannot prove with a test seed.
+func testProverRandomness() io.Reader {
+	return nil
+}
+
+// useProverRandomness always fails: production builds only prove with crypto/rand.
+func useProverRandomness(io.Reader) (restore func(), err error) {
+	return nil, errors.New("proving with a test randomness source requires the sp1_test_seed build tag")
+}
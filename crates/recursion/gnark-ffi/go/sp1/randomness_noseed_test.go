@@ -0,0 +1,18 @@
+//go:build !sp1_test_seed
+
+package sp1
+
+import "testing"
+
+func TestProverRandomnessWithoutSeedTag(t *testing.T) {
+	prove := rangeCheckedProver(t)
+	if _, err := prove(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prove(NewDeterministicReader([]byte("golden"))); err == nil {
+		t.Fatal("proved with a test randomness source without the sp1_test_seed build tag")
+	}
+	if testProverRandomness() != nil {
+		t.Fatal("the test seed is enabled without the sp1_test_seed build tag")
+	}
+}
@@ -0,0 +1,37 @@
+//go:build sp1_test_seed
+
+package sp1
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// testProverRandomness returns a deterministic stream of SP1_TEST_PROVER_SEED if it is set, so
+// golden proofs can be regenerated. It only exists in builds with the sp1_test_seed tag.
+func testProverRandomness() io.Reader {
+	seed := os.Getenv("SP1_TEST_PROVER_SEED")
+	if seed == "" {
+		return nil
+	}
+	fmt.Printf("WARNING: proving with the deterministic test seed %q, the proof is not zero-knowledge\n", seed)
+	return NewDeterministicReader([]byte(seed))
+}
+
+var proverRandomnessMutex sync.Mutex
+
+// useProverRandomness replaces crypto/rand.Reader, which gnark samples its blinding factors and
+// commitment masks from, with randomness until restore is called. The swap affects the whole
+// process, hence the build tag; proves with a test source run one at a time.
+func useProverRandomness(randomness io.Reader) (restore func(), err error) {
+	proverRandomnessMutex.Lock()
+	previous := rand.Reader
+	rand.Reader = randomness
+	return func() {
+		rand.Reader = previous
+		proverRandomnessMutex.Unlock()
+	}, nil
+}
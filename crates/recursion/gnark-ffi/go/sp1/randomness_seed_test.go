@@ -0,0 +1,32 @@
+//go:build sp1_test_seed
+
+package sp1
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestDeterministicProverRandomness(t *testing.T) {
+	proveWith := rangeCheckedProver(t)
+	prove := func(randomness io.Reader) []byte {
+		proof, err := proveWith(randomness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return proof
+	}
+
+	golden := prove(NewDeterministicReader([]byte("golden")))
+	if !bytes.Equal(golden, prove(NewDeterministicReader([]byte("golden")))) {
+		t.Fatal("proofs with the same seed differ")
+	}
+	if bytes.Equal(golden, prove(NewDeterministicReader([]byte("other")))) {
+		t.Fatal("proofs with different seeds are equal")
+	}
+	// Without a source, gnark samples from crypto/rand, which is restored after each prove.
+	if bytes.Equal(golden, prove(nil)) {
+		t.Fatal("the default prover is deterministic")
+	}
+}
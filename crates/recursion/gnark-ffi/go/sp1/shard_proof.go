@@ -0,0 +1,315 @@
+package sp1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+)
+
+// The types below mirror SP1ReduceProof<BabyBearPoseidon2Outer> (see crates/core/executor/src/reduce.rs
+// and crates/stark/src/types.rs), the proof wrapped into the BN254 circuit, and are decoded from
+// its bincode encoding with the default options:
+//
+//   - integers and usizes are little-endian u64s, u32s for BabyBear elements;
+//   - sequences, maps and strings are prefixed by their length as a u64, fixed size arrays and
+//     structs are their fields in order;
+//   - BabyBear elements are their canonical value, extension elements their four coefficients;
+//   - BN254 elements (the digests of the outer MMCS) are 32 little-endian bytes, prefixed by their
+//     length like a byte sequence.
+
+type ShardCommitment struct {
+	GlobalMainCommit  *big.Int
+	LocalMainCommit   *big.Int
+	PermutationCommit *big.Int
+	QuotientCommit    *big.Int
+}
+
+type CommitPhaseProofStep struct {
+	SiblingValue babybear.Ext
+	OpeningProof []*big.Int
+}
+
+type QueryProof struct {
+	CommitPhaseOpenings []CommitPhaseProofStep
+}
+
+type FriProof struct {
+	CommitPhaseCommits []*big.Int
+	QueryProofs        []QueryProof
+	FinalPoly          babybear.Ext
+	PowWitness         uint32
+}
+
+type BatchOpening struct {
+	OpenedValues [][]uint32
+	OpeningProof []*big.Int
+}
+
+type PcsProof struct {
+	FriProof      FriProof
+	QueryOpenings [][]BatchOpening
+}
+
+type ShardProof struct {
+	Commitment   ShardCommitment
+	OpenedValues ShardOpenedValues
+	OpeningProof PcsProof
+	ChipOrdering map[string]int
+	PublicValues []uint32
+}
+
+// ChipInformation is the name, trace domain and preprocessed trace dimensions of a chip.
+type ChipInformation struct {
+	Name   string
+	LogN   int
+	Shift  uint32
+	Width  int
+	Height int
+}
+
+type StarkVerifyingKey struct {
+	Commit          *big.Int
+	PcStart         uint32
+	ChipInformation []ChipInformation
+	ChipOrdering    map[string]int
+}
+
+type ReduceProof struct {
+	Vk    StarkVerifyingKey
+	Proof ShardProof
+}
+
+// DecodeReduceProof decodes a bincode encoded SP1ReduceProof, rejecting trailing data and
+// non-canonical field elements.
+func DecodeReduceProof(data []byte) (*ReduceProof, error) {
+	r := bincodeReader{data: data}
+	var proof ReduceProof
+	proof.Vk = r.verifyingKey()
+	proof.Proof = r.shardProof()
+	if err := r.finish(); err != nil {
+		return nil, fmt.Errorf("decoding reduce proof: %w", err)
+	}
+	return &proof, nil
+}
+
+// DecodeShardProof decodes a bincode encoded ShardProof, see DecodeReduceProof.
+func DecodeShardProof(data []byte) (*ShardProof, error) {
+	r := bincodeReader{data: data}
+	proof := r.shardProof()
+	if err := r.finish(); err != nil {
+		return nil, fmt.Errorf("decoding shard proof: %w", err)
+	}
+	return &proof, nil
+}
+
+// bincodeReader decodes the bincode layout, keeping the first error.
+type bincodeReader struct {
+	data []byte
+	err  error
+}
+
+func (r *bincodeReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *bincodeReader) finish() error {
+	if r.err == nil && len(r.data) != 0 {
+		r.err = fmt.Errorf("%d bytes of trailing data", len(r.data))
+	}
+	return r.err
+}
+
+func (r *bincodeReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.data) < n {
+		r.fail(io.ErrUnexpectedEOF)
+		return make([]byte, n)
+	}
+	out := r.data[:n]
+	r.data = r.data[n:]
+	return out
+}
+
+func (r *bincodeReader) u32() uint32 {
+	return binary.LittleEndian.Uint32(r.next(4))
+}
+
+func (r *bincodeReader) u64() uint64 {
+	return binary.LittleEndian.Uint64(r.next(8))
+}
+
+// len reads the length of a sequence whose elements take at least minSize bytes, bounding it by
+// the remaining data so that a corrupted length cannot trigger a huge allocation.
+func (r *bincodeReader) len(minSize int) int {
+	n := r.u64()
+	if r.err == nil && n > uint64(len(r.data)/minSize) {
+		r.fail(fmt.Errorf("length %d exceeds the remaining %d bytes", n, len(r.data)))
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (r *bincodeReader) usize() int {
+	n := r.u64()
+	if n > 1<<31 {
+		r.fail(fmt.Errorf("usize %d out of range", n))
+		return 0
+	}
+	return int(n)
+}
+
+func (r *bincodeReader) string() string {
+	return string(r.next(r.len(1)))
+}
+
+func (r *bincodeReader) felt() uint32 {
+	v := r.u32()
+	if v >= uint32(babybear.Params{}.Modulus().Uint64()) {
+		r.fail(fmt.Errorf("non-canonical BabyBear element %d", v))
+	}
+	return v
+}
+
+func (r *bincodeReader) felts() []uint32 {
+	out := make([]uint32, r.len(4))
+	for i := range out {
+		out[i] = r.felt()
+	}
+	return out
+}
+
+func (r *bincodeReader) ext() babybear.Ext {
+	return babybear.Ext{r.felt(), r.felt(), r.felt(), r.felt()}
+}
+
+func (r *bincodeReader) exts() [][]string {
+	out := make([][]string, r.len(16))
+	for i := range out {
+		out[i] = r.ext().Strings()
+	}
+	return out
+}
+
+func (r *bincodeReader) bn254() *big.Int {
+	if n := r.u64(); r.err == nil && n != 32 {
+		r.fail(fmt.Errorf("expected a 32 byte BN254 element, got %d bytes", n))
+	}
+	le := r.next(32)
+	be := make([]byte, 32)
+	for i := range le {
+		be[31-i] = le[i]
+	}
+	v := new(big.Int).SetBytes(be)
+	if v.Cmp(ecc.BN254.ScalarField()) >= 0 {
+		r.fail(errors.New("non-canonical BN254 element"))
+	}
+	return v
+}
+
+func (r *bincodeReader) digests() []*big.Int {
+	out := make([]*big.Int, r.len(40))
+	for i := range out {
+		out[i] = r.bn254()
+	}
+	return out
+}
+
+func (r *bincodeReader) chipOrdering() map[string]int {
+	n := r.len(16)
+	out := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		name := r.string()
+		out[name] = r.usize()
+	}
+	return out
+}
+
+func (r *bincodeReader) verifyingKey() StarkVerifyingKey {
+	var vk StarkVerifyingKey
+	vk.Commit = r.bn254()
+	vk.PcStart = r.felt()
+	vk.ChipInformation = make([]ChipInformation, r.len(36))
+	for i := range vk.ChipInformation {
+		info := &vk.ChipInformation[i]
+		info.Name = r.string()
+		info.LogN = r.usize()
+		info.Shift = r.felt()
+		info.Width = r.usize()
+		info.Height = r.usize()
+	}
+	vk.ChipOrdering = r.chipOrdering()
+	return vk
+}
+
+func (r *bincodeReader) airOpenedValues() AirOpenedValues {
+	local := r.exts()
+	next := r.exts()
+	return AirOpenedValues{Local: local, Next: next}
+}
+
+func (r *bincodeReader) shardProof() ShardProof {
+	var proof ShardProof
+	proof.Commitment = ShardCommitment{
+		GlobalMainCommit:  r.bn254(),
+		LocalMainCommit:   r.bn254(),
+		PermutationCommit: r.bn254(),
+		QuotientCommit:    r.bn254(),
+	}
+
+	proof.OpenedValues.Chips = make([]ChipOpenedValues, r.len(48))
+	for i := range proof.OpenedValues.Chips {
+		chip := &proof.OpenedValues.Chips[i]
+		chip.Preprocessed = r.airOpenedValues()
+		chip.Main = r.airOpenedValues()
+		chip.Permutation = r.airOpenedValues()
+		chip.Quotient = make([][][]string, r.len(8))
+		for j := range chip.Quotient {
+			chip.Quotient[j] = r.exts()
+		}
+		chip.GlobalCumulativeSum = r.ext().Strings()
+		chip.LocalCumulativeSum = r.ext().Strings()
+		chip.LogDegree = r.usize()
+	}
+
+	fri := &proof.OpeningProof.FriProof
+	fri.CommitPhaseCommits = r.digests()
+	fri.QueryProofs = make([]QueryProof, r.len(8))
+	for i := range fri.QueryProofs {
+		steps := make([]CommitPhaseProofStep, r.len(24))
+		for j := range steps {
+			steps[j].SiblingValue = r.ext()
+			steps[j].OpeningProof = r.digests()
+		}
+		fri.QueryProofs[i].CommitPhaseOpenings = steps
+	}
+	fri.FinalPoly = r.ext()
+	fri.PowWitness = r.felt()
+
+	proof.OpeningProof.QueryOpenings = make([][]BatchOpening, r.len(8))
+	for i := range proof.OpeningProof.QueryOpenings {
+		openings := make([]BatchOpening, r.len(16))
+		for j := range openings {
+			openings[j].OpenedValues = make([][]uint32, r.len(8))
+			for k := range openings[j].OpenedValues {
+				openings[j].OpenedValues[k] = r.felts()
+			}
+			openings[j].OpeningProof = r.digests()
+		}
+		proof.OpeningProof.QueryOpenings[i] = openings
+	}
+
+	proof.ChipOrdering = r.chipOrdering()
+	proof.PublicValues = r.felts()
+	return proof
+}
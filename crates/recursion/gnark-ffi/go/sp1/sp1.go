@@ -1,25 +1,32 @@
 package sp1
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"strconv"
 
 	"github.com/consensys/gnark/frontend"
 	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
 	"github.com/succinctlabs/sp1-recursion-gnark/sp1/poseidon2"
 )
 
 var srsFile string = "srs.bin"
 var srsLagrangeFile string = "srs_lagrange.bin"
+var srsProvenanceFile string = "srs_provenance.json"
 var constraintsJsonFile string = "constraints.json"
+var friConfigPath string = "fri_config.json"
+var vkeyRegistryPath string = "vkeys.json"
 var plonkVerifierContractPath string = "PlonkVerifier.sol"
 var groth16VerifierContractPath string = "Groth16Verifier.sol"
 var plonkCircuitPath string = "plonk_circuit.bin"
 var groth16CircuitPath string = "groth16_circuit.bin"
 var plonkVkPath string = "plonk_vk.bin"
 var groth16VkPath string = "groth16_vk.bin"
+var groth16RawVkPath string = "groth16_vk_raw.bin"
 var plonkPkPath string = "plonk_pk.bin"
 var groth16PkPath string = "groth16_pk.bin"
 var plonkWitnessPath string = "plonk_witness.json"
@@ -31,6 +38,9 @@ type Circuit struct {
 	Vars                  []frontend.Variable
 	Felts                 []babybear.Variable
 	Exts                  []babybear.ExtensionVariable
+
+	// Stats, when set, receives the number of constraints added by each opcode.
+	Stats *CircuitStats `gnark:"-"`
 }
 
 type Constraint struct {
@@ -44,6 +54,14 @@ type WitnessInput struct {
 	Exts                  [][]string `json:"exts"`
 	VkeyHash              string     `json:"vkey_hash"`
 	CommittedValuesDigest string     `json:"committed_values_digest"`
+	ShapeDigest           string     `json:"shape_digest"`
+}
+
+// ShapeDigest returns the digest of a serialized constraint system: the first 31 bytes of its
+// SHA-256 hash, read as a big-endian integer so that it fits in the BN254 scalar field.
+func ShapeDigest(constraintsJson []byte) string {
+	hash := sha256.Sum256(constraintsJson)
+	return new(big.Int).SetBytes(hash[:31]).String()
 }
 
 type Proof struct {
@@ -79,26 +97,37 @@ func (circuit *Circuit) Define(api frontend.API) error {
 	felts := make(map[string]babybear.Variable)
 	exts := make(map[string]babybear.ExtensionVariable)
 
-	// Iterate through the witnesses and range check them, if necessary.
+	// Iterate through the witnesses and range check them, if necessary. Witnessed felts include the
+	// public values hashed into the committed values digest, so they must be canonical: otherwise
+	// x and x + p would be the same BabyBear element with two different digests.
 	for i := 0; i < len(circuit.Felts); i++ {
-		if os.Getenv("GROTH16") != "1" {
-			fieldAPI.RangeChecker.Check(circuit.Felts[i].Value, 31)
-		} else {
-			api.ToBinary(circuit.Felts[i].Value, 31)
-		}
+		fieldAPI.AssertCanonical(circuit.Felts[i])
 	}
 	for i := 0; i < len(circuit.Exts); i++ {
 		for j := 0; j < 4; j++ {
-			if os.Getenv("GROTH16") != "1" {
-				fieldAPI.RangeChecker.Check(circuit.Exts[i].Value[j].Value, 31)
-			} else {
-				api.ToBinary(circuit.Exts[i].Value[j].Value, 31)
-			}
+			fieldAPI.RangeChecker.Check(circuit.Exts[i].Value[j].Value, 31)
 		}
 	}
 
+	witnessVars := newWitnessUsage("vars", len(circuit.Vars))
+	witnessFelts := newWitnessUsage("felts", len(circuit.Felts))
+	witnessExts := newWitnessUsage("exts", len(circuit.Exts))
+
+	var countConstraints func() int
+	if circuit.Stats != nil {
+		countConstraints = field.ConstraintCounter(api)
+	}
+	if countConstraints != nil {
+		circuit.Stats.Witness = countConstraints()
+	}
+
 	// Iterate through the instructions and handle each opcode.
-	for _, cs := range constraints {
+	for i, cs := range constraints {
+		before := 0
+		if countConstraints != nil {
+			before = countConstraints()
+		}
+		endScope := fieldAPI.Scope("opcode." + cs.Opcode)
 		switch cs.Opcode {
 		case "ImmV":
 			vars[cs.Args[0][0]] = frontend.Variable(cs.Args[1][0])
@@ -170,11 +199,11 @@ func (circuit *Circuit) Define(api frontend.API) error {
 				felts[cs.Args[i][0]] = state[i]
 			}
 		case "SelectV":
-			vars[cs.Args[0][0]] = api.Select(vars[cs.Args[1][0]], vars[cs.Args[2][0]], vars[cs.Args[3][0]])
+			vars[cs.Args[0][0]] = fieldAPI.SelectV(fieldAPI.NewBool(vars[cs.Args[1][0]]), vars[cs.Args[2][0]], vars[cs.Args[3][0]])
 		case "SelectF":
-			felts[cs.Args[0][0]] = fieldAPI.SelectF(vars[cs.Args[1][0]], felts[cs.Args[2][0]], felts[cs.Args[3][0]])
+			felts[cs.Args[0][0]] = fieldAPI.SelectF(fieldAPI.NewBool(vars[cs.Args[1][0]]), felts[cs.Args[2][0]], felts[cs.Args[3][0]])
 		case "SelectE":
-			exts[cs.Args[0][0]] = fieldAPI.SelectE(vars[cs.Args[1][0]], exts[cs.Args[2][0]], exts[cs.Args[3][0]])
+			exts[cs.Args[0][0]] = fieldAPI.SelectE(fieldAPI.NewBool(vars[cs.Args[1][0]]), exts[cs.Args[2][0]], exts[cs.Args[3][0]])
 		case "Ext2Felt":
 			out := fieldAPI.Ext2Felt(exts[cs.Args[4][0]])
 			for i := 0; i < 4; i++ {
@@ -200,21 +229,21 @@ func (circuit *Circuit) Define(api frontend.API) error {
 			api.Println(e.Value[2].Value)
 			api.Println(e.Value[3].Value)
 		case "WitnessV":
-			i, err := strconv.Atoi(cs.Args[1][0])
+			i, err := witnessVars.take(cs.Args[1][0])
 			if err != nil {
-				panic(err)
+				return err
 			}
 			vars[cs.Args[0][0]] = circuit.Vars[i]
 		case "WitnessF":
-			i, err := strconv.Atoi(cs.Args[1][0])
+			i, err := witnessFelts.take(cs.Args[1][0])
 			if err != nil {
-				panic(err)
+				return err
 			}
 			felts[cs.Args[0][0]] = circuit.Felts[i]
 		case "WitnessE":
-			i, err := strconv.Atoi(cs.Args[1][0])
+			i, err := witnessExts.take(cs.Args[1][0])
 			if err != nil {
-				panic(err)
+				return err
 			}
 			exts[cs.Args[0][0]] = circuit.Exts[i]
 		case "CommitVkeyHash":
@@ -232,7 +261,61 @@ func (circuit *Circuit) Define(api frontend.API) error {
 		default:
 			return fmt.Errorf("unhandled opcode: %s", cs.Opcode)
 		}
+		endScope()
+		if countConstraints != nil {
+			after := countConstraints()
+			circuit.Stats.record(cs.Opcode, after-before)
+			circuit.Stats.progress(i+1, len(constraints), after)
+		}
+	}
+
+	// A witness with elements the constraints never read was laid out for other constraints, even
+	// if its shape digest matches.
+	for _, usage := range []*witnessUsage{witnessVars, witnessFelts, witnessExts} {
+		if err := usage.check(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// witnessUsage records which witnessed elements of a kind the constraints read.
+type witnessUsage struct {
+	kind  string
+	used  []bool
+	nUsed int
+}
+
+func newWitnessUsage(kind string, n int) *witnessUsage {
+	return &witnessUsage{kind: kind, used: make([]bool, n)}
+}
+
+// take parses the index of a witness instruction and marks the element as read.
+func (u *witnessUsage) take(index string) (int, error) {
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return 0, fmt.Errorf("invalid witness index %q: %w", index, err)
+	}
+	if i < 0 || i >= len(u.used) {
+		return 0, fmt.Errorf("the constraints read witness %s %d, but the witness declares %d", u.kind, i, len(u.used))
 	}
+	if !u.used[i] {
+		u.used[i] = true
+		u.nUsed++
+	}
+	return i, nil
+}
 
+// check returns an error if some declared elements were not read.
+func (u *witnessUsage) check() error {
+	if u.nUsed == len(u.used) {
+		return nil
+	}
+	for i, used := range u.used {
+		if !used {
+			return fmt.Errorf("the constraints read %d of the %d witness %s, the first one never read being %d", u.nUsed, len(u.used), u.kind, i)
+		}
+	}
 	return nil
 }
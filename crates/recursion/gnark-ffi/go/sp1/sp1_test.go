@@ -0,0 +1,1394 @@
+package sp1
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint/solver"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/std/rangecheck"
+	"github.com/consensys/gnark/test"
+	"github.com/consensys/gnark/test/unsafekzg"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/poseidon2"
+)
+
+func setConstraints(t *testing.T, constraints []byte) {
+	constraintsPath := filepath.Join(t.TempDir(), "constraints.json")
+	if err := os.WriteFile(constraintsPath, constraints, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONSTRAINTS_JSON", constraintsPath)
+}
+
+func TestWitnessedFeltsAreCanonical(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// A single public value, witnessed and compared against 5.
+	constraints := []byte(`[
+		{"opcode":"WitnessF","args":[["f0"],["0"]]},
+		{"opcode":"ImmF","args":[["f1"],["5"]]},
+		{"opcode":"AssertEqF","args":[["f0"],["f1"]]}
+	]`)
+	setConstraints(t, constraints)
+
+	newWitness := func(felt string) Circuit {
+		return NewCircuit(WitnessInput{
+			Felts:                 []string{felt},
+			VkeyHash:              "1",
+			CommittedValuesDigest: "2",
+			ShapeDigest:           ShapeDigest(constraints),
+		})
+	}
+
+	circuit := newWitness("5")
+	assignment := newWitness("5")
+	assert.ProverSucceeded(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+
+	// 5 + p, p and 2^31 all fit in 32 bits but are not canonical BabyBear elements.
+	for _, felt := range []string{"2013265926", "2013265921", "2147483648"} {
+		assignment := newWitness(felt)
+		assert.ProverFailed(&circuit, &assignment, test.WithCurves(ecc.BN254), test.WithBackends(backend.PLONK))
+	}
+}
+
+func TestWitnessIsFullyRead(t *testing.T) {
+	constraints := []byte(`[
+		{"opcode":"WitnessF","args":[["f0"],["1"]]},
+		{"opcode":"ImmF","args":[["f1"],["5"]]},
+		{"opcode":"AssertEqF","args":[["f0"],["f1"]]}
+	]`)
+	setConstraints(t, constraints)
+
+	for felts, expected := range map[int]string{
+		1: "read witness felts 1, but the witness declares 1",
+		2: "read 1 of the 2 witness felts, the first one never read being 0",
+	} {
+		circuit := NewCircuit(WitnessInput{
+			Felts:                 make([]string, felts),
+			VkeyHash:              "1",
+			CommittedValuesDigest: "2",
+			ShapeDigest:           ShapeDigest(constraints),
+		})
+		_, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("%d felts: got %v, expected %q", felts, err, expected)
+		}
+	}
+}
+
+func TestCompileIsDeterministic(t *testing.T) {
+	constraints := []byte(`[
+		{"opcode":"WitnessF","args":[["f0"],["0"]]},
+		{"opcode":"WitnessF","args":[["f1"],["1"]]},
+		{"opcode":"WitnessE","args":[["e0"],["0"]]},
+		{"opcode":"MulF","args":[["f2"],["f0"],["f1"]]},
+		{"opcode":"Num2BitsF","args":[["b0","b1","b2","b3","b4","b5","b6","b7","b8","b9","b10","b11","b12","b13","b14","b15","b16","b17","b18","b19","b20","b21","b22","b23","b24","b25","b26","b27","b28","b29","b30"],["f2"]]},
+		{"opcode":"SelectF","args":[["f3"],["b0"],["f0"],["f1"]]},
+		{"opcode":"MulEF","args":[["e1"],["e0"],["f3"]]},
+		{"opcode":"InvE","args":[["e2"],["e1"]]},
+		{"opcode":"MulE","args":[["e3"],["e2"],["e1"]]}
+	]`)
+	setConstraints(t, constraints)
+
+	// Every compilation gets fresh random witness values, which must not affect the circuit.
+	randomFelt := func() string {
+		return strconv.FormatInt(rand.Int63n(2013265921), 10)
+	}
+	newCircuit := func() frontend.Circuit {
+		circuit := NewCircuit(WitnessInput{
+			Felts:                 []string{randomFelt(), randomFelt()},
+			Exts:                  [][]string{{randomFelt(), randomFelt(), randomFelt(), randomFelt()}},
+			VkeyHash:              randomFelt(),
+			CommittedValuesDigest: randomFelt(),
+			ShapeDigest:           ShapeDigest(constraints),
+		})
+		return &circuit
+	}
+	for _, newBuilder := range []frontend.NewBuilder{scs.NewBuilder, r1cs.NewBuilder} {
+		if err := CheckDeterministicCompile(newBuilder, newCircuit); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newPoseidon2Circuit := func() frontend.Circuit {
+		var circuit TestPoseidon2BabyBearCircuit
+		for i := range circuit.Input {
+			circuit.Input[i] = babybear.NewF(randomFelt())
+			circuit.ExpectedOutput[i] = babybear.NewF(randomFelt())
+		}
+		return &circuit
+	}
+	if err := CheckDeterministicCompile(scs.NewBuilder, newPoseidon2Circuit); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuditLogRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv(auditLogEnv, path)
+	t.Setenv(auditLogMaxBytesEnv, "300")
+
+	for i := 0; i < 4; i++ {
+		entry := startAudit("verify", "plonk")
+		entry.VkeyHash = strconv.Itoa(i)
+		entry.finish(nil)
+	}
+
+	// Each entry is over 100 bytes, so every file holds at most two of them.
+	var vkeyHashes []string
+	for _, name := range []string{path + ".1", path} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			var entry AuditEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.Result != "ok" {
+				t.Fatalf("unexpected result %q", entry.Result)
+			}
+			vkeyHashes = append(vkeyHashes, entry.VkeyHash)
+		}
+	}
+	if strings.Join(vkeyHashes, ",") != "0,1,2,3" {
+		t.Fatalf("unexpected entries %v", vkeyHashes)
+	}
+}
+
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+type rangeCheckedCircuit struct {
+	X frontend.Variable
+}
+
+func (c *rangeCheckedCircuit) Define(api frontend.API) error {
+	rangecheck.New(api).Check(c.X, 31)
+	return nil
+}
+
+// rangeCheckedProver returns a function proving rangeCheckedCircuit with proveGroth16 and the given
+// randomness, verifying the proof and returning it serialized.
+func rangeCheckedProver(t *testing.T) func(randomness io.Reader) ([]byte, error) {
+	// The range checker commits to its inputs, like the wrap circuit.
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &rangeCheckedCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&rangeCheckedCircuit{X: 1 << 30}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return func(randomness io.Reader) ([]byte, error) {
+		proof, err := proveGroth16(ccs, pk, fullWitness, randomness)
+		if err != nil {
+			return nil, err
+		}
+		if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := proof.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+func TestPreflightCategories(t *testing.T) {
+	dataDir := t.TempDir()
+	constraints := []byte(`[]`)
+	for name, contents := range map[string][]byte{
+		constraintsJsonFile: constraints,
+		plonkCircuitPath:    []byte("circuit"),
+		plonkPkPath:         []byte("pk"),
+		plonkVkPath:         []byte("vk"),
+	} {
+		if err := os.WriteFile(filepath.Join(dataDir, name), contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeWitness := func(input WitnessInput) string {
+		data, err := json.Marshal(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "witness.json")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	valid := WitnessInput{Felts: []string{"5"}, VkeyHash: "1", CommittedValuesDigest: "2", ShapeDigest: ShapeDigest(constraints)}
+
+	category := func(witnessPath string, pkPath string) string {
+		err := Preflight(dataDir, witnessPath, plonkCircuitPath, pkPath, plonkVkPath, true)
+		if err == nil {
+			return ""
+		}
+		var preflightErr *PreflightError
+		if !errors.As(err, &preflightErr) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return preflightErr.Category
+	}
+
+	if c := category(writeWitness(valid), plonkPkPath); c != "" {
+		t.Fatalf("valid job failed the %s check", c)
+	}
+	if c := category(writeWitness(valid), "missing_pk.bin"); c != PreflightArtifacts {
+		t.Fatalf("missing key: got category %q", c)
+	}
+	nonCanonical := valid
+	nonCanonical.Felts = []string{"2013265921"}
+	if c := category(writeWitness(nonCanonical), plonkPkPath); c != PreflightWitness {
+		t.Fatalf("non-canonical felt: got category %q", c)
+	}
+	otherShape := valid
+	otherShape.ShapeDigest = ShapeDigest([]byte(`[{}]`))
+	if c := category(writeWitness(otherShape), plonkPkPath); c != PreflightWitness {
+		t.Fatalf("other shape: got category %q", c)
+	}
+
+	registry := VkeyRegistry{"v1.0.0": {"0x" + digest([]byte("vk")): {}}}
+	if err := registry.Write(filepath.Join(dataDir, vkeyRegistryPath)); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SP1_CIRCUIT_VERSION", "v1.0.0")
+	if c := category(writeWitness(valid), plonkPkPath); c != "" {
+		t.Fatalf("registered vkey failed the %s check", c)
+	}
+	t.Setenv("SP1_CIRCUIT_VERSION", "v2.0.0")
+	if c := category(writeWitness(valid), plonkPkPath); c != PreflightVersion {
+		t.Fatalf("other version: got category %q", c)
+	}
+}
+
+func TestSupportBundleRedactsSecrets(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dataDir, plonkVkPath), []byte("vk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SP1_CIRCUIT_VERSION", "v1.0.0")
+	t.Setenv("SP1_PROVER_API_KEY", "hunter2")
+
+	outputPath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err := WriteSupportBundle(dataDir, outputPath); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[header.Name] = string(data)
+	}
+
+	if !strings.Contains(contents["sp1-support-bundle/artifacts.json"], digest([]byte("vk"))) {
+		t.Fatal("missing vkey digest")
+	}
+	environment := contents["sp1-support-bundle/environment.json"]
+	if !strings.Contains(environment, "v1.0.0") || strings.Contains(environment, "hunter2") {
+		t.Fatalf("unexpected environment %s", environment)
+	}
+}
+
+func TestRawGroth16VerifyingKey(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &squareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&squareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encodedProof := proof.(*groth16_bn254.Proof).MarshalSolidity()
+
+	var buf bytes.Buffer
+	if err := ExportGroth16VerifyingKey(vk, &buf); err != nil {
+		t.Fatal(err)
+	}
+	rawVk, err := ParseGroth16VerifyingKey(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rawVk.Verify(encodedProof, []*big.Int{big.NewInt(9)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rawVk.Verify(encodedProof, []*big.Int{big.NewInt(10)}); err == nil {
+		t.Fatal("verified a proof with the wrong public input")
+	}
+	if _, err := ParseGroth16VerifyingKey(buf.Bytes()[:buf.Len()-1]); err == nil {
+		t.Fatal("parsed a truncated verifying key")
+	}
+}
+
+func TestGroth16BatchVerifier(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &squareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var proofs [][]byte
+	var inputs [][]*big.Int
+	for _, x := range []int64{3, 4, 5} {
+		fullWitness, err := frontend.NewWitness(&squareCircuit{X: x, Y: x * x}, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := groth16.Prove(ccs, pk, fullWitness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proofs = append(proofs, proof.(*groth16_bn254.Proof).MarshalSolidity())
+		inputs = append(inputs, []*big.Int{big.NewInt(x * x)})
+	}
+
+	var buf bytes.Buffer
+	if err := ExportGroth16VerifyingKey(vk, &buf); err != nil {
+		t.Fatal(err)
+	}
+	rawVk, err := ParseGroth16VerifyingKey(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := big.NewInt(42)
+	if err := rawVk.VerifyBatch(proofs, inputs, seed); err != nil {
+		t.Fatal(err)
+	}
+	calldata, err := EncodeGroth16Batch(proofs, inputs, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calldata) != 4+3*32+32+3*256+32+3*32 || !bytes.Equal(calldata[:4], Groth16BatchSelector(1)) {
+		t.Fatalf("unexpected calldata layout, %d bytes", len(calldata))
+	}
+
+	// Swapping two public inputs breaks both of their proofs.
+	inputs[0], inputs[1] = inputs[1], inputs[0]
+	if err := rawVk.VerifyBatch(proofs, inputs, seed); err == nil {
+		t.Fatal("verified a batch with swapped public inputs")
+	}
+	if err := rawVk.VerifyBatch(proofs, inputs[:2], seed); err == nil {
+		t.Fatal("verified a batch with a missing public input")
+	}
+	swapped, err := EncodeGroth16Batch(proofs, inputs, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contract bytes.Buffer
+	if err := ExportGroth16BatchVerifier(vk, &contract); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"uint256[1][] calldata inputs", "PUB_0_X", "verifyBatch"} {
+		if !strings.Contains(contract.String(), expected) {
+			t.Fatalf("batch verifier is missing %q", expected)
+		}
+	}
+	if strings.Contains(contract.String(), "<no value>") {
+		t.Fatal("batch verifier has unexpanded template values")
+	}
+
+	t.Run("forge", func(t *testing.T) {
+		forgeTestGroth16BatchVerifier(t, contract.Bytes(), calldata, swapped)
+	})
+}
+
+const groth16BatchVerifierForgeTest = `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+import {Groth16BatchVerifier} from "../src/Groth16BatchVerifier.sol";
+
+contract Groth16BatchVerifierTest {
+    Groth16BatchVerifier verifier;
+
+    function setUp() public {
+        verifier = new Groth16BatchVerifier();
+    }
+
+    function testValidBatch() public view {
+        (bool ok,) = address(verifier).staticcall(hex"%x");
+        require(ok, "valid batch rejected");
+    }
+
+    function testSwappedInputs() public view {
+        (bool ok,) = address(verifier).staticcall(hex"%x");
+        require(!ok, "batch with swapped public inputs accepted");
+    }
+}
+`
+
+// forgeTestGroth16BatchVerifier compiles the batch verifier with forge and runs valid and invalid
+// calldata against it, checking that the contract agrees with VerifyBatch. It skips without forge,
+// unless SP1_REQUIRE_FORGE is set, as it is in CI.
+func forgeTestGroth16BatchVerifier(t *testing.T, contract []byte, valid []byte, invalid []byte) {
+	forge, err := exec.LookPath("forge")
+	if err != nil {
+		if os.Getenv("SP1_REQUIRE_FORGE") != "" {
+			t.Fatal(err)
+		}
+		t.Skip("forge is not installed")
+	}
+	dir := t.TempDir()
+	for path, contents := range map[string][]byte{
+		"foundry.toml":                    []byte("[profile.default]\nsrc = \"src\"\ntest = \"test\"\n"),
+		"src/Groth16BatchVerifier.sol":    contract,
+		"test/Groth16BatchVerifier.t.sol": []byte(fmt.Sprintf(groth16BatchVerifierForgeTest, valid, invalid)),
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(forge, "test", "--root", dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("forge test: %v\n%s", err, output)
+	}
+}
+
+func TestProofCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(checkpointDirEnv, "")
+	vkPath := dir + "/vk.bin"
+	if err := os.WriteFile(vkPath, []byte("vk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if openProofCheckpoint("groth16", vkPath, []byte("witness")) != nil {
+		t.Fatal("checkpoints are enabled without a checkpoint dir")
+	}
+	if err := SetCheckpointDir(dir + "/checkpoints"); err != nil {
+		t.Fatal(err)
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &squareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&squareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint := openProofCheckpoint("groth16", vkPath, []byte("witness"))
+	if checkpoint.load(groth16.NewProof(ecc.BN254)) {
+		t.Fatal("loaded a checkpoint that was never saved")
+	}
+	checkpoint.save(proof)
+
+	resumed := groth16.NewProof(ecc.BN254)
+	if !openProofCheckpoint("groth16", vkPath, []byte("witness")).load(resumed) {
+		t.Fatal("failed to load the checkpoint")
+	}
+	var expected, actual bytes.Buffer
+	proof.WriteTo(&expected)
+	resumed.WriteTo(&actual)
+	if !bytes.Equal(expected.Bytes(), actual.Bytes()) {
+		t.Fatal("the checkpointed proof changed")
+	}
+
+	// Another witness or system does not resume from the checkpoint.
+	if openProofCheckpoint("groth16", vkPath, []byte("other witness")).load(groth16.NewProof(ecc.BN254)) {
+		t.Fatal("loaded the checkpoint of another witness")
+	}
+	if openProofCheckpoint("plonk", vkPath, []byte("witness")).load(groth16.NewProof(ecc.BN254)) {
+		t.Fatal("loaded the checkpoint of another system")
+	}
+
+	checkpoint.discard()
+	if checkpoint.load(groth16.NewProof(ecc.BN254)) {
+		t.Fatal("loaded a discarded checkpoint")
+	}
+}
+
+func TestPlonkArtifactsAreLoadedOnce(t *testing.T) {
+	dataDir := t.TempDir()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &squareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, artifact := range map[string]io.WriterTo{plonkCircuitPath: ccs, plonkPkPath: pk, plonkVkPath: vk} {
+		var buf bytes.Buffer
+		if _, err := artifact.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dataDir, path), buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer forgetPlonkArtifacts(dataDir)
+
+	artifacts := loadPlonkArtifacts(dataDir)
+	if artifacts.scs.GetNbConstraints() != ccs.GetNbConstraints() {
+		t.Fatalf("read %d constraints, expected %d", artifacts.scs.GetNbConstraints(), ccs.GetNbConstraints())
+	}
+	if loadPlonkArtifacts(dataDir) != artifacts {
+		t.Fatal("the artifacts were read again")
+	}
+	forgetPlonkArtifacts(dataDir)
+	if loadPlonkArtifacts(dataDir) == artifacts {
+		t.Fatal("the forgotten artifacts were reused")
+	}
+}
+
+func TestGroth16ArtifactsAreLoadedOnce(t *testing.T) {
+	dataDir := t.TempDir()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &squareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var circuit, key bytes.Buffer
+	if _, err := ccs.WriteTo(&circuit); err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.WriteDump(&key); err != nil {
+		t.Fatal(err)
+	}
+	circuitPath, pkPath := filepath.Join(dataDir, groth16CircuitPath), filepath.Join(dataDir, groth16PkPath)
+	if err := os.WriteFile(circuitPath, circuit.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(pkPath, key.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	forgetGroth16Artifacts()
+	defer forgetGroth16Artifacts()
+
+	// The memory of the proving key is only accounted for when it is about to be read.
+	if required := provingKeyMemory(dataDir, groth16PkPath, globalPkInitialized); required != int64(key.Len()) {
+		t.Fatalf("%d bytes required before loading, expected %d", required, key.Len())
+	}
+	loadGroth16Artifacts(dataDir)
+	if !globalR1csInitialized || !globalPkInitialized {
+		t.Fatal("the loaded artifacts are not marked as such")
+	}
+	if globalR1cs.GetNbConstraints() != ccs.GetNbConstraints() {
+		t.Fatalf("read %d constraints, expected %d", globalR1cs.GetNbConstraints(), ccs.GetNbConstraints())
+	}
+	if required := provingKeyMemory(dataDir, groth16PkPath, globalPkInitialized); required != 0 {
+		t.Fatalf("%d bytes required once loaded, expected 0", required)
+	}
+
+	// The artifacts are not read again, so that they can be gone from the disk.
+	if err := os.Remove(circuitPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(pkPath); err != nil {
+		t.Fatal(err)
+	}
+	loadGroth16Artifacts(dataDir)
+
+	// Once forgotten, they are, and a failed read does not leave the lock held.
+	forgetGroth16Artifacts()
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("the forgotten artifacts were reused")
+				}
+			}()
+			loadGroth16Artifacts(dataDir)
+		}()
+	}
+}
+
+func TestAnonymizeWitnessPreservesShape(t *testing.T) {
+	witness := WitnessInput{
+		Vars:                  []string{"12345", "not a number"},
+		Felts:                 []string{"42", "2013265921"},
+		Exts:                  [][]string{{"1", "2", "3", "4"}, {"5"}},
+		VkeyHash:              "777",
+		CommittedValuesDigest: "888",
+		ShapeDigest:           "999",
+	}
+	anonymized, err := AnonymizeWitness(witness, NewDeterministicReader([]byte("anonymize")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if anonymized.ShapeDigest != witness.ShapeDigest {
+		t.Fatalf("shape digest changed to %s", anonymized.ShapeDigest)
+	}
+	if anonymized.VkeyHash == witness.VkeyHash || anonymized.Vars[0] == witness.Vars[0] || anonymized.Felts[0] == witness.Felts[0] {
+		t.Fatalf("values were not anonymized: %+v", anonymized)
+	}
+	if anonymized.Vars[1] != witness.Vars[1] || anonymized.Felts[1] != witness.Felts[1] {
+		t.Fatalf("malformed values were not preserved: %+v", anonymized)
+	}
+	if len(anonymized.Exts) != 2 || len(anonymized.Exts[0]) != 4 || len(anonymized.Exts[1]) != 1 {
+		t.Fatalf("ext shapes were not preserved: %+v", anonymized.Exts)
+	}
+	if _, err := babybear.ParseExt(anonymized.Exts[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCircuitStats(t *testing.T) {
+	constraints := []byte(`[
+		{"opcode":"WitnessF","args":[["f0"],["0"]]},
+		{"opcode":"ImmF","args":[["f1"],["5"]]},
+		{"opcode":"MulF","args":[["f2"],["f0"],["f0"]]},
+		{"opcode":"MulF","args":[["f3"],["f2"],["f0"]]},
+		{"opcode":"AssertEqF","args":[["f3"],["f1"]]}
+	]`)
+	setConstraints(t, constraints)
+
+	// The progress is reported against the total of the previous build.
+	dataDir := t.TempDir()
+	if err := os.WriteFile(dataDir+"/"+constraintStatsPath, []byte(`{"total":42}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if expected := newCircuitStats(dataDir).Expected; expected != 42 {
+		t.Fatalf("expected the previous total 42, got %d", expected)
+	}
+
+	for _, newBuilder := range []frontend.NewBuilder{scs.NewBuilder, r1cs.NewBuilder} {
+		circuit := NewCircuit(WitnessInput{Felts: []string{"0"}, VkeyHash: "0", CommittedValuesDigest: "0"})
+		circuit.Stats = newCircuitStats(dataDir)
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), newBuilder, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		circuit.Stats.finish(ccs.GetNbConstraints())
+
+		stats := circuit.Stats
+		if stats.Opcodes["MulF"].Count != 2 || stats.Opcodes["ImmF"].Count != 1 {
+			t.Fatalf("unexpected opcode counts: %+v", stats.Opcodes)
+		}
+		if stats.Opcodes["ImmF"].Constraints != 0 || stats.Opcodes["AssertEqF"].Constraints == 0 {
+			t.Fatalf("unexpected opcode constraints: %+v", stats.Opcodes)
+		}
+		sum := stats.Witness + stats.Deferred
+		for _, opcode := range stats.Opcodes {
+			sum += opcode.Constraints
+		}
+		if stats.Deferred < 0 || sum != ccs.GetNbConstraints() {
+			t.Fatalf("stats do not add up to %d constraints: %+v", ccs.GetNbConstraints(), stats)
+		}
+	}
+}
+
+func TestFriConfig(t *testing.T) {
+	dataDir := t.TempDir()
+	if _, err := ReadFriConfig(dataDir); err == nil {
+		t.Fatal("read a missing FRI config")
+	}
+
+	// The configs written by write_fri_config in crates/prover/src/build.rs for the outer proofs.
+	for _, c := range []struct {
+		json         string
+		expectedBits int
+		expectedDev  bool
+	}{
+		{json: `{"log_blowup":4,"num_queries":25,"proof_of_work_bits":16,"profile":"release"}`, expectedBits: 116},
+		{json: `{"log_blowup":4,"num_queries":1,"proof_of_work_bits":16,"profile":"dev"}`, expectedBits: 20, expectedDev: true},
+	} {
+		if err := os.WriteFile(filepath.Join(dataDir, friConfigPath), []byte(c.json), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := ReadFriConfig(dataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bits := config.ConjecturedSoundnessBits(); bits != c.expectedBits || config.IsDev() != c.expectedDev {
+			t.Errorf("%s: got %d bits and dev %t, expected %d bits and dev %t", c.json, bits, config.IsDev(), c.expectedBits, c.expectedDev)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dataDir, friConfigPath), []byte(`{"num_queries":"25"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFriConfig(dataDir); err == nil {
+		t.Fatal("read a malformed FRI config")
+	}
+}
+
+func TestVkeyRegistry(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("SP1_CIRCUIT_VERSION", "v4.0.0")
+	write := func(path, contents string) {
+		if err := os.WriteFile(filepath.Join(dataDir, path), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(groth16VkPath, "vk")
+	write(groth16CircuitPath, "circuit")
+	recordVkey(dataDir, "v4.0.0-rc.3", groth16VkPath, []string{groth16CircuitPath})
+
+	// Rebuilding with another vkey keeps the previous one, and other versions are kept apart.
+	write(groth16VkPath, "another vk")
+	recordVkey(dataDir, "v4.0.0-rc.3", groth16VkPath, []string{groth16CircuitPath})
+	// Without a version from the build, the one of the environment is used.
+	recordVkey(dataDir, "", groth16VkPath, []string{groth16CircuitPath})
+
+	registry, err := ReadVkeyRegistry(filepath.Join(dataDir, vkeyRegistryPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vkeyHash, err := fileDigest(filepath.Join(dataDir, groth16VkPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(registry["v4.0.0-rc.3"]) != 2 || len(registry["v4.0.0"]) != 1 {
+		t.Fatalf("unexpected registry %v", registry)
+	}
+	if versions := registry.Versions("0x" + vkeyHash); !reflect.DeepEqual(versions, []string{"v4.0.0", "v4.0.0-rc.3"}) {
+		t.Errorf("got versions %v", versions)
+	}
+	artifacts, ok := registry.Artifacts("v4.0.0", "0x"+vkeyHash)
+	// SHA-256 of "circuit".
+	if !ok || artifacts[groth16CircuitPath] != "4666a3f66bc600ad9f11fa871e13e32f1c0fe8ba9ad25265a449b0c983c589f1" {
+		t.Errorf("got artifacts %v", artifacts)
+	}
+	if !registry.IsAccepted("v4.0.0", "0x"+vkeyHash) || registry.IsAccepted("v4.0.0", vkeyHash) || registry.IsAccepted("v3.0.0", "0x"+vkeyHash) {
+		t.Error("accepted an unexpected vkey")
+	}
+
+	if registry, err := ReadVkeyRegistry(filepath.Join(dataDir, "missing.json")); err != nil || len(registry) != 0 {
+		t.Errorf("got %v and %v for a missing registry", registry, err)
+	}
+	write(vkeyRegistryPath, "[]")
+	if _, err := ReadVkeyRegistry(filepath.Join(dataDir, vkeyRegistryPath)); err == nil {
+		t.Error("read a malformed registry")
+	}
+}
+
+func TestDevProfileVkeysAreMarked(t *testing.T) {
+	for _, c := range []struct {
+		profile         string
+		expectedVersion string
+	}{
+		{profile: "release", expectedVersion: "v4.0.0"},
+		{profile: "dev", expectedVersion: "v4.0.0-dev-insecure"},
+	} {
+		dataDir := t.TempDir()
+		config := `{"log_blowup":4,"num_queries":1,"proof_of_work_bits":16,"profile":"` + c.profile + `"}`
+		if err := os.WriteFile(filepath.Join(dataDir, friConfigPath), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dataDir, plonkVkPath), []byte("vk"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		recordVkey(dataDir, "v4.0.0", plonkVkPath, nil)
+
+		registry, err := ReadVkeyRegistry(filepath.Join(dataDir, vkeyRegistryPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(registry) != 1 || registry[c.expectedVersion] == nil {
+			t.Errorf("%s profile: expected the vkey under %s, got %v", c.profile, c.expectedVersion, registry)
+		}
+	}
+}
+
+func TestValidateShardShape(t *testing.T) {
+	ext := []string{"1", "2", "3", "4"}
+	values := ShardOpenedValues{Chips: []ChipOpenedValues{{
+		Main:     AirOpenedValues{Local: [][]string{ext}, Next: [][]string{ext}},
+		Quotient: [][][]string{{ext, ext, ext, ext}},
+	}}}
+	shape := ChipShape{MainWidth: 1, QuotientChunks: 1}
+	if err := values.ValidateShape([]ChipShape{shape}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, shapes := range map[string][]ChipShape{
+		"missing chip":              {shape, shape},
+		"wider main trace":          {{MainWidth: 2, QuotientChunks: 1}},
+		"preprocessed trace":        {{PreprocessedWidth: 1, MainWidth: 1, QuotientChunks: 1}},
+		"more quotient chunks":      {{MainWidth: 1, QuotientChunks: 2}},
+		"no quotient chunks at all": {{MainWidth: 1}},
+	} {
+		if err := values.ValidateShape(shapes); err == nil {
+			t.Errorf("opened values accepted with a %s", name)
+		}
+	}
+	values.Chips[0].Quotient[0] = values.Chips[0].Quotient[0][:3]
+	if err := values.ValidateShape([]ChipShape{shape}); err == nil {
+		t.Error("opened values accepted with a quotient chunk of 3 values")
+	}
+}
+
+// bincodeWriter encodes values like bincode with the default options, for the decoder tests.
+type bincodeWriter struct{ bytes.Buffer }
+
+func (w *bincodeWriter) u32(v uint32) { binary.Write(&w.Buffer, binary.LittleEndian, v) }
+func (w *bincodeWriter) u64(v uint64) { binary.Write(&w.Buffer, binary.LittleEndian, v) }
+func (w *bincodeWriter) str(s string) { w.u64(uint64(len(s))); w.WriteString(s) }
+func (w *bincodeWriter) ext(e babybear.Ext) {
+	for _, c := range e {
+		w.u32(c)
+	}
+}
+func (w *bincodeWriter) bn254(v int64) {
+	w.u64(32)
+	le := make([]byte, 32)
+	binary.LittleEndian.PutUint64(le, uint64(v))
+	w.Write(le)
+}
+
+func TestDecodeReduceProof(t *testing.T) {
+	var w bincodeWriter
+
+	// Verifying key: commit, pc start, one chip and its ordering.
+	w.bn254(1)
+	w.u32(2)
+	w.u64(1)
+	w.str("Cpu")
+	w.u64(4)
+	w.u32(31)
+	w.u64(5)
+	w.u64(16)
+	w.u64(1)
+	w.str("Cpu")
+	w.u64(0)
+
+	// Shard commitment.
+	for i := int64(0); i < 4; i++ {
+		w.bn254(10 + i)
+	}
+	// One chip with one main column and one quotient chunk.
+	w.u64(1)
+	w.u64(0)
+	w.u64(0)
+	w.u64(1)
+	w.ext(babybear.Ext{1, 2, 3, 4})
+	w.u64(1)
+	w.ext(babybear.Ext{5, 6, 7, 8})
+	w.u64(0)
+	w.u64(0)
+	w.u64(1)
+	w.u64(1)
+	w.ext(babybear.Ext{9, 0, 0, 0})
+	w.ext(babybear.Ext{})
+	w.ext(babybear.Ext{})
+	w.u64(4)
+	// FRI proof: one commit phase commit, one query with one step.
+	w.u64(1)
+	w.bn254(20)
+	w.u64(1)
+	w.u64(1)
+	w.ext(babybear.Ext{1, 1, 1, 1})
+	w.u64(1)
+	w.bn254(21)
+	w.ext(babybear.Ext{2, 2, 2, 2})
+	w.u32(77)
+	// Query openings: one query, one batch with one matrix row.
+	w.u64(1)
+	w.u64(1)
+	w.u64(1)
+	w.u64(2)
+	w.u32(3)
+	w.u32(4)
+	w.u64(1)
+	w.bn254(22)
+	// Chip ordering and public values.
+	w.u64(1)
+	w.str("Cpu")
+	w.u64(0)
+	w.u64(2)
+	w.u32(100)
+	w.u32(101)
+
+	proof, err := DecodeReduceProof(w.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.Vk.ChipInformation[0] != (ChipInformation{Name: "Cpu", LogN: 4, Shift: 31, Width: 5, Height: 16}) {
+		t.Fatalf("unexpected chip information: %+v", proof.Vk.ChipInformation)
+	}
+	if proof.Proof.Commitment.QuotientCommit.Int64() != 13 || proof.Proof.OpeningProof.FriProof.PowWitness != 77 {
+		t.Fatalf("unexpected proof: %+v", proof.Proof)
+	}
+	chip := proof.Proof.OpenedValues.Chips[0]
+	if strings.Join(chip.Main.Next[0], ",") != "5,6,7,8" || chip.Quotient[0][0][0] != "9" || chip.LogDegree != 4 {
+		t.Fatalf("unexpected opened values: %+v", chip)
+	}
+	if opening := proof.Proof.OpeningProof.QueryOpenings[0][0]; opening.OpenedValues[0][1] != 4 || opening.OpeningProof[0].Int64() != 22 {
+		t.Fatalf("unexpected query openings: %+v", opening)
+	}
+	if proof.Proof.ChipOrdering["Cpu"] != 0 || len(proof.Proof.PublicValues) != 2 {
+		t.Fatalf("unexpected chip ordering or public values: %+v", proof.Proof)
+	}
+
+	if _, err := DecodeReduceProof(append(w.Bytes(), 0)); err == nil {
+		t.Fatal("decoded a proof with trailing data")
+	}
+	if _, err := DecodeReduceProof(w.Bytes()[:w.Len()-1]); err == nil {
+		t.Fatal("decoded a truncated proof")
+	}
+}
+
+// TestDecodeRustReduceProof decodes a proof serialized by the Rust prover rather than by
+// bincodeWriter, which shares the assumptions of the decoder. The fixture is written by the end to
+// end test of the prover:
+//
+//	SP1_REDUCE_PROOF_FIXTURE=$PWD/sp1/testdata/proof-with-pis.bin cargo test --release -p sp1-prover test_e2e
+func TestDecodeRustReduceProof(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "proof-with-pis.bin"))
+	if errors.Is(err, os.ErrNotExist) {
+		// TODO: commit the fixture and make this test required. It takes a full end to end prove:
+		//   SP1_REDUCE_PROOF_FIXTURE=$PWD/sp1/testdata/proof-with-pis.bin \
+		//     cargo test --release -p sp1-prover test_e2e
+		t.Skip("no proof serialized by the Rust prover in testdata")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := DecodeReduceProof(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The chips of the verifying key and of the proof are ordered consistently.
+	for name, index := range proof.Vk.ChipOrdering {
+		if index >= len(proof.Vk.ChipInformation) || proof.Vk.ChipInformation[index].Name != name {
+			t.Fatalf("chip %s is not at index %d of the verifying key", name, index)
+		}
+	}
+	if len(proof.Proof.ChipOrdering) != len(proof.Proof.OpenedValues.Chips) {
+		t.Fatalf("%d chips ordered, %d opened", len(proof.Proof.ChipOrdering), len(proof.Proof.OpenedValues.Chips))
+	}
+	if len(proof.Proof.OpeningProof.FriProof.QueryProofs) == 0 || len(proof.Proof.PublicValues) == 0 {
+		t.Fatalf("unexpected proof: %+v", proof.Proof)
+	}
+	if _, err := DecodeReduceProof(data[:len(data)-1]); err == nil {
+		t.Fatal("decoded a truncated proof")
+	}
+}
+
+func TestConfigOverridesEnvironment(t *testing.T) {
+	t.Setenv("SP1_CIRCUIT_VERSION", "v0")
+	t.Setenv(auditLogEnv, "")
+	t.Setenv(auditLogMaxBytesEnv, "")
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	if err := os.WriteFile(path, []byte(`{"circuit_version":"v1","audit_lgo":"typo"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(path); err == nil {
+		t.Fatal("loaded a config with an unknown field")
+	}
+
+	auditLog := filepath.Join(t.TempDir(), "audit.jsonl")
+	config := fmt.Sprintf(`{"circuit_version":"v1","audit_log":%q,"audit_log_max_bytes":1024}`, auditLog)
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv("SP1_CIRCUIT_VERSION") != "v1" || os.Getenv(auditLogEnv) != auditLog || os.Getenv(auditLogMaxBytesEnv) != "1024" {
+		t.Fatalf("config was not applied: %s, %s", os.Getenv("SP1_CIRCUIT_VERSION"), os.Getenv(auditLogEnv))
+	}
+}
+
+func TestCompatibilityMatrix(t *testing.T) {
+	dataDir := t.TempDir()
+	registry := VkeyRegistry{
+		"v2.0.0":              {"0xb": {groth16CircuitPath: "0"}, "0xa": {plonkCircuitPath: "0"}},
+		"v1.0.0-dev-insecure": {"0xc": {plonkCircuitPath: "0"}},
+		"v1.0.0":              {"0xd": {}},
+	}
+	if err := registry.Write(filepath.Join(dataDir, vkeyRegistryPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	matrix, err := CompatibilityMatrix(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Compatibility{
+		{CircuitVersion: "v1.0.0-dev-insecure", System: "plonk", VkeyHash: "0xc"},
+		{CircuitVersion: "v2.0.0", System: "groth16", VkeyHash: "0xb"},
+		{CircuitVersion: "v2.0.0", System: "plonk", VkeyHash: "0xa"},
+	}
+	for i := range expected {
+		expected[i].WitnessSchemaVersion = WitnessSchemaVersion
+		expected[i].AbiVersion = AbiVersion
+	}
+	if !reflect.DeepEqual(matrix, expected) {
+		t.Fatalf("unexpected matrix: %+v", matrix)
+	}
+}
+
+func TestCommittedValuesDigest(t *testing.T) {
+	// SHA-256 of the public values with the top 3 bits masked, as computed by
+	// SP1PublicValues::hash_bn254.
+	for publicValues, expected := range map[string]string{
+		"":      "1669258166902426033910600439979403418188664065762541458854010994191676651605",
+		"hello": "5855867631771680560439387125935340174641676935550168723807105521669882222628",
+	} {
+		if digest := CommittedValuesDigest([]byte(publicValues)); digest != expected {
+			t.Fatalf("digest of %q: got %s, expected %s", publicValues, digest, expected)
+		}
+	}
+}
+
+func TestFittingParallelism(t *testing.T) {
+	const gib = 1 << 30
+	for _, c := range []struct {
+		available, required int64
+		expected            int
+	}{
+		{available: 64 * gib, required: 8 * gib, expected: 16},
+		{available: 16 * gib, required: 8 * gib, expected: 8},
+		// A single thread is suggested when even it does not fit.
+		{available: 8 * gib, required: 8 * gib, expected: 1},
+		{available: 4 * gib, required: 8 * gib, expected: 1},
+	} {
+		if threads := fittingParallelism(c.available, c.required, gib, 16); threads != c.expected {
+			t.Errorf("%d bytes available, %d required: got %d threads, expected %d", c.available, c.required, threads, c.expected)
+		}
+	}
+}
+
+func TestConformanceVectorsAreTampered(t *testing.T) {
+	proof := Proof{PublicInputs: [2]string{"12", "34"}, RawProof: "00112233"}
+	vectors, err := conformanceVectors(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vectors[0].Proof != proof.RawProof || vectors[0].PublicInputs != proof.PublicInputs {
+		t.Fatalf("the first vector is not the valid proof: %+v", vectors[0])
+	}
+	seen := make(map[string]bool)
+	for _, vector := range vectors {
+		key := vector.Proof + "/" + vector.PublicInputs[0] + "/" + vector.PublicInputs[1]
+		if seen[key] {
+			t.Errorf("vector %s repeats another one", vector.Name)
+		}
+		seen[key] = true
+	}
+	if _, err := conformanceVectors(Proof{PublicInputs: [2]string{"x", "34"}, RawProof: "00"}); err == nil {
+		t.Error("accepted an invalid vkey hash")
+	}
+}
+
+func TestCompareBench(t *testing.T) {
+	old := BenchResult{Constraints: 1000, ProveTimeMs: 200, PeakHeapBytes: 400, ProofBytes: 800}
+	new := BenchResult{Constraints: 900, ProveTimeMs: 300, PeakHeapBytes: 300, ProofBytes: 800}
+	delta := compareBench(old, new)
+	want := BenchDelta{
+		Constraints: -100, ConstraintsPercent: -10,
+		ProveTimeMs: 100, ProveTimePercent: 50,
+		PeakHeapBytes: -100, PeakHeapPercent: -25,
+	}
+	if delta != want {
+		t.Fatalf("got %+v, want %+v", delta, want)
+	}
+	if err := WriteBenchComparison("old", "new", "halo2", "", filepath.Join(t.TempDir(), "bench.json")); err == nil {
+		t.Error("accepted an unknown proof system")
+	}
+}
+
+func TestSolverStallIsReported(t *testing.T) {
+	var output bytes.Buffer
+	defaultOutput := stallOutput
+	stallOutput = &output
+	defer func() { stallOutput = defaultOutput }()
+
+	release := make(chan struct{})
+	stalled := func(_ *big.Int, _ []*big.Int, _ []*big.Int) error {
+		<-release
+		return nil
+	}
+	watch := newSolverWatch(nil, 20*time.Millisecond)
+	hint := watch.wrap("stalledHint", stalled)
+	returned := make(chan error)
+	go func() { returned <- hint(nil, make([]*big.Int, 2), make([]*big.Int, 1)) }()
+
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	if err := <-returned; err != nil {
+		t.Fatal(err)
+	}
+	watch.stop()
+
+	dump := output.String()
+	for _, expected := range []string{"Current hint: stalledHint, 2 inputs, 1 outputs", "Hints: 0 returned, 1 called", "goroutine "} {
+		if !strings.Contains(dump, expected) {
+			t.Errorf("the dump lacks %q:\n%s", expected, dump)
+		}
+	}
+	if strings.Count(dump, "WARNING") != 1 {
+		t.Errorf("the stall was not reported exactly once:\n%s", dump)
+	}
+}
+
+// BenchmarkHintWrappers measures the overhead the wrappers of proverHints add to a hint call, with
+// the solver's concurrency.
+func BenchmarkHintWrappers(b *testing.B) {
+	hint := func(_ *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		outputs[0].Set(inputs[0])
+		return nil
+	}
+	watch := newSolverWatch(nil, time.Hour)
+	defer watch.stop()
+	for _, c := range []struct {
+		name string
+		hint solver.Hint
+	}{
+		{"bare", hint},
+		{"recover", recoverHintPanics("hint", hint)},
+		{"recover and watch", watch.wrap("hint", recoverHintPanics("hint", hint))},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				inputs, outputs := []*big.Int{big.NewInt(1)}, []*big.Int{new(big.Int)}
+				for pb.Next() {
+					c.hint(nil, inputs, outputs)
+				}
+			})
+		})
+	}
+}
+
+type panickingHintCircuit struct {
+	X frontend.Variable
+}
+
+func panickingHint(_ *big.Int, _ []*big.Int, _ []*big.Int) error {
+	panic("hint failure")
+}
+
+func (c *panickingHintCircuit) Define(api frontend.API) error {
+	out, err := api.Compiler().NewHint(panickingHint, 1, c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(out[0], c.X)
+	return nil
+}
+
+func TestHintPanicsAreErrors(t *testing.T) {
+	solver.RegisterHint(panickingHint)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &panickingHintCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&panickingHintCircuit{X: 1}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hint panics in a worker goroutine of the solver, which would abort the test binary.
+	if _, err := proveGroth16(ccs, pk, fullWitness, nil); err == nil || !strings.Contains(err.Error(), "hint failure") {
+		t.Fatalf("expected the panic of the hint as an error, got %v", err)
+	}
+}
+
+func TestReplayTranscript(t *testing.T) {
+	dataDir := t.TempDir()
+	constraints := []Constraint{
+		{Opcode: "ImmV", Args: [][]string{{"s0"}, {"0"}}},
+		{Opcode: "ImmV", Args: [][]string{{"s1"}, {"0"}}},
+		{Opcode: "ImmV", Args: [][]string{{"s2"}, {"0"}}},
+		{Opcode: "Permute", Args: [][]string{{"s0"}, {"s1"}, {"s2"}}},
+		{Opcode: "WitnessF", Args: [][]string{{"a"}, {"0"}}},
+		{Opcode: "ImmF", Args: [][]string{{"b"}, {"4"}}},
+		{Opcode: "AssertEqF", Args: [][]string{{"a"}, {"b"}}},
+	}
+	data, err := json.Marshal(constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, constraintsJsonFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	witnessPath := filepath.Join(dataDir, "witness.json")
+	if err := os.WriteFile(witnessPath, []byte(`{"felts":["3"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var transcript strings.Builder
+	if err := ReplayTranscript(dataDir, witnessPath, &transcript); err != nil {
+		t.Fatal(err)
+	}
+	output := poseidon2.PermuteBn254([3]fr.Element{})
+	for _, expected := range []string{
+		"duplexing 1 (instruction 3, Permute)",
+		"out: [" + output[0].String() + " ",
+		"assertion failed (instruction 6, AssertEqF): 3 != 4",
+		"1 duplexings, 1 failed assertions",
+	} {
+		if !strings.Contains(transcript.String(), expected) {
+			t.Errorf("the transcript lacks %q:\n%s", expected, transcript.String())
+		}
+	}
+
+	x := babybear.Ext{3, 1, 4, 1}
+	if product := mulE(x, invE(x)); product != (babybear.Ext{1}) {
+		t.Errorf("x * x^-1 = %v", product)
+	}
+}
+
+// writeRoundTripInputs writes the constraints and witnesses of a small circuit proving that the
+// witnessed felt cubed is 8, with witnessed vars as public values. Groth16 only binds the public
+// inputs used by constraints, so the circuit must commit them like the wrap circuit does.
+func writeRoundTripInputs(t *testing.T, dataDir string) WitnessInput {
+	constraints := []byte(`[
+		{"opcode":"WitnessF","args":[["f0"],["0"]]},
+		{"opcode":"ImmF","args":[["f1"],["8"]]},
+		{"opcode":"MulF","args":[["f2"],["f0"],["f0"]]},
+		{"opcode":"MulF","args":[["f3"],["f2"],["f0"]]},
+		{"opcode":"AssertEqF","args":[["f3"],["f1"]]},
+		{"opcode":"WitnessV","args":[["v0"],["0"]]},
+		{"opcode":"WitnessV","args":[["v1"],["1"]]},
+		{"opcode":"CommitVkeyHash","args":[["v0"]]},
+		{"opcode":"CommitCommitedValuesDigest","args":[["v1"]]}
+	]`)
+	witnessInput := WitnessInput{
+		Vars:                  []string{"42", "43"},
+		Felts:                 []string{"2"},
+		VkeyHash:              "42",
+		CommittedValuesDigest: "43",
+		ShapeDigest:           ShapeDigest(constraints),
+	}
+	witness, err := json.Marshal(witnessInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, data := range map[string][]byte{constraintsJsonFile: constraints, plonkWitnessPath: witness, groth16WitnessPath: witness} {
+		if err := os.WriteFile(filepath.Join(dataDir, path), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return witnessInput
+}
+
+func TestProveVerifyRoundTrip(t *testing.T) {
+	// The builds and proves set these for the rest of the process.
+	t.Setenv("CONSTRAINTS_JSON", "")
+	t.Setenv("GROTH16", "")
+	t.Setenv("SP1_CIRCUIT_VERSION", "")
+
+	// A data dir named dev uses an unsafe SRS instead of downloading one.
+	dataDir := filepath.Join(t.TempDir(), "dev")
+	if err := os.Mkdir(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	witnessInput := writeRoundTripInputs(t, dataDir)
+
+	BuildPlonk(dataDir, "")
+	defer forgetPlonkArtifacts(dataDir)
+	proof := ProvePlonk(dataDir, filepath.Join(dataDir, plonkWitnessPath))
+	if err := VerifyPlonk(dataDir, proof.RawProof, witnessInput.VkeyHash, witnessInput.CommittedValuesDigest); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPlonk(dataDir, proof.RawProof, witnessInput.VkeyHash, "44"); err == nil {
+		t.Fatal("verified the PLONK proof against other public values")
+	}
+
+	BuildGroth16(dataDir, "")
+	defer forgetGroth16Artifacts()
+	forgetGroth16Artifacts()
+	proof = ProveGroth16(dataDir, filepath.Join(dataDir, groth16WitnessPath))
+	if err := VerifyGroth16(dataDir, proof.RawProof, witnessInput.VkeyHash, witnessInput.CommittedValuesDigest); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyGroth16(dataDir, proof.RawProof, witnessInput.VkeyHash, "44"); err == nil {
+		t.Fatal("verified the Groth16 proof against other public values")
+	}
+}
@@ -0,0 +1,130 @@
+package sp1
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/constraint/solver"
+)
+
+// solverStallTimeoutEnv enables the detection of stalled solvers: it is how long a hint may run
+// before the solver is reported as stalled, as a Go duration such as "5m". Hints take
+// microseconds, so a hint running for minutes is deadlocked or looping on its input. The
+// detection is off when it is unset or "0", as it records every hint call of every prove.
+const solverStallTimeoutEnv = "SP1_SOLVER_STALL_TIMEOUT"
+
+// stallOutput receives the diagnostics of stalled solvers.
+var stallOutput io.Writer = os.Stderr
+
+// solverWatch tracks the hints called while solving a witness, and dumps diagnostics when one of
+// them runs longer than the timeout. gnark has no hook into the solver itself: a solver stuck
+// outside of a hint goes unnoticed.
+type solverWatch struct {
+	cs      constraint.ConstraintSystem
+	timeout time.Duration
+
+	// The solver calls hints from several goroutines, millions of times: the running calls are
+	// kept in a sync.Map rather than behind a mutex.
+	calls    atomic.Int64
+	nextCall atomic.Int64
+	running  sync.Map
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+type hintCall struct {
+	name     string
+	start    time.Time
+	nInputs  int
+	nOutputs int
+	// Only read and written by the goroutine of the watch.
+	reported bool
+}
+
+func solverStallTimeout() time.Duration {
+	value := os.Getenv(solverStallTimeoutEnv)
+	if value == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		panic(fmt.Errorf("invalid %s %q: %w", solverStallTimeoutEnv, value, err))
+	}
+	return timeout
+}
+
+func newSolverWatch(cs constraint.ConstraintSystem, timeout time.Duration) *solverWatch {
+	w := &solverWatch{
+		cs:      cs,
+		timeout: timeout,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go w.watch()
+	return w
+}
+
+// wrap returns hint, recording its calls in the watch.
+func (w *solverWatch) wrap(name string, hint solver.Hint) solver.Hint {
+	return func(field *big.Int, inputs []*big.Int, outputs []*big.Int) error {
+		id := w.nextCall.Add(1)
+		w.running.Store(id, &hintCall{name: name, start: time.Now(), nInputs: len(inputs), nOutputs: len(outputs)})
+		defer func() {
+			w.running.Delete(id)
+			w.calls.Add(1)
+		}()
+		return hint(field, inputs, outputs)
+	}
+}
+
+func (w *solverWatch) watch() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(max(w.timeout/4, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check dumps the diagnostics once for every hint call running longer than the timeout.
+func (w *solverWatch) check() {
+	w.running.Range(func(_, value any) bool {
+		call := value.(*hintCall)
+		if !call.reported && time.Since(call.start) >= w.timeout {
+			call.reported = true
+			w.dump(call)
+		}
+		return true
+	})
+}
+
+func (w *solverWatch) dump(call *hintCall) {
+	fmt.Fprintf(stallOutput, "WARNING: the solver made no progress for %s\n", time.Since(call.start).Round(time.Second))
+	fmt.Fprintf(stallOutput, "Current hint: %s, %d inputs, %d outputs\n", call.name, call.nInputs, call.nOutputs)
+	fmt.Fprintf(stallOutput, "Hints: %d returned, %d called\n", w.calls.Load(), w.nextCall.Load())
+	if w.cs != nil {
+		fmt.Fprintf(
+			stallOutput, "Circuit: %d constraints, %d public, %d secret and %d internal variables\n",
+			w.cs.GetNbConstraints(), w.cs.GetNbPublicVariables(), w.cs.GetNbSecretVariables(), w.cs.GetNbInternalVariables(),
+		)
+	}
+	fmt.Fprintln(stallOutput, "Goroutines:")
+	pprof.Lookup("goroutine").WriteTo(stallOutput, 2)
+}
+
+func (w *solverWatch) stop() {
+	close(w.done)
+	<-w.stopped
+}
@@ -0,0 +1,134 @@
+package sp1
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/field"
+)
+
+var constraintStatsPath string = "constraint_stats.json"
+
+// scopeProfileEnv names the file to which the builds write the constraints of the circuit broken
+// down by gadget scope, in the pprof format of gnark/profile, see field.ScopeProfile.
+const scopeProfileEnv = "SP1_SCOPE_PROFILE"
+
+// OpcodeStats are the number of instructions of an opcode in constraints.json and the number of
+// constraints they add to the circuit.
+type OpcodeStats struct {
+	Count       int `json:"count"`
+	Constraints int `json:"constraints"`
+}
+
+// CircuitStats break the constraints of the circuit down by opcode. Witness counts the range
+// checks of the witnessed felts and exts, and Deferred the constraints added after Define, such
+// as the batched range checks of the PLONK range checker, which cannot be attributed.
+type CircuitStats struct {
+	Opcodes  map[string]*OpcodeStats `json:"opcodes"`
+	Witness  int                     `json:"witness"`
+	Deferred int                     `json:"deferred"`
+	Total    int                     `json:"total"`
+
+	// Expected is the total of the previous build, if any, against which progress is reported.
+	Expected   int       `json:"-"`
+	lastReport time.Time `json:"-"`
+}
+
+// progressInterval is the minimum delay between two progress reports while defining the circuit.
+var progressInterval = 10 * time.Second
+
+// newCircuitStats returns empty stats, expecting the total of the previous build in dataDir.
+func newCircuitStats(dataDir string) *CircuitStats {
+	stats := &CircuitStats{Opcodes: make(map[string]*OpcodeStats), lastReport: time.Now()}
+	if data, err := os.ReadFile(dataDir + "/" + constraintStatsPath); err == nil {
+		var previous CircuitStats
+		if json.Unmarshal(data, &previous) == nil {
+			stats.Expected = previous.Total
+		}
+	}
+	return stats
+}
+
+func (s *CircuitStats) record(opcode string, constraints int) {
+	stats, ok := s.Opcodes[opcode]
+	if !ok {
+		stats = &OpcodeStats{}
+		s.Opcodes[opcode] = stats
+	}
+	stats.Count++
+	stats.Constraints += constraints
+}
+
+// progress reports how many of the instructions have been defined and the constraints emitted so
+// far, at most once per progressInterval, so that a slow compilation can be told apart from a hung
+// one. The constraints added after Define are not included. gnark solves the witness in a single
+// call without any hook, so proving cannot report progress the same way.
+func (s *CircuitStats) progress(done int, total int, constraints int) {
+	if time.Since(s.lastReport) < progressInterval && done != total {
+		return
+	}
+	s.lastReport = time.Now()
+	expected := ""
+	if s.Expected > 0 {
+		expected = fmt.Sprintf(" of about %d", s.Expected)
+	}
+	fmt.Printf("Defined %d/%d instructions, %d constraints%s\n", done, total, constraints, expected)
+}
+
+// finish sets the totals once the circuit is compiled to nbConstraints constraints.
+func (s *CircuitStats) finish(nbConstraints int) {
+	s.Total = nbConstraints
+	s.Deferred = nbConstraints - s.Witness
+	for _, stats := range s.Opcodes {
+		s.Deferred -= stats.Constraints
+	}
+}
+
+// write writes the stats to the data dir and prints the opcodes adding the most constraints.
+func (s *CircuitStats) write(dataDir string) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(dataDir+"/"+constraintStatsPath, data, 0644); err != nil {
+		panic(err)
+	}
+
+	opcodes := make([]string, 0, len(s.Opcodes))
+	for opcode := range s.Opcodes {
+		opcodes = append(opcodes, opcode)
+	}
+	sort.Slice(opcodes, func(i, j int) bool {
+		return s.Opcodes[opcodes[i]].Constraints > s.Opcodes[opcodes[j]].Constraints
+	})
+	fmt.Printf("Constraints: %d total, %d witness range checks, %d deferred\n", s.Total, s.Witness, s.Deferred)
+	for _, opcode := range opcodes[:min(len(opcodes), 10)] {
+		stats := s.Opcodes[opcode]
+		fmt.Printf("  %-28s %10d constraints %8d instructions\n", opcode, stats.Constraints, stats.Count)
+	}
+}
+
+// startScopeProfile starts a scope profile if SP1_SCOPE_PROFILE is set, and returns the function
+// stopping it and writing it once the circuit is compiled.
+func startScopeProfile() func() {
+	path := os.Getenv(scopeProfileEnv)
+	if path == "" {
+		return func() {}
+	}
+	profile := field.StartScopeProfile()
+	return func() {
+		profile.Stop()
+		file, err := os.Create(path)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		if err := profile.Write(file); err != nil {
+			panic(err)
+		}
+		fmt.Printf("Wrote the constraints by gadget scope to %s, see go tool pprof -top %s\n", path, path)
+	}
+}
@@ -0,0 +1,158 @@
+package sp1
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// supportBundleLogLines is the number of audit log entries included in a support bundle.
+const supportBundleLogLines = 1000
+
+// WriteSupportBundle writes a gzipped tarball to outputPath with what is needed to act on a bug
+// report against the circuit in dataDir: the environment, the sizes and digests of the artifacts,
+// the FRI config, vkey registry and constraint stats, per-opcode constraint counts and the tail of
+// the audit log. Witnesses and keys are never included, and environment values that look like
+// secrets are redacted.
+func WriteSupportBundle(dataDir string, outputPath string) error {
+	files := make(map[string][]byte)
+
+	environment, err := json.MarshalIndent(supportEnvironment(), "", "  ")
+	if err != nil {
+		return err
+	}
+	files["environment.json"] = environment
+
+	artifacts, err := artifactDigests(dataDir)
+	if err != nil {
+		return err
+	}
+	if files["artifacts.json"], err = json.MarshalIndent(artifacts, "", "  "); err != nil {
+		return err
+	}
+
+	for _, name := range []string{friConfigPath, vkeyRegistryPath, constraintStatsPath} {
+		if data, err := os.ReadFile(filepath.Join(dataDir, name)); err == nil {
+			files[name] = data
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dataDir, constraintsJsonFile)); err == nil {
+		var constraints []Constraint
+		if err := json.Unmarshal(data, &constraints); err == nil {
+			counts := make(map[string]int)
+			for _, constraint := range constraints {
+				counts[constraint.Opcode]++
+			}
+			if files["constraint_counts.json"], err = json.MarshalIndent(counts, "", "  "); err != nil {
+				return err
+			}
+		}
+	}
+
+	if path := os.Getenv(auditLogEnv); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			lines := strings.SplitAfter(string(data), "\n")
+			if len(lines) > supportBundleLogLines {
+				lines = lines[len(lines)-supportBundleLogLines:]
+			}
+			files["audit.jsonl"] = []byte(strings.Join(lines, ""))
+		}
+	}
+
+	return writeTarGz(outputPath, "sp1-support-bundle/", files)
+}
+
+func supportEnvironment() map[string]any {
+	variables := make(map[string]string)
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, "SP1_") && name != "GROTH16" && name != "CONSTRAINTS_JSON" && name != "FRI_QUERIES" {
+			continue
+		}
+		upper := strings.ToUpper(name)
+		for _, secret := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "CREDENTIAL"} {
+			if strings.Contains(upper, secret) {
+				value = "<redacted>"
+			}
+		}
+		variables[name] = value
+	}
+
+	environment := map[string]any{
+		"time":       time.Now().UTC(),
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"num_cpu":    runtime.NumCPU(),
+		"variables":  variables,
+	}
+	if available, err := availableMemory(); err == nil {
+		environment["available_memory"] = available
+	}
+	return environment
+}
+
+type artifactInfo struct {
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+func artifactDigests(dataDir string) (map[string]artifactInfo, error) {
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	artifacts := make(map[string]artifactInfo)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		digest, err := fileDigest(filepath.Join(dataDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		artifacts[entry.Name()] = artifactInfo{Size: info.Size(), Sha256: digest}
+	}
+	return artifacts, nil
+}
+
+// writeTarGz writes files to outputPath as a gzipped tarball, under the directory prefix.
+func writeTarGz(outputPath string, prefix string, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		header := &tar.Header{Name: prefix + name, Mode: 0644, Size: int64(len(files[name]))}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, buf.Bytes(), 0644)
+}
@@ -1,6 +1,10 @@
 package sp1
 
 import (
+	"bytes"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/frontend"
 	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
 	"github.com/succinctlabs/sp1-recursion-gnark/sp1/poseidon2"
@@ -29,3 +33,35 @@ func (circuit *TestPoseidon2BabyBearCircuit) Define(api frontend.API) error {
 
 	return nil
 }
+
+// CheckDeterministicCompile compiles the circuit returned by newCircuit twice in the same process
+// and checks that both constraint systems serialize to the same bytes. newCircuit is called once
+// per compilation, so it can hand out different (e.g. randomized) witness values to show they do
+// not leak into the circuit. A mismatch usually means map iteration or pointer ordering leaked
+// into the constraint order, which would make vkeys irreproducible.
+func CheckDeterministicCompile(newBuilder frontend.NewBuilder, newCircuit func() frontend.Circuit) error {
+	var serialized [2][]byte
+	for i := range serialized {
+		cs, err := frontend.Compile(ecc.BN254.ScalarField(), newBuilder, newCircuit())
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if _, err := cs.WriteTo(&buf); err != nil {
+			return err
+		}
+		serialized[i] = buf.Bytes()
+	}
+
+	if !bytes.Equal(serialized[0], serialized[1]) {
+		offset := 0
+		for offset < len(serialized[0]) && offset < len(serialized[1]) && serialized[0][offset] == serialized[1][offset] {
+			offset++
+		}
+		return fmt.Errorf(
+			"compilation is not deterministic: serialized constraint systems of %d and %d bytes differ at byte %d",
+			len(serialized[0]), len(serialized[1]), offset,
+		)
+	}
+	return nil
+}
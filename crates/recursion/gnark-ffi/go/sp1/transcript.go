@@ -0,0 +1,416 @@
+package sp1
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/babybear"
+	"github.com/succinctlabs/sp1-recursion-gnark/sp1/poseidon2"
+)
+
+// challengerFeltsPerVar is the number of felts the outer challenger samples from a state element,
+// one per 64 bits, see split_32 in sp1-recursion-circuit.
+const challengerFeltsPerVar = 3
+
+const babybearModulus = 2013265921
+
+// ReplayTranscript evaluates the constraints of dataDir on the witness at witnessPath outside of
+// the circuit and writes the Fiat-Shamir transcript to w: the state going into and coming out of
+// every duplexing of the challenger, i.e. every Permute or PermuteBabyBear instruction, with the
+// felts the outer challenger samples from it in the order sample returns them. The challenger
+// compiles to plain instructions, so observations appear as the state they are absorbed into.
+// Prints and failed assertions are written in between, so that a transcript differing from the
+// one of the Rust prover can be narrowed down to the first step that differs.
+func ReplayTranscript(dataDir string, witnessPath string, w io.Writer) error {
+	data, err := os.ReadFile(dataDir + "/" + constraintsJsonFile)
+	if err != nil {
+		return err
+	}
+	var constraints []Constraint
+	if err := json.Unmarshal(data, &constraints); err != nil {
+		return fmt.Errorf("error deserializing JSON: %v", err)
+	}
+	witnessData, err := os.ReadFile(witnessPath)
+	if err != nil {
+		return err
+	}
+	var witness WitnessInput
+	if err := json.Unmarshal(witnessData, &witness); err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	r := transcriptReplay{
+		witness: witness,
+		out:     out,
+		vars:    make(map[string]fr.Element),
+		felts:   make(map[string]uint32),
+		exts:    make(map[string]babybear.Ext),
+	}
+	for i, cs := range constraints {
+		if err := r.step(i, cs); err != nil {
+			return fmt.Errorf("instruction %d (%s): %w", i, cs.Opcode, err)
+		}
+	}
+	fmt.Fprintf(out, "%d duplexings, %d failed assertions\n", r.duplexings, r.failures)
+	return nil
+}
+
+// ReplayTranscriptFile writes the transcript of the witness at witnessPath to outputPath, see
+// ReplayTranscript.
+func ReplayTranscriptFile(dataDir string, witnessPath string, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return ReplayTranscript(dataDir, witnessPath, file)
+}
+
+type transcriptReplay struct {
+	witness    WitnessInput
+	out        io.Writer
+	vars       map[string]fr.Element
+	felts      map[string]uint32
+	exts       map[string]babybear.Ext
+	duplexings int
+	failures   int
+}
+
+func (r *transcriptReplay) step(i int, cs Constraint) error {
+	arg := func(j int) string { return cs.Args[j][0] }
+	switch cs.Opcode {
+	case "ImmV":
+		var v fr.Element
+		if _, err := v.SetString(arg(1)); err != nil {
+			return err
+		}
+		r.vars[arg(0)] = v
+	case "ImmF":
+		v, err := strconv.ParseUint(arg(1), 10, 64)
+		if err != nil {
+			return err
+		}
+		r.felts[arg(0)] = uint32(v % babybearModulus)
+	case "ImmE":
+		e, err := babybear.ParseExt(cs.Args[1])
+		if err != nil {
+			return err
+		}
+		r.exts[arg(0)] = e
+	case "AddV", "SubV", "MulV":
+		a, b := r.vars[arg(1)], r.vars[arg(2)]
+		var v fr.Element
+		switch cs.Opcode {
+		case "AddV":
+			v.Add(&a, &b)
+		case "SubV":
+			v.Sub(&a, &b)
+		default:
+			v.Mul(&a, &b)
+		}
+		r.vars[arg(0)] = v
+	case "AddF":
+		r.felts[arg(0)] = addF(r.felts[arg(1)], r.felts[arg(2)])
+	case "SubF":
+		r.felts[arg(0)] = subF(r.felts[arg(1)], r.felts[arg(2)])
+	case "MulF":
+		r.felts[arg(0)] = mulF(r.felts[arg(1)], r.felts[arg(2)])
+	case "DivF":
+		r.checkNonZero(i, cs, r.felts[arg(2)] == 0)
+		r.felts[arg(0)] = mulF(r.felts[arg(1)], invF(r.felts[arg(2)]))
+	case "AddE":
+		r.exts[arg(0)] = addE(r.exts[arg(1)], r.exts[arg(2)])
+	case "SubE":
+		r.exts[arg(0)] = addE(r.exts[arg(1)], negE(r.exts[arg(2)]))
+	case "MulE":
+		r.exts[arg(0)] = mulE(r.exts[arg(1)], r.exts[arg(2)])
+	case "DivE":
+		r.checkNonZero(i, cs, r.exts[arg(2)] == babybear.Ext{})
+		r.exts[arg(0)] = mulE(r.exts[arg(1)], invE(r.exts[arg(2)]))
+	case "AddEF":
+		r.exts[arg(0)] = addE(r.exts[arg(1)], babybear.Ext{r.felts[arg(2)]})
+	case "SubEF":
+		r.exts[arg(0)] = addE(r.exts[arg(1)], negE(babybear.Ext{r.felts[arg(2)]}))
+	case "MulEF":
+		r.exts[arg(0)] = mulE(r.exts[arg(1)], babybear.Ext{r.felts[arg(2)]})
+	case "DivEF":
+		r.checkNonZero(i, cs, r.felts[arg(2)] == 0)
+		r.exts[arg(0)] = mulE(r.exts[arg(1)], babybear.Ext{invF(r.felts[arg(2)])})
+	case "NegE":
+		r.exts[arg(0)] = negE(r.exts[arg(1)])
+	case "InvE":
+		r.checkNonZero(i, cs, r.exts[arg(1)] == babybear.Ext{})
+		r.exts[arg(0)] = invE(r.exts[arg(1)])
+	case "Num2BitsV":
+		v := r.vars[arg(1)]
+		var value big.Int
+		v.BigInt(&value)
+		numBits, err := strconv.Atoi(arg(2))
+		if err != nil {
+			return err
+		}
+		if value.BitLen() > numBits {
+			r.fail(i, cs, fmt.Sprintf("%s does not fit in %d bits", value.String(), numBits))
+		}
+		for j, name := range cs.Args[0] {
+			r.vars[name] = *new(fr.Element).SetUint64(uint64(value.Bit(j)))
+		}
+	case "Num2BitsF":
+		v := r.felts[arg(1)]
+		for j, name := range cs.Args[0] {
+			r.vars[name] = *new(fr.Element).SetUint64(uint64(v>>j) & 1)
+		}
+	case "Permute":
+		var state [3]fr.Element
+		for j := range state {
+			state[j] = r.vars[arg(j)]
+		}
+		output := poseidon2.PermuteBn254(state)
+		for j := range output {
+			r.vars[arg(j)] = output[j]
+		}
+		r.logPermute(i, state, output)
+	case "PermuteBabyBear":
+		var state [poseidon2.BABYBEAR_WIDTH]uint32
+		for j := range state {
+			state[j] = r.felts[arg(j)]
+		}
+		output := poseidon2.PermuteBabyBear(state)
+		for j := range output {
+			r.felts[arg(j)] = output[j]
+		}
+		r.duplexings++
+		fmt.Fprintf(r.out, "duplexing %d (instruction %d, PermuteBabyBear)\n", r.duplexings, i)
+		fmt.Fprintf(r.out, "  in:  %v\n", state)
+		fmt.Fprintf(r.out, "  out: %v\n", output)
+	case "SelectV":
+		if r.isTrue(arg(1)) {
+			r.vars[arg(0)] = r.vars[arg(2)]
+		} else {
+			r.vars[arg(0)] = r.vars[arg(3)]
+		}
+	case "SelectF":
+		if r.isTrue(arg(1)) {
+			r.felts[arg(0)] = r.felts[arg(2)]
+		} else {
+			r.felts[arg(0)] = r.felts[arg(3)]
+		}
+	case "SelectE":
+		if r.isTrue(arg(1)) {
+			r.exts[arg(0)] = r.exts[arg(2)]
+		} else {
+			r.exts[arg(0)] = r.exts[arg(3)]
+		}
+	case "Ext2Felt":
+		e := r.exts[arg(4)]
+		for j := 0; j < 4; j++ {
+			r.felts[arg(j)] = e[j]
+		}
+	case "AssertEqV":
+		a, b := r.vars[arg(0)], r.vars[arg(1)]
+		if !a.Equal(&b) {
+			r.fail(i, cs, fmt.Sprintf("%s != %s", a.String(), b.String()))
+		}
+	case "AssertEqF":
+		if a, b := r.felts[arg(0)], r.felts[arg(1)]; a != b {
+			r.fail(i, cs, fmt.Sprintf("%d != %d", a, b))
+		}
+	case "AssertNeF":
+		if a, b := r.felts[arg(0)], r.felts[arg(1)]; a == b {
+			r.fail(i, cs, fmt.Sprintf("%d == %d", a, b))
+		}
+	case "AssertEqE":
+		if a, b := r.exts[arg(0)], r.exts[arg(1)]; a != b {
+			r.fail(i, cs, fmt.Sprintf("%v != %v", a, b))
+		}
+	case "PrintV":
+		v := r.vars[arg(0)]
+		fmt.Fprintf(r.out, "print (instruction %d): %s\n", i, v.String())
+	case "PrintF":
+		fmt.Fprintf(r.out, "print (instruction %d): %d\n", i, r.felts[arg(0)])
+	case "PrintE":
+		fmt.Fprintf(r.out, "print (instruction %d): %v\n", i, r.exts[arg(0)])
+	case "WitnessV":
+		j, err := witnessIndex(arg(1), len(r.witness.Vars))
+		if err != nil {
+			return err
+		}
+		var v fr.Element
+		if _, err := v.SetString(r.witness.Vars[j]); err != nil {
+			return err
+		}
+		r.vars[arg(0)] = v
+	case "WitnessF":
+		j, err := witnessIndex(arg(1), len(r.witness.Felts))
+		if err != nil {
+			return err
+		}
+		v, err := babybear.ParseF(r.witness.Felts[j])
+		if err != nil {
+			return err
+		}
+		r.felts[arg(0)] = v
+	case "WitnessE":
+		j, err := witnessIndex(arg(1), len(r.witness.Exts))
+		if err != nil {
+			return err
+		}
+		e, err := babybear.ParseExt(r.witness.Exts[j])
+		if err != nil {
+			return err
+		}
+		r.exts[arg(0)] = e
+	case "CommitVkeyHash":
+		r.checkCommitment(i, cs, r.witness.VkeyHash)
+	case "CommitCommitedValuesDigest":
+		r.checkCommitment(i, cs, r.witness.CommittedValuesDigest)
+	case "CircuitFelts2Ext":
+		r.exts[arg(0)] = babybear.Ext{r.felts[arg(1)], r.felts[arg(2)], r.felts[arg(3)], r.felts[arg(4)]}
+	case "CircuitFelt2Var":
+		r.vars[arg(0)] = *new(fr.Element).SetUint64(uint64(r.felts[arg(1)]))
+	case "ReduceE":
+	default:
+		return fmt.Errorf("unhandled opcode: %s", cs.Opcode)
+	}
+	return nil
+}
+
+// logPermute writes a duplexing of the outer challenger, with the felts split_32 samples from the
+// output state in the order sample pops them.
+func (r *transcriptReplay) logPermute(i int, state [3]fr.Element, output [3]fr.Element) {
+	r.duplexings++
+	fmt.Fprintf(r.out, "duplexing %d (instruction %d, Permute)\n", r.duplexings, i)
+	fmt.Fprintf(r.out, "  in:  %s\n", formatVars(state[:]))
+	fmt.Fprintf(r.out, "  out: %s\n", formatVars(output[:]))
+
+	var samples []uint32
+	for _, v := range output {
+		var value big.Int
+		v.BigInt(&value)
+		for j := 0; j < challengerFeltsPerVar; j++ {
+			limb := new(big.Int).Rsh(&value, uint(64*j))
+			limb.And(limb, new(big.Int).SetUint64(^uint64(0)))
+			samples = append(samples, uint32(limb.Uint64()%babybearModulus))
+		}
+	}
+	for left, right := 0, len(samples)-1; left < right; left, right = left+1, right-1 {
+		samples[left], samples[right] = samples[right], samples[left]
+	}
+	fmt.Fprintf(r.out, "  samples: %v\n", samples)
+}
+
+func (r *transcriptReplay) isTrue(name string) bool {
+	v := r.vars[name]
+	return !v.IsZero()
+}
+
+func (r *transcriptReplay) checkCommitment(i int, cs Constraint, expected string) {
+	v := r.vars[cs.Args[0][0]]
+	var e fr.Element
+	if _, err := e.SetString(expected); err != nil || !v.Equal(&e) {
+		r.fail(i, cs, fmt.Sprintf("%s != %s", v.String(), expected))
+	}
+}
+
+// checkNonZero reports a division by zero, which makes the circuit unsatisfiable.
+func (r *transcriptReplay) checkNonZero(i int, cs Constraint, zero bool) {
+	if zero {
+		r.fail(i, cs, "division by zero")
+	}
+}
+
+func (r *transcriptReplay) fail(i int, cs Constraint, message string) {
+	r.failures++
+	fmt.Fprintf(r.out, "assertion failed (instruction %d, %s): %s\n", i, cs.Opcode, message)
+}
+
+func witnessIndex(index string, n int) (int, error) {
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return 0, fmt.Errorf("invalid witness index %q: %w", index, err)
+	}
+	if i < 0 || i >= n {
+		return 0, fmt.Errorf("witness index %d out of range, the witness declares %d", i, n)
+	}
+	return i, nil
+}
+
+func formatVars(vars []fr.Element) string {
+	formatted := make([]string, len(vars))
+	for i := range vars {
+		formatted[i] = vars[i].String()
+	}
+	return "[" + strings.Join(formatted, " ") + "]"
+}
+
+func addF(a, b uint32) uint32 {
+	return uint32((uint64(a) + uint64(b)) % babybearModulus)
+}
+
+func subF(a, b uint32) uint32 {
+	return uint32((uint64(a) + babybearModulus - uint64(b)) % babybearModulus)
+}
+
+func mulF(a, b uint32) uint32 {
+	return uint32(uint64(a) * uint64(b) % babybearModulus)
+}
+
+func invF(a uint32) uint32 {
+	result, base := uint32(1), a
+	for exponent := uint32(babybearModulus - 2); exponent > 0; exponent >>= 1 {
+		if exponent&1 == 1 {
+			result = mulF(result, base)
+		}
+		base = mulF(base, base)
+	}
+	return result
+}
+
+func addE(a, b babybear.Ext) babybear.Ext {
+	return babybear.Ext{addF(a[0], b[0]), addF(a[1], b[1]), addF(a[2], b[2]), addF(a[3], b[3])}
+}
+
+func negE(a babybear.Ext) babybear.Ext {
+	return babybear.Ext{subF(0, a[0]), subF(0, a[1]), subF(0, a[2]), subF(0, a[3])}
+}
+
+// mulE multiplies in BabyBear[X]/(X^4 - 11).
+func mulE(a, b babybear.Ext) babybear.Ext {
+	w := uint32(babybear.Params{}.ExtW())
+	var result babybear.Ext
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			product := mulF(a[i], b[j])
+			if i+j >= 4 {
+				result[i+j-4] = addF(result[i+j-4], mulF(w, product))
+			} else {
+				result[i+j] = addF(result[i+j], product)
+			}
+		}
+	}
+	return result
+}
+
+// invE returns a^(p^4 - 2), the inverse of a non-zero a.
+func invE(a babybear.Ext) babybear.Ext {
+	p := big.NewInt(babybearModulus)
+	exponent := new(big.Int).Exp(p, big.NewInt(4), nil)
+	exponent.Sub(exponent, big.NewInt(2))
+	result := babybear.Ext{1}
+	for i := exponent.BitLen() - 1; i >= 0; i-- {
+		result = mulE(result, result)
+		if exponent.Bit(i) == 1 {
+			result = mulE(result, a)
+		}
+	}
+	return result
+}
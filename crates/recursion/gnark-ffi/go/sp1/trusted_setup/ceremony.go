@@ -0,0 +1,128 @@
+package trusted_setup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+)
+
+// The public ceremonies the SRS of the PLONK circuit can be derived from.
+const (
+	AztecIgnition = "aztec-ignition"
+	EthereumKZG   = "ethereum-kzg"
+	// Unsafe is the SRS generated locally for dev circuits, whose secret is known.
+	Unsafe = "unsafe"
+	// Imported is an SRS read from a file. Its structure is validated, but not which ceremony it
+	// comes from.
+	Imported = "imported"
+	// Unknown is an SRS derived by a build that did not record its provenance.
+	Unknown = "unknown"
+)
+
+// aztecIgnitionStartIdx is the first contribution whose transcript is downloaded and verified.
+const aztecIgnitionStartIdx = 174
+
+// Provenance records where the SRS of a circuit comes from.
+type Provenance struct {
+	Ceremony string `json:"ceremony"`
+	// FirstContribution is the first Aztec Ignition contribution that was verified.
+	FirstContribution int `json:"first_contribution,omitempty"`
+	// ImportedFrom is the file an imported SRS was read from.
+	ImportedFrom string `json:"imported_from,omitempty"`
+	NbG1Points   int    `json:"nb_g1_points"`
+}
+
+// SaveSRS writes the SRS of source to fileName and returns its provenance. The source is either
+// AztecIgnition, whose transcripts are downloaded and verified, or the path of a serialized BN254
+// SRS, e.g. derived from Aztec Ignition on another machine. The SRS is validated in both cases,
+// but an imported SRS is only checked to be made of powers of a single secret, so its provenance
+// is Imported rather than the ceremony it claims to come from.
+func SaveSRS(source string, fileName string) (Provenance, error) {
+	var srs *kzg_bn254.SRS
+	var provenance Provenance
+	switch source {
+	case AztecIgnition:
+		srs = DownloadAztecIgnitionSrs(aztecIgnitionStartIdx)
+		provenance.Ceremony = AztecIgnition
+		provenance.FirstContribution = aztecIgnitionStartIdx
+	case EthereumKZG:
+		return Provenance{}, errors.New("the Ethereum KZG ceremony is over BLS12-381, its SRS cannot be used with the BN254 circuit")
+	default:
+		var err error
+		if srs, err = readSRS(source); err != nil {
+			return Provenance{}, err
+		}
+		provenance.Ceremony = Imported
+		provenance.ImportedFrom = source
+	}
+	if err := ValidateSRS(srs); err != nil {
+		return Provenance{}, fmt.Errorf("invalid SRS from %s: %w", source, err)
+	}
+	provenance.NbG1Points = len(srs.Pk.G1)
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return Provenance{}, err
+	}
+	defer file.Close()
+	if _, err := srs.WriteTo(file); err != nil {
+		return Provenance{}, err
+	}
+	return provenance, nil
+}
+
+func readSRS(path string) (*kzg_bn254.SRS, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var srs kzg_bn254.SRS
+	if _, err := srs.ReadFrom(file); err != nil {
+		return nil, fmt.Errorf("reading the SRS %s: %w", path, err)
+	}
+	return &srs, nil
+}
+
+// ValidateSRS checks that the points of srs are the successive powers of a single secret τ, i.e.
+// that [τ^(i+1)]G₁ = τ·[τ^i]G₁ for all i, against [τ]G₂ of its verifying key. The powers are
+// checked at once with a random linear combination:
+// e(Σ rᵢ[τ^(i+1)]G₁, G₂) = e(Σ rᵢ[τ^i]G₁, [τ]G₂).
+func ValidateSRS(srs *kzg_bn254.SRS) error {
+	g1 := srs.Pk.G1
+	if len(g1) < 2 {
+		return errors.New("the SRS has less than 2 points")
+	}
+	_, _, g1Gen, g2Gen := bn254.Generators()
+	if !g1[0].Equal(&g1Gen) || !srs.Vk.G1.Equal(&g1Gen) || !srs.Vk.G2[0].Equal(&g2Gen) {
+		return errors.New("the SRS does not start with the generators")
+	}
+
+	scalars := make([]fr.Element, len(g1)-1)
+	for i := range scalars {
+		if _, err := scalars[i].SetRandom(); err != nil {
+			return err
+		}
+	}
+	var shifted, powers bn254.G1Affine
+	if _, err := shifted.MultiExp(g1[1:], scalars, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	if _, err := powers.MultiExp(g1[:len(g1)-1], scalars, ecc.MultiExpConfig{}); err != nil {
+		return err
+	}
+	powers.Neg(&powers)
+	ok, err := bn254.PairingCheck([]bn254.G1Affine{shifted, powers}, []bn254.G2Affine{srs.Vk.G2[0], srs.Vk.G2[1]})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the points of the SRS are not successive powers of the same secret")
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package trusted_setup
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/kzg"
+	"github.com/consensys/gnark/test"
+)
+
+func TestValidateSRS(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	srs, err := kzg_bn254.NewSRS(64, big.NewInt(42))
+	assert.NoError(err)
+	assert.NoError(ValidateSRS(srs))
+
+	// Import a serialized SRS. Nothing proves it comes from a ceremony: this one has the secret 42.
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.bin")
+	file, err := os.Create(source)
+	assert.NoError(err)
+	_, err = srs.WriteTo(file)
+	assert.NoError(err)
+	assert.NoError(file.Close())
+	provenance, err := SaveSRS(source, filepath.Join(dir, "srs.bin"))
+	assert.NoError(err)
+	assert.Equal(Provenance{Ceremony: Imported, ImportedFrom: source, NbG1Points: len(srs.Pk.G1)}, provenance)
+
+	srs.Pk.G1[10] = srs.Pk.G1[11]
+	assert.Error(ValidateSRS(srs))
+
+	_, err = SaveSRS(EthereumKZG, filepath.Join(dir, "srs.bin"))
+	assert.Error(err)
+}
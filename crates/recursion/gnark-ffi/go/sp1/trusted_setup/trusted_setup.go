@@ -67,6 +67,26 @@ func eval(p []fr.Element, point fr.Element) fr.Element {
 }
 
 func DownloadAndSaveAztecIgnitionSrs(startIdx int, fileName string) {
+	srs := DownloadAztecIgnitionSrs(startIdx)
+
+	fSRS, err := os.Create(fileName)
+	if err != nil {
+		log.Fatal("error creating srs file: ", err)
+		panic(err)
+	}
+	defer fSRS.Close()
+
+	_, err = srs.WriteTo(fSRS)
+	if err != nil {
+		log.Fatal("error writing srs file: ", err)
+		panic(err)
+	}
+}
+
+// DownloadAztecIgnitionSrs downloads the Aztec Ignition transcripts from the contribution
+// startIdx on, checks that each contribution follows the previous one, and returns the SRS of the
+// last one.
+func DownloadAztecIgnitionSrs(startIdx int) *kzg_bn254.SRS {
 	config := ignition.Config{
 		BaseURL:  "https://aztec-ignition.s3.amazonaws.com/",
 		Ceremony: "MAIN IGNITION", // "TINY_TEST_5"
@@ -129,23 +149,14 @@ func DownloadAndSaveAztecIgnitionSrs(startIdx int, fileName string) {
 			},
 		},
 	}
+	srs.Vk.Lines[0] = bn254.PrecomputeLines(srs.Vk.G2[0])
+	srs.Vk.Lines[1] = bn254.PrecomputeLines(srs.Vk.G2[1])
 
 	// sanity check
 	sanityCheck(&srs)
 	log.Println("success ✅: kzg sanity check with SRS")
 
-	fSRS, err := os.Create(fileName)
-	if err != nil {
-		log.Fatal("error creating srs file: ", err)
-		panic(err)
-	}
-	defer fSRS.Close()
-
-	_, err = srs.WriteTo(fSRS)
-	if err != nil {
-		log.Fatal("error writing srs file: ", err)
-		panic(err)
-	}
+	return &srs
 }
 
 func ToLagrange(scs constraint.ConstraintSystem, canonicalSRS kzg.SRS) kzg.SRS {
@@ -13,6 +13,11 @@ import (
 )
 
 func VerifyPlonk(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkeyHash string, verifyCmdCommittedValuesDigest string) error {
+	audit := startAudit("verify", "plonk")
+	audit.VkeyHash = verifyCmdVkeyHash
+	audit.CommittedValuesDigest = verifyCmdCommittedValuesDigest
+	defer audit.finishOnPanic()
+
 	// Sanity check the required arguments have been provided.
 	if verifyCmdDataDir == "" {
 		panic("--data is required")
@@ -23,6 +28,7 @@ func VerifyPlonk(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkeyHa
 	if err != nil {
 		panic(err)
 	}
+	audit.ProofDigest = digest(proofDecodedBytes)
 	proof := plonk.NewProof(ecc.BN254)
 	if _, err := proof.ReadFrom(bytes.NewReader(proofDecodedBytes)); err != nil {
 		panic(err)
@@ -38,10 +44,10 @@ func VerifyPlonk(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkeyHa
 
 	// Compute the public witness.
 	circuit := Circuit{
-		Vars:                 []frontend.Variable{},
-		Felts:                []babybear.Variable{},
-		Exts:                 []babybear.ExtensionVariable{},
-		VkeyHash:             verifyCmdVkeyHash,
+		Vars:                  []frontend.Variable{},
+		Felts:                 []babybear.Variable{},
+		Exts:                  []babybear.ExtensionVariable{},
+		VkeyHash:              verifyCmdVkeyHash,
 		CommittedValuesDigest: verifyCmdCommittedValuesDigest,
 	}
 	witness, err := frontend.NewWitness(&circuit, ecc.BN254.ScalarField())
@@ -55,10 +61,16 @@ func VerifyPlonk(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkeyHa
 
 	// Verify proof.
 	err = plonk.Verify(proof, vk, publicWitness)
+	audit.finish(err)
 	return err
 }
 
 func VerifyGroth16(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkeyHash string, verifyCmdCommittedValuesDigest string) error {
+	audit := startAudit("verify", "groth16")
+	audit.VkeyHash = verifyCmdVkeyHash
+	audit.CommittedValuesDigest = verifyCmdCommittedValuesDigest
+	defer audit.finishOnPanic()
+
 	// Sanity check the required arguments have been provided.
 	if verifyCmdDataDir == "" {
 		panic("--data is required")
@@ -69,6 +81,7 @@ func VerifyGroth16(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkey
 	if err != nil {
 		panic(err)
 	}
+	audit.ProofDigest = digest(proofDecodedBytes)
 	proof := groth16.NewProof(ecc.BN254)
 	if _, err := proof.ReadFrom(bytes.NewReader(proofDecodedBytes)); err != nil {
 		panic(err)
@@ -84,10 +97,10 @@ func VerifyGroth16(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkey
 
 	// Compute the public witness.
 	circuit := Circuit{
-		Vars:                 []frontend.Variable{},
-		Felts:                []babybear.Variable{},
-		Exts:                 []babybear.ExtensionVariable{},
-		VkeyHash:             verifyCmdVkeyHash,
+		Vars:                  []frontend.Variable{},
+		Felts:                 []babybear.Variable{},
+		Exts:                  []babybear.ExtensionVariable{},
+		VkeyHash:              verifyCmdVkeyHash,
 		CommittedValuesDigest: verifyCmdCommittedValuesDigest,
 	}
 	witness, err := frontend.NewWitness(&circuit, ecc.BN254.ScalarField())
@@ -101,5 +114,6 @@ func VerifyGroth16(verifyCmdDataDir string, verifyCmdProof string, verifyCmdVkey
 
 	// Verify proof.
 	err = groth16.Verify(proof, vk, publicWitness)
+	audit.finish(err)
 	return err
 }
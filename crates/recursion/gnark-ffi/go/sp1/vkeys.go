@@ -0,0 +1,126 @@
+package sp1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// VkeyRegistry records the verifying keys produced by the build command. It maps a circuit
+// version to the hashes of the vkeys built for it, and each vkey hash to the SHA-256 digests of
+// the artifacts it was built with, so services can pin the vkeys they accept.
+//
+// Vkey hashes are the SHA-256 of the serialized vkey file, like PlonkBn254Prover::get_vkey_hash
+// on the Rust side.
+type VkeyRegistry map[string]map[string]map[string]string
+
+// ReadVkeyRegistry reads a registry file. A missing file is an empty registry.
+func ReadVkeyRegistry(path string) (VkeyRegistry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return VkeyRegistry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var registry VkeyRegistry
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, err
+	}
+	if registry == nil {
+		registry = VkeyRegistry{}
+	}
+	return registry, nil
+}
+
+func (r VkeyRegistry) Write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Versions returns the circuit versions a vkey hash was built for.
+func (r VkeyRegistry) Versions(vkeyHash string) []string {
+	var versions []string
+	for version, vkeys := range r {
+		if _, ok := vkeys[vkeyHash]; ok {
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// IsAccepted reports whether vkeyHash was built for the given circuit version.
+func (r VkeyRegistry) IsAccepted(version, vkeyHash string) bool {
+	_, ok := r[version][vkeyHash]
+	return ok
+}
+
+// Artifacts returns the artifact digests recorded for a vkey.
+func (r VkeyRegistry) Artifacts(version, vkeyHash string) (map[string]string, bool) {
+	artifacts, ok := r[version][vkeyHash]
+	return artifacts, ok
+}
+
+// recordVkey adds the vkey and artifacts written by a build to the registry in dataDir, under the
+// circuit version the Rust build passes, or SP1_CIRCUIT_VERSION if it is empty (e.g. from the
+// config of the CLI). Vkeys of dev profile circuits are recorded under the version suffixed with
+// -dev-insecure.
+func recordVkey(dataDir string, version string, vkPath string, artifactPaths []string) {
+	if version == "" {
+		version = os.Getenv("SP1_CIRCUIT_VERSION")
+	}
+	if version == "" {
+		version = "unknown"
+	}
+	if config, err := ReadFriConfig(dataDir); err == nil && config.IsDev() {
+		version += "-dev-insecure"
+	}
+
+	vkeyHash, err := fileDigest(dataDir + "/" + vkPath)
+	if err != nil {
+		panic(err)
+	}
+	artifacts := make(map[string]string)
+	for _, path := range artifactPaths {
+		digest, err := fileDigest(dataDir + "/" + path)
+		if err != nil {
+			panic(err)
+		}
+		artifacts[path] = digest
+	}
+
+	registryPath := dataDir + "/" + vkeyRegistryPath
+	registry, err := ReadVkeyRegistry(registryPath)
+	if err != nil {
+		panic(err)
+	}
+	if registry[version] == nil {
+		registry[version] = make(map[string]map[string]string)
+	}
+	registry[version]["0x"+vkeyHash] = artifacts
+	if err := registry.Write(registryPath); err != nil {
+		panic(err)
+	}
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
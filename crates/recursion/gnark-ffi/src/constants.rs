@@ -0,0 +1,88 @@
+use p3_baby_bear::{MONTY_INVERSE, POSEIDON2_INTERNAL_MATRIX_DIAG_16_BABYBEAR_MONTY};
+use p3_field::{PrimeField, PrimeField32};
+use serde::Serialize;
+use sp1_primitives::RC_16_30_U32;
+use sp1_recursion_core::stark::bn254_poseidon2_rc3;
+
+/// The constants the Gnark circuit needs to mirror the BabyBear Poseidon2 permutation and the
+/// BN254 Poseidon2 permutation of the outer challenger. They are exported to
+/// `go/sp1/constants/constants.json`, from which `go generate` produces the Go tables.
+#[derive(Debug, Clone, Serialize)]
+pub struct GnarkConstants {
+    pub rc16: Vec<[u32; 16]>,
+    pub internal_diag_m1: [u32; 16],
+    pub monty_inverse: u32,
+    /// The round constants of the width 3 BN254 permutation, as 0x-prefixed 32 byte hex.
+    pub rc3: Vec<[String; 3]>,
+}
+
+impl GnarkConstants {
+    pub fn new() -> Self {
+        Self {
+            rc16: RC_16_30_U32.to_vec(),
+            internal_diag_m1: POSEIDON2_INTERNAL_MATRIX_DIAG_16_BABYBEAR_MONTY
+                .map(|x| x.as_canonical_u32()),
+            monty_inverse: MONTY_INVERSE.as_canonical_u32(),
+            rc3: bn254_poseidon2_rc3()
+                .into_iter()
+                .map(|round| {
+                    round.map(|x| format!("0x{:0>64}", x.as_canonical_biguint().to_str_radix(16)))
+                })
+                .collect(),
+        }
+    }
+
+    /// Serializes the constants in the format checked into the Go module.
+    pub fn to_json(&self) -> String {
+        serde_json::to_string_pretty(self).unwrap() + "\n"
+    }
+}
+
+impl Default for GnarkConstants {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::GnarkConstants;
+    use p3_field::PrimeField;
+    use sha2::{Digest, Sha256};
+    use sp1_recursion_core::stark::bn254_poseidon2_rc3;
+
+    /// The SHA-256 of the BN254 round constants as 32 byte big-endian integers, also checked by
+    /// the Go tests, so the outer challenger and the circuit cannot use different constants.
+    const RC3_DIGEST: &str = "c08ebd7b8765286f6933070d74e9a1859072e58fb7d18d17f9ca97872b93f4a2";
+
+    #[test]
+    fn test_rc3_digest() {
+        let mut hasher = Sha256::new();
+        for round in bn254_poseidon2_rc3() {
+            for x in round {
+                let bytes = x.as_canonical_biguint().to_bytes_be();
+                let mut padded = [0u8; 32];
+                padded[32 - bytes.len()..].copy_from_slice(&bytes);
+                hasher.update(padded);
+            }
+        }
+        assert_eq!(hex::encode(hasher.finalize()), RC3_DIGEST);
+    }
+
+    /// Checks that the constants checked into the Go module match the Rust crates. Run with
+    /// `UPDATE_GNARK_CONSTANTS=1` to rewrite the file, then run `go generate ./...` in `go/`.
+    #[test]
+    fn test_gnark_constants_are_up_to_date() {
+        let path = std::path::Path::new(env!("CARGO_MANIFEST_DIR"))
+            .join("go/sp1/constants/constants.json");
+        let expected = GnarkConstants::new().to_json();
+        if std::env::var("UPDATE_GNARK_CONSTANTS").is_ok() {
+            std::fs::write(&path, &expected).unwrap();
+        }
+        let actual = std::fs::read_to_string(&path).unwrap();
+        assert_eq!(
+            actual, expected,
+            "go/sp1/constants/constants.json is out of date, rerun with UPDATE_GNARK_CONSTANTS=1"
+        );
+    }
+}
@@ -1,8 +1,20 @@
+use super::{Compatibility, GoRuntimeConfig};
 use crate::ProofBn254;
 use crate::{Groth16Bn254Proof, PlonkBn254Proof};
 use anyhow::{anyhow, Result};
 use sp1_core_machine::SP1_CIRCUIT_VERSION;
-use std::{io::Write, process::Command};
+use sp1_primitives::io::SP1PublicValues;
+use std::{io::Write, process::Command, sync::Mutex};
+
+/// The config file passed to the containers, see [load_config].
+static CONFIG_PATH: Mutex<Option<String>> = Mutex::new(None);
+
+/// The checkpoint dir passed to the prove containers, see [set_checkpoint_dir].
+static CHECKPOINT_DIR: Mutex<Option<String>> = Mutex::new(None);
+
+/// The Go runtime config passed to the containers.
+static GO_RUNTIME_CONFIG: Mutex<GoRuntimeConfig> =
+    Mutex::new(GoRuntimeConfig { memory_limit: None, gc_percent: None });
 
 /// Represents the proof system being used
 enum ProofSystem {
@@ -45,8 +57,23 @@ fn call_docker(args: &[&str], mounts: &[(&str, &str)]) -> Result<()> {
     for (src, dest) in mounts {
         cmd.arg("-v").arg(format!("{}:{}", src, dest));
     }
+    let config = *GO_RUNTIME_CONFIG.lock().unwrap();
+    if let Some(memory_limit) = config.memory_limit {
+        cmd.arg("-e").arg(format!("GOMEMLIMIT={}", memory_limit));
+    }
+    if let Some(gc_percent) = config.gc_percent {
+        let gogc = if gc_percent < 0 { "off".to_string() } else { gc_percent.to_string() };
+        cmd.arg("-e").arg(format!("GOGC={}", gogc));
+    }
+    let config_path = CONFIG_PATH.lock().unwrap().clone();
+    if let Some(config_path) = &config_path {
+        cmd.arg("-v").arg(format!("{}:/config.json", config_path));
+    }
     cmd.arg(get_docker_image());
     cmd.args(args);
+    if config_path.is_some() {
+        cmd.args(["--config", "/config.json"]);
+    }
     let result = cmd.status()?;
     if !result.success() {
         log::error!("Failed to run `docker run`: {:?}", cmd);
@@ -58,16 +85,19 @@ fn call_docker(args: &[&str], mounts: &[(&str, &str)]) -> Result<()> {
 
 fn prove(system: ProofSystem, data_dir: &str, witness_path: &str) -> Result<Vec<u8>> {
     let output_file = tempfile::NamedTempFile::new()?;
-    let mounts = [
+    let mut mounts = vec![
         (data_dir, "/circuit"),
         (witness_path, "/witness"),
         (output_file.path().to_str().unwrap(), "/output"),
     ];
+    let mut args = vec!["prove", "--system", system.as_str(), "/circuit", "/witness", "/output"];
+    let checkpoint_dir = CHECKPOINT_DIR.lock().unwrap().clone();
+    if let Some(checkpoint_dir) = &checkpoint_dir {
+        mounts.push((checkpoint_dir.as_str(), "/checkpoint"));
+        args.extend(["--checkpoint-dir", "/checkpoint"]);
+    }
     assert_docker();
-    call_docker(
-        &["prove", "--system", system.as_str(), "/circuit", "/witness", "/output"],
-        &mounts,
-    )?;
+    call_docker(&args, &mounts)?;
     Ok(std::fs::read(output_file.path())?)
 }
 
@@ -163,6 +193,30 @@ pub fn verify_groth16_bn254(
     verify(ProofSystem::Groth16, data_dir, proof, vkey_hash, committed_values_digest)
 }
 
+/// Verifies a PLONK proof against its raw public values. The digest is computed like
+/// `sp1.CommittedValuesDigest` before calling the container.
+pub fn verify_plonk_bn254_public_values(
+    data_dir: &str,
+    proof: &str,
+    vkey_hash: &str,
+    public_values: &[u8],
+) -> Result<()> {
+    let digest = SP1PublicValues::from(public_values).hash_bn254().to_string();
+    verify(ProofSystem::Plonk, data_dir, proof, vkey_hash, &digest)
+}
+
+/// Verifies a Groth16 proof against its raw public values, see
+/// [verify_plonk_bn254_public_values].
+pub fn verify_groth16_bn254_public_values(
+    data_dir: &str,
+    proof: &str,
+    vkey_hash: &str,
+    public_values: &[u8],
+) -> Result<()> {
+    let digest = SP1PublicValues::from(public_values).hash_bn254().to_string();
+    verify(ProofSystem::Groth16, data_dir, proof, vkey_hash, &digest)
+}
+
 fn test(system: ProofSystem, witness_json: &str, constraints_json: &str) -> Result<()> {
     let mounts = [(constraints_json, "/constraints"), (witness_json, "/witness")];
     assert_docker();
@@ -177,6 +231,111 @@ pub fn test_groth16_bn254(witness_json: &str, constraints_json: &str) {
     test(ProofSystem::Groth16, witness_json, constraints_json).expect("failed to test with docker");
 }
 
+/// Writes a support bundle for the circuit in `data_dir` to `output_path`.
+pub fn write_support_bundle(data_dir: &str, output_path: &str) -> Result<()> {
+    std::fs::File::create(output_path)?;
+    let mounts = [(data_dir, "/circuit"), (output_path, "/output")];
+    assert_docker();
+    call_docker(&["support-bundle", "/circuit", "/output"], &mounts)
+}
+
+/// Writes the compatibility matrix of the artifacts in `data_dir` to `output_path` as JSON.
+pub fn write_compatibility_matrix(data_dir: &str, output_path: &str) -> Result<()> {
+    std::fs::File::create(output_path)?;
+    let mounts = [(data_dir, "/circuit"), (output_path, "/output")];
+    assert_docker();
+    call_docker(&["compatibility-matrix", "/circuit", "/output"], &mounts)
+}
+
+/// Proves the witness at `witness_path` with the `system` circuit (`plonk` or `groth16`) in
+/// `data_dir` and writes a conformance bundle for alternative verifiers to `output_path`.
+pub fn write_conformance_bundle(
+    data_dir: &str,
+    system: &str,
+    witness_path: &str,
+    output_path: &str,
+) -> Result<()> {
+    std::fs::File::create(output_path)?;
+    let mounts = [(data_dir, "/circuit"), (witness_path, "/witness"), (output_path, "/output")];
+    assert_docker();
+    call_docker(
+        &["conformance-bundle", "--system", system, "/circuit", "/witness", "/output"],
+        &mounts,
+    )
+}
+
+/// Proves a witness with the artifacts of `old_dir` and of `new_dir` and writes the deltas of
+/// their measurements to `output_path`, see the native `bench_compare`.
+pub fn bench_compare(
+    old_dir: &str,
+    new_dir: &str,
+    system: &str,
+    witness_path: Option<&str>,
+    output_path: &str,
+) -> Result<()> {
+    std::fs::File::create(output_path)?;
+    let mut mounts = vec![(old_dir, "/old"), (new_dir, "/new"), (output_path, "/output")];
+    let mut args = vec!["bench", "--compare", "/old", "/new", "--system", system];
+    if let Some(witness_path) = witness_path {
+        mounts.push((witness_path, "/witness"));
+        args.extend(["--witness", "/witness"]);
+    }
+    args.push("/output");
+    assert_docker();
+    call_docker(&args, &mounts)
+}
+
+/// Replays the challenger transcript of the witness at `witness_path` outside of the circuit in
+/// `data_dir` and writes it to `output_path`.
+pub fn replay_transcript(data_dir: &str, witness_path: &str, output_path: &str) -> Result<()> {
+    std::fs::File::create(output_path)?;
+    let mounts = [(data_dir, "/circuit"), (witness_path, "/witness"), (output_path, "/output")];
+    assert_docker();
+    call_docker(&["transcript", "/circuit", "/witness", "/output"], &mounts)
+}
+
+/// Returns the combinations of circuit version, system, vkey hash, witness schema version and ABI
+/// version supported with the artifacts in `data_dir`, one per vkey recorded by the builds.
+pub fn compatibility_matrix(data_dir: &str) -> Result<Vec<Compatibility>> {
+    let output_file = tempfile::NamedTempFile::new()?;
+    write_compatibility_matrix(data_dir, output_file.path().to_str().unwrap())?;
+    Ok(serde_json::from_slice(&std::fs::read(output_file.path())?)?)
+}
+
+/// Writes a copy of the witness at `input_path` with its program data randomized to
+/// `output_path`.
+pub fn anonymize_witness(input_path: &str, output_path: &str) -> Result<()> {
+    std::fs::File::create(output_path)?;
+    let mounts = [(input_path, "/witness"), (output_path, "/output")];
+    assert_docker();
+    call_docker(&["anonymize-witness", "/witness", "/output"], &mounts)
+}
+
+/// Makes the containers started from now on load the JSON config file at `path`.
+pub fn load_config(path: &str) -> Result<()> {
+    let path = std::fs::canonicalize(path)?;
+    *CONFIG_PATH.lock().unwrap() = Some(path.to_string_lossy().into_owned());
+    Ok(())
+}
+
+/// Enables proof checkpoints in `dir` for the containers started from now on, or disables them if
+/// `dir` is empty.
+pub fn set_checkpoint_dir(dir: &str) -> Result<()> {
+    let dir = if dir.is_empty() {
+        None
+    } else {
+        std::fs::create_dir_all(dir)?;
+        Some(std::fs::canonicalize(dir)?.to_string_lossy().into_owned())
+    };
+    *CHECKPOINT_DIR.lock().unwrap() = dir;
+    Ok(())
+}
+
+/// Applies `config` to the Go runtime of the containers started from now on.
+pub fn set_go_runtime_config(config: &GoRuntimeConfig) {
+    *GO_RUNTIME_CONFIG.lock().unwrap() = *config;
+}
+
 pub fn test_babybear_poseidon2() {
     unimplemented!()
 }
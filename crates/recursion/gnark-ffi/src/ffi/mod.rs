@@ -7,3 +7,71 @@ cfg_if::cfg_if! {
         pub use docker::*;
     }
 }
+
+use serde::{Deserialize, Serialize};
+
+/// The version of the C interface of the Go library these bindings call, see `sp1.AbiVersion`.
+pub const ABI_VERSION: u32 = 1;
+
+/// The version of the witness JSON written by this crate, see `sp1.WitnessSchemaVersion`.
+pub const WITNESS_SCHEMA_VERSION: u32 = 1;
+
+/// A combination of artifacts and interfaces supported by the gnark prover, as returned by
+/// `compatibility_matrix`.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Compatibility {
+    pub circuit_version: String,
+    /// `plonk` or `groth16`.
+    pub system: String,
+    pub vkey_hash: String,
+    pub witness_schema_version: u32,
+    pub abi_version: u32,
+}
+
+impl Compatibility {
+    /// Returns whether the entry can be used with the witnesses and bindings of this crate.
+    pub fn is_supported(&self) -> bool {
+        self.witness_schema_version == WITNESS_SCHEMA_VERSION && self.abi_version == ABI_VERSION
+    }
+}
+
+/// Memory settings of the Go runtime running the gnark prover, applied with
+/// `set_go_runtime_config`. Fields left to `None` keep the runtime's current setting.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub struct GoRuntimeConfig {
+    /// Soft limit on the memory used by the Go runtime in bytes, like `GOMEMLIMIT`.
+    pub memory_limit: Option<u64>,
+    /// Garbage collection target percentage, like `GOGC`. A negative value disables the
+    /// collector until the memory limit is reached.
+    pub gc_percent: Option<i32>,
+}
+
+impl GoRuntimeConfig {
+    /// Reads the config from `SP1_GO_MEMORY_LIMIT` (in bytes) and `SP1_GO_GC_PERCENT`.
+    pub fn from_env() -> Self {
+        Self {
+            memory_limit: std::env::var("SP1_GO_MEMORY_LIMIT")
+                .ok()
+                .map(|value| value.parse().expect("SP1_GO_MEMORY_LIMIT must be a number of bytes")),
+            gc_percent: std::env::var("SP1_GO_GC_PERCENT")
+                .ok()
+                .map(|value| value.parse().expect("SP1_GO_GC_PERCENT must be an integer")),
+        }
+    }
+
+    /// Limits the Go runtime to `fraction` of the total memory of the machine, as reported by
+    /// `/proc/meminfo`. Leaves the limit unset if the total memory is unknown.
+    pub fn with_memory_fraction(mut self, fraction: f64) -> Self {
+        assert!(fraction > 0.0 && fraction <= 1.0, "memory fraction must be in (0, 1]");
+        self.memory_limit = total_memory().map(|total| (total as f64 * fraction) as u64);
+        self
+    }
+}
+
+/// Returns MemTotal from `/proc/meminfo`, in bytes.
+fn total_memory() -> Option<u64> {
+    let meminfo = std::fs::read_to_string("/proc/meminfo").ok()?;
+    let line = meminfo.lines().find(|line| line.starts_with("MemTotal:"))?;
+    let kib: u64 = line.split_whitespace().nth(1)?.parse().ok()?;
+    Some(kib * 1024)
+}
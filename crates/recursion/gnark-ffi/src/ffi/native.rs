@@ -5,11 +5,12 @@
 //! Although we cast to *mut c_char because the Go signatures can't be immutable, the Go functions
 //! should not modify the strings.
 
+use super::{Compatibility, GoRuntimeConfig};
 use crate::{Groth16Bn254Proof, PlonkBn254Proof};
 use cfg_if::cfg_if;
 use sp1_core_machine::SP1_CIRCUIT_VERSION;
 use std::{
-    ffi::{c_char, CStr, CString},
+    ffi::{c_char, c_int, c_void, CStr, CString},
     mem::forget,
 };
 
@@ -30,7 +31,7 @@ enum ProofResult {
 }
 
 impl ProofSystem {
-    fn build_fn(&self) -> unsafe extern "C" fn(*mut c_char) {
+    fn build_fn(&self) -> unsafe extern "C" fn(*mut c_char, *mut c_char) -> *mut c_char {
         match self {
             ProofSystem::Plonk => bind::BuildPlonkBn254,
             ProofSystem::Groth16 => bind::BuildGroth16Bn254,
@@ -44,6 +45,21 @@ impl ProofSystem {
         }
     }
 
+    fn prove_chunked_fn(
+        &self,
+    ) -> unsafe extern "C" fn(
+        *mut c_char,
+        *mut c_char,
+        WriteChunkFn,
+        *mut c_void,
+        usize,
+    ) -> *mut c_char {
+        match self {
+            ProofSystem::Plonk => bind::ProvePlonkBn254Chunked,
+            ProofSystem::Groth16 => bind::ProveGroth16Bn254Chunked,
+        }
+    }
+
     fn verify_fn(
         &self,
     ) -> unsafe extern "C" fn(*mut c_char, *mut c_char, *mut c_char, *mut c_char) -> *mut c_char
@@ -54,6 +70,16 @@ impl ProofSystem {
         }
     }
 
+    fn verify_public_values_fn(
+        &self,
+    ) -> unsafe extern "C" fn(*mut c_char, *mut c_char, *mut c_char, *mut c_char) -> *mut c_char
+    {
+        match self {
+            ProofSystem::Plonk => bind::VerifyPlonkBn254PublicValues,
+            ProofSystem::Groth16 => bind::VerifyGroth16Bn254PublicValues,
+        }
+    }
+
     fn test_fn(&self) -> unsafe extern "C" fn(*mut c_char, *mut c_char) -> *mut c_char {
         match self {
             ProofSystem::Plonk => bind::TestPlonkBn254,
@@ -69,8 +95,16 @@ enum ProveFunction {
 
 fn build(system: ProofSystem, data_dir: &str) {
     let data_dir = CString::new(data_dir).expect("CString::new failed");
+    // Recorded by the Go build in the vkeys.json registry.
+    let circuit_version = CString::new(SP1_CIRCUIT_VERSION).expect("CString::new failed");
     unsafe {
-        (system.build_fn())(data_dir.as_ptr() as *mut c_char);
+        let err_ptr = (system.build_fn())(
+            data_dir.as_ptr() as *mut c_char,
+            circuit_version.as_ptr() as *mut c_char,
+        );
+        if !err_ptr.is_null() {
+            panic!("Build failed: {}", ptr_to_string_freed(err_ptr));
+        }
     }
 }
 
@@ -94,25 +128,101 @@ fn prove(system: ProofSystem, data_dir: &str, witness_path: &str) -> ProofResult
     }
 }
 
+/// Forwards a chunk written by the Go prover to the Rust closure behind `ctx`.
+unsafe extern "C" fn write_chunk<F: FnMut(&[u8]) -> bool>(
+    ctx: *mut c_void,
+    data: *const c_char,
+    len: usize,
+) -> c_int {
+    let on_chunk = &mut *(ctx as *mut F);
+    let chunk = std::slice::from_raw_parts(data as *const u8, len);
+    if on_chunk(chunk) {
+        0
+    } else {
+        1
+    }
+}
+
+fn prove_chunked<F: FnMut(&[u8]) -> bool>(
+    system: ProofSystem,
+    data_dir: &str,
+    witness_path: &str,
+    chunk_size: usize,
+    mut on_chunk: F,
+) -> Result<(), String> {
+    let data_dir = CString::new(data_dir).expect("CString::new failed");
+    let witness_path = CString::new(witness_path).expect("CString::new failed");
+
+    let err_ptr = unsafe {
+        (system.prove_chunked_fn())(
+            data_dir.as_ptr() as *mut c_char,
+            witness_path.as_ptr() as *mut c_char,
+            Some(write_chunk::<F>),
+            &mut on_chunk as *mut F as *mut c_void,
+            chunk_size,
+        )
+    };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
 fn verify(
     system: ProofSystem,
     data_dir: &str,
     proof: &str,
     vkey_hash: &str,
     committed_values_digest: &str,
+) -> Result<(), String> {
+    call_verify(system.verify_fn(), data_dir, proof, vkey_hash, committed_values_digest)
+}
+
+fn verify_public_values(
+    system: ProofSystem,
+    data_dir: &str,
+    proof: &str,
+    vkey_hash: &str,
+    public_values: &[u8],
+) -> Result<(), String> {
+    call_verify(
+        system.verify_public_values_fn(),
+        data_dir,
+        proof,
+        vkey_hash,
+        &hex::encode(public_values),
+    )
+}
+
+/// Calls a Go verify function, whose last argument is either the committed values digest or the
+/// hex encoded public values.
+fn call_verify(
+    verify_fn: unsafe extern "C" fn(
+        *mut c_char,
+        *mut c_char,
+        *mut c_char,
+        *mut c_char,
+    ) -> *mut c_char,
+    data_dir: &str,
+    proof: &str,
+    vkey_hash: &str,
+    public_values: &str,
 ) -> Result<(), String> {
     let data_dir = CString::new(data_dir).expect("CString::new failed");
     let proof = CString::new(proof).expect("CString::new failed");
     let vkey_hash = CString::new(vkey_hash).expect("CString::new failed");
-    let committed_values_digest =
-        CString::new(committed_values_digest).expect("CString::new failed");
+    let public_values = CString::new(public_values).expect("CString::new failed");
 
     let err_ptr = unsafe {
-        (system.verify_fn())(
+        verify_fn(
             data_dir.as_ptr() as *mut c_char,
             proof.as_ptr() as *mut c_char,
             vkey_hash.as_ptr() as *mut c_char,
-            committed_values_digest.as_ptr() as *mut c_char,
+            public_values.as_ptr() as *mut c_char,
         )
     };
     if err_ptr.is_null() {
@@ -153,6 +263,17 @@ pub fn prove_plonk_bn254(data_dir: &str, witness_path: &str) -> PlonkBn254Proof
     }
 }
 
+/// Proves like [prove_plonk_bn254], but streams the JSON encoded proof to `on_chunk` in chunks
+/// of at most `chunk_size` bytes. Returning `false` from `on_chunk` aborts the stream.
+pub fn prove_plonk_bn254_chunked(
+    data_dir: &str,
+    witness_path: &str,
+    chunk_size: usize,
+    on_chunk: impl FnMut(&[u8]) -> bool,
+) -> Result<(), String> {
+    prove_chunked(ProofSystem::Plonk, data_dir, witness_path, chunk_size, on_chunk)
+}
+
 pub fn verify_plonk_bn254(
     data_dir: &str,
     proof: &str,
@@ -162,6 +283,17 @@ pub fn verify_plonk_bn254(
     verify(ProofSystem::Plonk, data_dir, proof, vkey_hash, committed_values_digest)
 }
 
+/// Verifies a PLONK proof against its raw public values, whose digest is computed by the Go
+/// library, so that it cannot be checked against the digest of other values by mistake.
+pub fn verify_plonk_bn254_public_values(
+    data_dir: &str,
+    proof: &str,
+    vkey_hash: &str,
+    public_values: &[u8],
+) -> Result<(), String> {
+    verify_public_values(ProofSystem::Plonk, data_dir, proof, vkey_hash, public_values)
+}
+
 pub fn test_plonk_bn254(witness_json: &str, constraints_json: &str) {
     test(ProofSystem::Plonk, witness_json, constraints_json)
 }
@@ -177,6 +309,17 @@ pub fn prove_groth16_bn254(data_dir: &str, witness_path: &str) -> Groth16Bn254Pr
     }
 }
 
+/// Proves like [prove_groth16_bn254], but streams the JSON encoded proof to `on_chunk` in chunks
+/// of at most `chunk_size` bytes. Returning `false` from `on_chunk` aborts the stream.
+pub fn prove_groth16_bn254_chunked(
+    data_dir: &str,
+    witness_path: &str,
+    chunk_size: usize,
+    on_chunk: impl FnMut(&[u8]) -> bool,
+) -> Result<(), String> {
+    prove_chunked(ProofSystem::Groth16, data_dir, witness_path, chunk_size, on_chunk)
+}
+
 pub fn verify_groth16_bn254(
     data_dir: &str,
     proof: &str,
@@ -186,10 +329,232 @@ pub fn verify_groth16_bn254(
     verify(ProofSystem::Groth16, data_dir, proof, vkey_hash, committed_values_digest)
 }
 
+/// Verifies a Groth16 proof against its raw public values, see
+/// [verify_plonk_bn254_public_values].
+pub fn verify_groth16_bn254_public_values(
+    data_dir: &str,
+    proof: &str,
+    vkey_hash: &str,
+    public_values: &[u8],
+) -> Result<(), String> {
+    verify_public_values(ProofSystem::Groth16, data_dir, proof, vkey_hash, public_values)
+}
+
 pub fn test_groth16_bn254(witness_json: &str, constraints_json: &str) {
     test(ProofSystem::Groth16, witness_json, constraints_json)
 }
 
+/// Writes a support bundle for the circuit in `data_dir` to `output_path`.
+pub fn write_support_bundle(data_dir: &str, output_path: &str) -> Result<(), String> {
+    let data_dir = CString::new(data_dir).expect("CString::new failed");
+    let output_path = CString::new(output_path).expect("CString::new failed");
+
+    let err_ptr = unsafe {
+        bind::WriteSupportBundle(
+            data_dir.as_ptr() as *mut c_char,
+            output_path.as_ptr() as *mut c_char,
+        )
+    };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Writes the compatibility matrix of the artifacts in `data_dir` to `output_path` as JSON.
+pub fn write_compatibility_matrix(data_dir: &str, output_path: &str) -> Result<(), String> {
+    let data_dir = CString::new(data_dir).expect("CString::new failed");
+    let output_path = CString::new(output_path).expect("CString::new failed");
+
+    let err_ptr = unsafe {
+        bind::WriteCompatibilityMatrix(
+            data_dir.as_ptr() as *mut c_char,
+            output_path.as_ptr() as *mut c_char,
+        )
+    };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Proves the witness at `witness_path` with the `system` circuit (`plonk` or `groth16`) in
+/// `data_dir` and writes a conformance bundle for alternative verifiers to `output_path`.
+pub fn write_conformance_bundle(
+    data_dir: &str,
+    system: &str,
+    witness_path: &str,
+    output_path: &str,
+) -> Result<(), String> {
+    let data_dir = CString::new(data_dir).expect("CString::new failed");
+    let system = CString::new(system).expect("CString::new failed");
+    let witness_path = CString::new(witness_path).expect("CString::new failed");
+    let output_path = CString::new(output_path).expect("CString::new failed");
+
+    let err_ptr = unsafe {
+        bind::WriteConformanceBundle(
+            data_dir.as_ptr() as *mut c_char,
+            system.as_ptr() as *mut c_char,
+            witness_path.as_ptr() as *mut c_char,
+            output_path.as_ptr() as *mut c_char,
+        )
+    };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Proves a witness with the artifacts of `old_dir` and of `new_dir` and writes the constraints,
+/// prove time, peak heap and proof size of both, with their deltas, to `output_path` as JSON.
+/// Without `witness_path`, each set of artifacts proves the witness it was built with.
+pub fn bench_compare(
+    old_dir: &str,
+    new_dir: &str,
+    system: &str,
+    witness_path: Option<&str>,
+    output_path: &str,
+) -> Result<(), String> {
+    let old_dir = CString::new(old_dir).expect("CString::new failed");
+    let new_dir = CString::new(new_dir).expect("CString::new failed");
+    let system = CString::new(system).expect("CString::new failed");
+    let witness_path = CString::new(witness_path.unwrap_or("")).expect("CString::new failed");
+    let output_path = CString::new(output_path).expect("CString::new failed");
+
+    let err_ptr = unsafe {
+        bind::BenchCompare(
+            old_dir.as_ptr() as *mut c_char,
+            new_dir.as_ptr() as *mut c_char,
+            system.as_ptr() as *mut c_char,
+            witness_path.as_ptr() as *mut c_char,
+            output_path.as_ptr() as *mut c_char,
+        )
+    };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Replays the challenger transcript of the witness at `witness_path` outside of the circuit in
+/// `data_dir` and writes every duplexing, with its samples, to `output_path`.
+pub fn replay_transcript(
+    data_dir: &str,
+    witness_path: &str,
+    output_path: &str,
+) -> Result<(), String> {
+    let data_dir = CString::new(data_dir).expect("CString::new failed");
+    let witness_path = CString::new(witness_path).expect("CString::new failed");
+    let output_path = CString::new(output_path).expect("CString::new failed");
+
+    let err_ptr = unsafe {
+        bind::ReplayTranscript(
+            data_dir.as_ptr() as *mut c_char,
+            witness_path.as_ptr() as *mut c_char,
+            output_path.as_ptr() as *mut c_char,
+        )
+    };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Returns the combinations of circuit version, system, vkey hash, witness schema version and ABI
+/// version supported with the artifacts in `data_dir`, one per vkey recorded by the builds.
+pub fn compatibility_matrix(data_dir: &str) -> Result<Vec<Compatibility>, String> {
+    let output_file = tempfile::NamedTempFile::new().map_err(|e| e.to_string())?;
+    write_compatibility_matrix(data_dir, output_file.path().to_str().unwrap())?;
+    let data = std::fs::read(output_file.path()).map_err(|e| e.to_string())?;
+    serde_json::from_slice(&data).map_err(|e| e.to_string())
+}
+
+/// Writes a copy of the witness at `input_path` to `output_path`, with every value that could
+/// carry program data randomized. The shape of the witness and malformed values are kept, so
+/// that parser and shape errors still reproduce.
+pub fn anonymize_witness(input_path: &str, output_path: &str) -> Result<(), String> {
+    let input_path = CString::new(input_path).expect("CString::new failed");
+    let output_path = CString::new(output_path).expect("CString::new failed");
+
+    let err_ptr = unsafe {
+        bind::AnonymizeWitness(
+            input_path.as_ptr() as *mut c_char,
+            output_path.as_ptr() as *mut c_char,
+        )
+    };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Enables proof checkpoints in `dir` for the Go prover of this process, or disables them if `dir`
+/// is empty. A proof interrupted after it was computed is then returned by the next call for the
+/// same witness instead of being proven again.
+pub fn set_checkpoint_dir(dir: &str) -> Result<(), String> {
+    let dir = CString::new(dir).expect("CString::new failed");
+    let err_ptr = unsafe { bind::SetCheckpointDir(dir.as_ptr() as *mut c_char) };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Reads the JSON config file at `path` and applies it to the Go prover of this process. Settings
+/// applied afterwards, e.g. with [set_go_runtime_config], override the file.
+pub fn load_config(path: &str) -> Result<(), String> {
+    let path = CString::new(path).expect("CString::new failed");
+    let err_ptr = unsafe { bind::LoadConfig(path.as_ptr() as *mut c_char) };
+    if err_ptr.is_null() {
+        Ok(())
+    } else {
+        unsafe {
+            // Safety: The error message is returned from the go code and is guaranteed to be valid.
+            Err(ptr_to_string_freed(err_ptr))
+        }
+    }
+}
+
+/// Applies `config` to the Go runtime of this process.
+pub fn set_go_runtime_config(config: &GoRuntimeConfig) {
+    unsafe {
+        if let Some(memory_limit) = config.memory_limit {
+            bind::SetMemoryLimit(memory_limit.min(i64::MAX as u64) as i64);
+        }
+        if let Some(gc_percent) = config.gc_percent {
+            bind::SetGCPercent(gc_percent);
+        }
+    }
+}
+
 pub fn test_babybear_poseidon2() {
     unsafe {
         let err_ptr = bind::TestPoseidonBabyBear2();
@@ -222,6 +587,11 @@ unsafe fn ptr_to_string_freed(input: *mut c_char) -> String {
 
 impl PlonkBn254Proof {
     unsafe fn from_raw(c_proof: *mut C_PlonkBn254Proof) -> Self {
+        if !(*c_proof).Error.is_null() {
+            let err = ptr_to_string_cloned((*c_proof).Error);
+            bind::FreePlonkBn254Proof(c_proof);
+            panic!("Prove failed: {}", err);
+        }
         let proof = PlonkBn254Proof {
             public_inputs: [
                 ptr_to_string_cloned((*c_proof).PublicInputs[0]),
@@ -238,6 +608,11 @@ impl PlonkBn254Proof {
 
 impl Groth16Bn254Proof {
     unsafe fn from_raw(c_proof: *mut C_Groth16Bn254Proof) -> Self {
+        if !(*c_proof).Error.is_null() {
+            let err = ptr_to_string_cloned((*c_proof).Error);
+            bind::FreeGroth16Bn254Proof(c_proof);
+            panic!("Prove failed: {}", err);
+        }
         let proof = Groth16Bn254Proof {
             public_inputs: [
                 ptr_to_string_cloned((*c_proof).PublicInputs[0]),
@@ -1,5 +1,6 @@
 mod babybear;
 
+pub mod constants;
 pub mod ffi;
 pub mod groth16_bn254;
 pub mod plonk_bn254;
@@ -44,7 +44,7 @@ impl PlonkBn254Prover {
 
         // Write witness.
         let mut witness_file = tempfile::NamedTempFile::new().unwrap();
-        let gnark_witness = GnarkWitness::new(witness);
+        let gnark_witness = GnarkWitness::new(witness).with_shape_digest(serialized.as_bytes());
         let serialized = serde_json::to_string(&gnark_witness).unwrap();
         witness_file.write_all(serialized.as_bytes()).unwrap();
 
@@ -65,7 +65,7 @@ impl PlonkBn254Prover {
 
         // Write witness.
         let witness_path = build_dir.join("plonk_witness.json");
-        let gnark_witness = GnarkWitness::new(witness);
+        let gnark_witness = GnarkWitness::new(witness).with_shape_digest(serialized.as_bytes());
         let mut file = File::create(witness_path).unwrap();
         let serialized = serde_json::to_string(&gnark_witness).unwrap();
         file.write_all(serialized.as_bytes()).unwrap();
@@ -90,7 +90,8 @@ impl PlonkBn254Prover {
     pub fn prove<C: Config>(&self, witness: Witness<C>, build_dir: PathBuf) -> PlonkBn254Proof {
         // Write witness.
         let mut witness_file = tempfile::NamedTempFile::new().unwrap();
-        let gnark_witness = GnarkWitness::new(witness);
+        let constraints_json = std::fs::read(build_dir.join("constraints.json")).unwrap();
+        let gnark_witness = GnarkWitness::new(witness).with_shape_digest(&constraints_json);
         let serialized = serde_json::to_string(&gnark_witness).unwrap();
         witness_file.write_all(serialized.as_bytes()).unwrap();
 
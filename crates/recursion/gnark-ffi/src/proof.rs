@@ -1,4 +1,7 @@
+use anyhow::{anyhow, ensure, Context, Result};
+use num_bigint::BigUint;
 use serde::{Deserialize, Serialize};
+use sha2::{Digest, Sha256};
 
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub enum ProofBn254 {
@@ -21,3 +24,147 @@ pub struct Groth16Bn254Proof {
     pub raw_proof: String,
     pub groth16_vkey_hash: [u8; 32],
 }
+
+const ENVELOPE_DOMAIN: &[u8] = b"SP1_PROOF_ENVELOPE_V1";
+
+impl ProofBn254 {
+    /// A canonical hash of the proof, its public inputs and the hash of the vkey it verifies
+    /// under, for use as a receipt or deduplication ID.
+    ///
+    /// The hash does not depend on how the values are written down: public inputs may be decimal
+    /// or 0x-prefixed hex, and the raw proof hex may use either case and an optional 0x prefix.
+    /// The encoded proof is derived from the raw proof and is not hashed.
+    ///
+    /// Fails if a public input is not an integer of at most 32 bytes or the raw proof is not hex.
+    pub fn envelope_hash(&self) -> Result<[u8; 32]> {
+        match self {
+            ProofBn254::Plonk(proof) => proof.envelope_hash(),
+            ProofBn254::Groth16(proof) => proof.envelope_hash(),
+        }
+    }
+}
+
+impl PlonkBn254Proof {
+    /// See [ProofBn254::envelope_hash].
+    pub fn envelope_hash(&self) -> Result<[u8; 32]> {
+        envelope_hash(0, &self.plonk_vkey_hash, &self.public_inputs, &self.raw_proof)
+    }
+}
+
+impl Groth16Bn254Proof {
+    /// See [ProofBn254::envelope_hash].
+    pub fn envelope_hash(&self) -> Result<[u8; 32]> {
+        envelope_hash(1, &self.groth16_vkey_hash, &self.public_inputs, &self.raw_proof)
+    }
+}
+
+/// Hashes the domain, the proof system, the vkey hash, each public input as a 32 byte big-endian
+/// integer and the length-prefixed raw proof bytes.
+fn envelope_hash(
+    system: u8,
+    vkey_hash: &[u8; 32],
+    public_inputs: &[String; 2],
+    raw_proof: &str,
+) -> Result<[u8; 32]> {
+    let mut hasher = Sha256::new();
+    hasher.update(ENVELOPE_DOMAIN);
+    hasher.update([system]);
+    hasher.update(vkey_hash);
+    for public_input in public_inputs {
+        let value = match public_input.strip_prefix("0x") {
+            Some(hex) => BigUint::parse_bytes(hex.as_bytes(), 16),
+            None => BigUint::parse_bytes(public_input.as_bytes(), 10),
+        }
+        .ok_or_else(|| anyhow!("invalid public input {public_input:?}"))?;
+        let bytes = value.to_bytes_be();
+        ensure!(bytes.len() <= 32, "public input does not fit in 32 bytes");
+        let mut padded = [0u8; 32];
+        padded[32 - bytes.len()..].copy_from_slice(&bytes);
+        hasher.update(padded);
+    }
+    let raw_proof = hex::decode(raw_proof.strip_prefix("0x").unwrap_or(raw_proof))
+        .context("invalid raw proof hex")?;
+    hasher.update((raw_proof.len() as u64).to_be_bytes());
+    hasher.update(&raw_proof);
+    Ok(hasher.finalize().into())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn proof() -> PlonkBn254Proof {
+        PlonkBn254Proof {
+            public_inputs: ["1".to_string(), "255".to_string()],
+            encoded_proof: "00".to_string(),
+            raw_proof: "abcdef".to_string(),
+            plonk_vkey_hash: [7; 32],
+        }
+    }
+
+    #[test]
+    fn test_envelope_hash_is_stable_across_encodings() {
+        let expected = proof().envelope_hash().unwrap();
+
+        let mut reencoded = proof();
+        reencoded.public_inputs = ["0x01".to_string(), "0xff".to_string()];
+        reencoded.raw_proof = "0xABCDEF".to_string();
+        reencoded.encoded_proof = "11".to_string();
+        assert_eq!(reencoded.envelope_hash().unwrap(), expected);
+
+        let json: ProofBn254 =
+            serde_json::from_str(&serde_json::to_string(&ProofBn254::Plonk(proof())).unwrap())
+                .unwrap();
+        assert_eq!(json.envelope_hash().unwrap(), expected);
+
+        // Computed independently from the definition in envelope_hash.
+        assert_eq!(
+            hex::encode(expected),
+            "d03189fd96973b0db2939e7a911c591d6a23078c98d2584a50f90060eb70c6c9"
+        );
+
+        let groth16 = Groth16Bn254Proof {
+            public_inputs: proof().public_inputs,
+            encoded_proof: proof().encoded_proof,
+            raw_proof: proof().raw_proof,
+            groth16_vkey_hash: proof().plonk_vkey_hash,
+        };
+        assert_ne!(groth16.envelope_hash().unwrap(), expected);
+    }
+
+    #[test]
+    fn test_envelope_hash_binds_the_envelope() {
+        let expected = proof().envelope_hash().unwrap();
+        let tampered: [fn(&mut PlonkBn254Proof); 4] = [
+            |proof| proof.public_inputs[0] = "2".to_string(),
+            |proof| proof.public_inputs.swap(0, 1),
+            |proof| proof.raw_proof = "abcdee".to_string(),
+            |proof| proof.plonk_vkey_hash[31] = 8,
+        ];
+        for tamper in tampered {
+            let mut proof = proof();
+            tamper(&mut proof);
+            assert_ne!(proof.envelope_hash().unwrap(), expected);
+        }
+
+        // The raw proof is length-prefixed, so leading zero bytes are not dropped.
+        let mut padded = proof();
+        padded.raw_proof = "00abcdef".to_string();
+        assert_ne!(padded.envelope_hash().unwrap(), expected);
+    }
+
+    #[test]
+    fn test_envelope_hash_rejects_malformed_envelopes() {
+        let malformed: [fn(&mut PlonkBn254Proof); 4] = [
+            |proof| proof.public_inputs[1] = format!("0x1{}", "0".repeat(64)),
+            |proof| proof.public_inputs[0] = "one".to_string(),
+            |proof| proof.public_inputs[0] = "0x".to_string(),
+            |proof| proof.raw_proof = "abc".to_string(),
+        ];
+        for malform in malformed {
+            let mut proof = proof();
+            malform(&mut proof);
+            assert!(proof.envelope_hash().is_err());
+        }
+    }
+}
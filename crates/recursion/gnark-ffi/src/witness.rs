@@ -1,7 +1,9 @@
 use std::{fs::File, io::Write};
 
+use num_bigint::BigUint;
 use p3_field::{AbstractExtensionField, AbstractField, PrimeField};
 use serde::{Deserialize, Serialize};
+use sha2::{Digest, Sha256};
 use sp1_recursion_compiler::ir::{Config, Witness};
 
 /// A witness that can be used to initialize values for witness generation inside Gnark.
@@ -12,6 +14,16 @@ pub struct GnarkWitness {
     pub exts: Vec<Vec<String>>,
     pub vkey_hash: String,
     pub committed_values_digest: String,
+    /// The digest of the constraint system this witness was generated for, see [shape_digest].
+    #[serde(default)]
+    pub shape_digest: String,
+}
+
+/// Computes the digest the Gnark circuit embeds for a serialized constraint system: the first 31
+/// bytes of its SHA-256 hash, as a big-endian integer.
+pub fn shape_digest(constraints_json: &[u8]) -> String {
+    let hash = Sha256::digest(constraints_json);
+    BigUint::from_bytes_be(&hash[..31]).to_string()
 }
 
 impl GnarkWitness {
@@ -39,9 +51,16 @@ impl GnarkWitness {
                 .committed_values_digest
                 .as_canonical_biguint()
                 .to_string(),
+            shape_digest: String::new(),
         }
     }
 
+    /// Declares the constraint system this witness belongs to.
+    pub fn with_shape_digest(mut self, constraints_json: &[u8]) -> Self {
+        self.shape_digest = shape_digest(constraints_json);
+        self
+    }
+
     /// Saves the witness to a given path.
     pub fn save(&self, path: &str) {
         let serialized = serde_json::to_string(self).unwrap();
@@ -11,7 +11,7 @@ use {
     futures::StreamExt,
     indicatif::{ProgressBar, ProgressStyle},
     reqwest::Client,
-    std::{cmp::min, process::Command},
+    std::{cmp::min, path::Path, process::Command},
 };
 
 use crate::SP1_CIRCUIT_VERSION;
@@ -68,11 +68,16 @@ pub fn try_install_circuit_artifacts(artifacts_type: &str) -> PathBuf {
 ///
 /// This function will download the latest circuit artifacts from the S3 bucket and extract them
 /// to the directory specified by [`groth16_bn254_artifacts_dir()`].
+///
+/// The artifacts are extracted to a staging directory next to `build_dir`, which is only renamed
+/// to `build_dir` once complete. If the process is interrupted, e.g. by a pod shutting down during
+/// startup, no partial `build_dir` is left behind to be mistaken for installed artifacts.
 #[cfg(any(feature = "network", feature = "network"))]
 #[allow(clippy::needless_pass_by_value)]
 pub fn install_circuit_artifacts(build_dir: PathBuf, artifacts_type: &str) {
-    // Create the build directory.
-    std::fs::create_dir_all(&build_dir).expect("failed to create build directory");
+    // Create the parent of the build directory, which holds the staging directory.
+    let parent = build_dir.parent().expect("build directory has no parent");
+    std::fs::create_dir_all(parent).expect("failed to create build directory");
 
     // Download the artifacts.
     let download_url =
@@ -83,21 +88,32 @@ pub fn install_circuit_artifacts(build_dir: PathBuf, artifacts_type: &str) {
     block_on(download_file(&client, &download_url, &mut artifacts_tar_gz_file))
         .expect("failed to download file");
 
-    // Extract the tarball to the build directory.
-    let mut res = Command::new("tar")
-        .args([
-            "-Pxzf",
-            artifacts_tar_gz_file.path().to_str().unwrap(),
-            "-C",
-            build_dir.to_str().unwrap(),
-        ])
-        .spawn()
-        .expect("failed to extract tarball");
-    res.wait().unwrap();
+    extract_circuit_artifacts(artifacts_tar_gz_file.path(), &build_dir);
 
     println!("[sp1] downloaded {} to {:?}", download_url, build_dir.to_str().unwrap(),);
 }
 
+/// Extracts the artifacts tarball to a staging directory next to `build_dir`, and renames it to
+/// `build_dir` once the extraction has succeeded.
+#[cfg(any(feature = "network", feature = "network"))]
+fn extract_circuit_artifacts(tarball: &Path, build_dir: &Path) {
+    let parent = build_dir.parent().expect("build directory has no parent");
+    let staging_dir = tempfile::tempdir_in(parent).expect("failed to create staging directory");
+    let status = Command::new("tar")
+        .args(["-Pxzf", tarball.to_str().unwrap(), "-C", staging_dir.path().to_str().unwrap()])
+        .status()
+        .expect("failed to extract tarball");
+    assert!(status.success(), "failed to extract tarball: {status}");
+
+    // Move the complete artifacts into place. Another process may have installed them meanwhile,
+    // in which case the staging directory is dropped.
+    let staging_dir = staging_dir.into_path();
+    if let Err(err) = std::fs::rename(&staging_dir, build_dir) {
+        std::fs::remove_dir_all(&staging_dir).ok();
+        assert!(build_dir.exists(), "failed to move artifacts to {}: {err}", build_dir.display());
+    }
+}
+
 /// Download the file with a progress bar that indicates the progress.
 #[cfg(any(feature = "network", feature = "network"))]
 pub async fn download_file(
@@ -128,3 +144,68 @@ pub async fn download_file(
 
     Ok(())
 }
+
+#[cfg(all(test, any(feature = "network", feature = "network")))]
+mod tests {
+    use super::*;
+
+    /// Creates a tarball of the given files, laid out like the published artifacts.
+    fn tarball(dir: &Path, files: &[(&str, &str)]) -> PathBuf {
+        let contents = dir.join("contents");
+        std::fs::create_dir(&contents).unwrap();
+        for (name, data) in files {
+            std::fs::write(contents.join(name), data).unwrap();
+        }
+        let tarball = dir.join("artifacts.tar.gz");
+        let status = Command::new("tar")
+            .args(["-czf", tarball.to_str().unwrap(), "-C", contents.to_str().unwrap(), "."])
+            .status()
+            .unwrap();
+        assert!(status.success());
+        tarball
+    }
+
+    /// Returns the entries of the circuits directory, to check that no staging directory is left.
+    fn entries(dir: &Path) -> Vec<String> {
+        let mut entries: Vec<String> = std::fs::read_dir(dir)
+            .unwrap()
+            .map(|entry| entry.unwrap().file_name().into_string().unwrap())
+            .collect();
+        entries.sort();
+        entries
+    }
+
+    #[test]
+    fn test_extract_circuit_artifacts() {
+        let dir = tempfile::tempdir().unwrap();
+        let tarball = tarball(dir.path(), &[("groth16_vk.bin", "vk"), ("groth16_pk.bin", "pk")]);
+        let circuits = dir.path().join("circuits");
+        std::fs::create_dir(&circuits).unwrap();
+        let build_dir = circuits.join("v4.0.0");
+
+        extract_circuit_artifacts(&tarball, &build_dir);
+        assert_eq!(std::fs::read_to_string(build_dir.join("groth16_vk.bin")).unwrap(), "vk");
+        assert_eq!(std::fs::read_to_string(build_dir.join("groth16_pk.bin")).unwrap(), "pk");
+        assert_eq!(entries(&circuits), ["v4.0.0"]);
+
+        // Artifacts installed meanwhile by another process are kept.
+        std::fs::write(build_dir.join("groth16_vk.bin"), "other vk").unwrap();
+        extract_circuit_artifacts(&tarball, &build_dir);
+        assert_eq!(std::fs::read_to_string(build_dir.join("groth16_vk.bin")).unwrap(), "other vk");
+        assert_eq!(entries(&circuits), ["v4.0.0"]);
+    }
+
+    #[test]
+    fn test_extract_circuit_artifacts_leaves_nothing_on_failure() {
+        let dir = tempfile::tempdir().unwrap();
+        let tarball = dir.path().join("truncated.tar.gz");
+        std::fs::write(&tarball, [0x1f, 0x8b, 0x08]).unwrap();
+        let circuits = dir.path().join("circuits");
+        std::fs::create_dir(&circuits).unwrap();
+        let build_dir = circuits.join("v4.0.0");
+
+        let result = std::panic::catch_unwind(|| extract_circuit_artifacts(&tarball, &build_dir));
+        assert!(result.is_err());
+        assert!(entries(&circuits).is_empty());
+    }
+}